@@ -0,0 +1,52 @@
+// Arquivo: backend/cmd/mock-oidc/main.go
+
+// Servidor OIDC local para testar o login social sem Google/Apple.
+//
+//	go run ./cmd/mock-oidc
+//
+// e no .env do backend:
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:9999
+//	OIDC_MOCK_CLIENT_ID=finplay
+//	OIDC_MOCK_CLIENT_SECRET=segredo
+//	OIDC_MOCK_REDIRECT_URL=http://localhost:8080/api/auth/oidc/callback
+package main
+
+import (
+	"finplay/backend/oidc/mockoidc"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9999", "endereço do servidor mock")
+	clientID := flag.String("client-id", "finplay", "client_id aceito")
+	clientSecret := flag.String("client-secret", "segredo", "client_secret aceito")
+	email := flag.String("email", "usuario.mock@gmail.com", "email do usuário autenticado")
+	subject := flag.String("sub", "mock-user-1", "subject do usuário autenticado")
+	verified := flag.Bool("email-verified", true, "marcar email como verificado")
+	flag.Parse()
+
+	issuer := "http://" + *addr
+	server, err := mockoidc.New(issuer, *clientID, *clientSecret)
+	if err != nil {
+		slog.Error("erro ao criar servidor mock", "error", err)
+		os.Exit(1)
+	}
+	server.SetUser(mockoidc.User{
+		Subject:       *subject,
+		Email:         *email,
+		EmailVerified: *verified,
+		Name:          "Usuário Mock",
+	})
+
+	slog.Info("mock OIDC rodando", "issuer", issuer, "discovery", issuer+"/.well-known/openid-configuration")
+
+	if err := http.ListenAndServe(*addr, server.Handler()); err != nil {
+		slog.Error("erro no servidor mock", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,41 @@
+// Arquivo: backend/cmd/pix-simulator/main.go
+
+// PSP PIX local para testar pagamentos sem banco real.
+//
+//	go run ./cmd/pix-simulator
+//
+// no .env do backend:
+//
+//	PIX_KEY=pagamentos@finplay.com.br
+//	PIX_WEBHOOK_SECRET=segredo-do-webhook-pix
+//	PIX_PSP_URL=http://localhost:9998
+//
+// e para "pagar" uma cobrança, envie o copia e cola retornado por
+// POST /api/orders/pix:
+//
+//	curl -X POST localhost:9998/pagar -d '{"codigo":"000201..."}'
+package main
+
+import (
+	"finplay/backend/payments/pix/pixsim"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9998", "endereço do simulador")
+	webhook := flag.String("webhook", "http://localhost:8080/api/webhooks/pix", "URL do webhook PIX do backend")
+	secret := flag.String("secret", "segredo-do-webhook-pix", "segredo HMAC compartilhado (PIX_WEBHOOK_SECRET)")
+	flag.Parse()
+
+	server := pixsim.New(*webhook, *secret)
+
+	slog.Info("simulador PIX rodando", "addr", *addr, "webhook", *webhook)
+
+	if err := http.ListenAndServe(*addr, server.Handler()); err != nil {
+		slog.Error("erro no simulador PIX", "error", err)
+		os.Exit(1)
+	}
+}
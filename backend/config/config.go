@@ -0,0 +1,409 @@
+// Arquivo: backend/config/config.go
+
+// Configuração tipada da aplicação. Ordem de precedência (a última vence):
+// valores padrão, arquivo YAML (CONFIG_FILE ou ./config.yaml) e variáveis de
+// ambiente (incluindo as do .env, carregado antes pelo main).
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Secret padrão, aceito apenas em desenvolvimento quando JWT_SECRET não é definido
+const DevJWTSecret = "seu-secret-super-secreto-aqui"
+
+type Config struct {
+	Env         string            `yaml:"env"`
+	Server      ServerConfig      `yaml:"server"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Password    PasswordConfig    `yaml:"password"`
+	LLM         LLMConfig         `yaml:"llm"`
+	Log         LogConfig         `yaml:"log"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	App         AppConfig         `yaml:"app"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+	OpenAPI     OpenAPIConfig     `yaml:"openapi"`
+	Payments    PaymentsConfig    `yaml:"payments"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Promotions  PromotionsConfig  `yaml:"promotions"`
+	Loyalty     LoyaltyConfig     `yaml:"loyalty"`
+	Taxes       TaxesConfig       `yaml:"taxes"`
+	Delivery    DeliveryConfig    `yaml:"delivery"`
+	Receipts    ReceiptsConfig    `yaml:"receipts"`
+}
+
+type ServerConfig struct {
+	Port              int           `yaml:"port"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	// Considerar X-Forwarded-For/-Proto (apenas atrás de um proxy reverso confiável)
+	TrustProxyHeaders bool       `yaml:"trust_proxy_headers"`
+	TLS               TLSConfig  `yaml:"tls"`
+	HSTS              HSTSConfig `yaml:"hsts"`
+}
+
+// HTTPS servido pelo próprio backend: certificado em arquivo ou, apenas em
+// desenvolvimento, autoassinado gerado na inicialização
+type TLSConfig struct {
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	SelfSigned bool   `yaml:"self_signed"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.SelfSigned
+}
+
+// Strict-Transport-Security, enviado apenas em respostas HTTPS
+type HSTSConfig struct {
+	MaxAge            time.Duration `yaml:"max_age"` // 0 desativa
+	IncludeSubdomains bool          `yaml:"include_subdomains"`
+}
+
+func (s ServerConfig) Addr() string {
+	return ":" + strconv.Itoa(s.Port)
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type DatabaseConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	SSLRootCert     string        `yaml:"sslrootcert"` // CA para verify-ca/verify-full
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+}
+
+type AuthConfig struct {
+	JWTSecret    string        `yaml:"jwt_secret"`
+	SessionTTL   time.Duration `yaml:"session_ttl"`
+	CookieSecure bool          `yaml:"cookie_secure"` // Secure + prefixo __Host- (exige HTTPS)
+}
+
+type PasswordConfig struct {
+	MinLength     int  `yaml:"min_length"`
+	MinScore      int  `yaml:"min_score"`
+	CheckBreached bool `yaml:"check_breached"`
+	BcryptCost    int  `yaml:"bcrypt_cost"`
+}
+
+type LLMConfig struct {
+	APIKey  string `yaml:"api_key"`
+	Model   string `yaml:"model"`
+	BaseURL string `yaml:"base_url"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn, error
+	Format string `yaml:"format"` // json ou text
+}
+
+type MetricsConfig struct {
+	// Se definido, /metrics exige "Authorization: Bearer <token>"
+	Token string `yaml:"token"`
+}
+
+type AppConfig struct {
+	// URL pública do frontend usada em links de email e redirecionamentos
+	URL string `yaml:"url"`
+}
+
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig `yaml:"providers"`
+}
+
+type OpenAPIConfig struct {
+	// Validar requisições contra openapi.yaml: auto (só em desenvolvimento), on ou off
+	ValidateRequests string `yaml:"validate_requests"`
+}
+
+type IdempotencyConfig struct {
+	// Por quanto tempo a resposta de um POST com Idempotency-Key é repetida
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type PromotionsConfig struct {
+	// Fuso da loja (IANA) para validade e dias da semana das promoções
+	Timezone string `yaml:"timezone"`
+}
+
+// Programa de fidelidade: pontos ganhos em pedidos finalizados e resgatados
+// como desconto em novos pedidos
+type LoyaltyConfig struct {
+	PointsPerReal   int           `yaml:"points_per_real"`   // pontos por R$ 1 pago (0 desativa o acúmulo)
+	PointValueCents int           `yaml:"point_value_cents"` // valor de cada ponto no resgate
+	PointsTTL       time.Duration `yaml:"points_ttl"`        // validade dos pontos ganhos
+	// Parte máxima do total do pedido paga com pontos (0 desativa o resgate)
+	MaxRedeemPercent int `yaml:"max_redeem_percent"`
+}
+
+type TaxesConfig struct {
+	// Jurisdição (UF) da loja, usada para escolher as regras de tributo
+	Jurisdiction string `yaml:"jurisdiction"`
+}
+
+// Taxa de entrega dos pedidos com delivery (não tributada)
+type DeliveryConfig struct {
+	FeeCents int `yaml:"fee_cents"`
+	// Pedidos a partir desse valor (já com descontos) não pagam a taxa (0 desativa)
+	FreeAboveCents int `yaml:"free_above_cents"`
+}
+
+// Cupom fiscal eletrônico (NFC-e) dos pedidos finalizados
+type ReceiptsConfig struct {
+	Authority   string `yaml:"authority"`   // autorizador (hoje apenas "stub", local)
+	Environment string `yaml:"environment"` // homologation ou production (tpAmb)
+	Series      int    `yaml:"series"`
+	// Emitente
+	CNPJ              string `yaml:"cnpj"` // só dígitos
+	CompanyName       string `yaml:"company_name"`
+	StateRegistration string `yaml:"state_registration"` // inscrição estadual
+	Address           string `yaml:"address"`
+	City              string `yaml:"city"`
+	CityCode          int    `yaml:"city_code"`  // código IBGE do município
+	State             string `yaml:"state"`      // UF
+	StateCode         int    `yaml:"state_code"` // código IBGE da UF
+	// Código de segurança do contribuinte (QR Code da NFC-e)
+	CSCID      string `yaml:"csc_id"`
+	CSC        string `yaml:"csc"`
+	ConsultURL string `yaml:"consult_url"` // consulta pública da SEFAZ (QR Code)
+	// Carga tributária aproximada (Lei 12.741/2012) impressa no cupom de
+	// pedidos sem tributos calculados (anteriores às regras de tributo)
+	ApproxTaxPercent float64 `yaml:"approx_tax_percent"`
+}
+
+type PaymentsConfig struct {
+	Gateway  string `yaml:"gateway"`  // provedor de pagamento (hoje apenas "fake")
+	Currency string `yaml:"currency"` // ISO 4217
+	// Capturar logo após a autorização; se false, a captura é uma chamada separada
+	AutoCapture bool `yaml:"auto_capture"`
+	// Latência simulada pelo gateway falso
+	FakeLatency time.Duration `yaml:"fake_latency"`
+	PIX         PIXConfig     `yaml:"pix"`
+}
+
+// Recebimento via PIX (ativo quando há chave configurada)
+type PIXConfig struct {
+	Key          string        `yaml:"key"`           // chave PIX recebedora
+	MerchantName string        `yaml:"merchant_name"` // até 25 caracteres no BR Code
+	MerchantCity string        `yaml:"merchant_city"` // até 15 caracteres no BR Code
+	ChargeTTL    time.Duration `yaml:"charge_ttl"`    // validade da cobrança
+	// Segredo HMAC das notificações do PSP (cabeçalho X-Pix-Signature)
+	WebhookSecret string `yaml:"webhook_secret"`
+	// API PIX do PSP para devoluções e remoção de cobranças; vazio = estorno
+	// de PIX indisponível
+	PSPURL string `yaml:"psp_url"`
+}
+
+func (p PIXConfig) Enabled() bool {
+	return p.Key != ""
+}
+
+type OIDCProviderConfig struct {
+	Name         string `yaml:"name"`
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RedirectURL  string `yaml:"redirect_url"`
+}
+
+// Valores padrão (adequados para desenvolvimento local)
+func Default() Config {
+	return Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Port:              8080,
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			// O chat aguarda a resposta do LLM, então a escrita precisa de folga
+			WriteTimeout:    60 * time.Second,
+			IdleTimeout:     120 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			HSTS: HSTSConfig{
+				MaxAge: 365 * 24 * time.Hour,
+			},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:3000", "http://localhost:3001"},
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Name:            "finplay_db",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+		Auth: AuthConfig{
+			JWTSecret:  DevJWTSecret, // rejeitado pela validação em produção
+			SessionTTL: 24 * time.Hour,
+		},
+		Password: PasswordConfig{
+			MinLength:     8,
+			MinScore:      2,
+			CheckBreached: true,
+			BcryptCost:    10,
+		},
+		LLM: LLMConfig{
+			Model:   "llama-3.1-8b-instant",
+			BaseURL: "https://api.groq.com/openai/v1",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		App: AppConfig{
+			URL: "http://localhost:3000",
+		},
+		OpenAPI: OpenAPIConfig{
+			ValidateRequests: "auto",
+		},
+		Payments: PaymentsConfig{
+			Gateway:     "fake",
+			Currency:    "BRL",
+			AutoCapture: true,
+			PIX: PIXConfig{
+				MerchantName: "FinPlay",
+				MerchantCity: "Sao Paulo",
+				ChargeTTL:    30 * time.Minute,
+			},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Promotions: PromotionsConfig{
+			Timezone: "America/Sao_Paulo",
+		},
+		Loyalty: LoyaltyConfig{
+			PointsPerReal:    1,
+			PointValueCents:  5,
+			PointsTTL:        365 * 24 * time.Hour,
+			MaxRedeemPercent: 50,
+		},
+		Taxes: TaxesConfig{
+			Jurisdiction: "SP",
+		},
+		Delivery: DeliveryConfig{
+			FeeCents: 800,
+		},
+		Receipts: ReceiptsConfig{
+			Authority:         "stub",
+			Environment:       "homologation",
+			Series:            1,
+			CNPJ:              "11222333000181",
+			CompanyName:       "FinPlay Restaurante LTDA",
+			StateRegistration: "110042490114",
+			Address:           "Av. Paulista, 1000",
+			City:              "Sao Paulo",
+			CityCode:          3550308,
+			State:             "SP",
+			StateCode:         35,
+			CSCID:             "000001",
+			ConsultURL:        "https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode",
+			ApproxTaxPercent:  16.5,
+		},
+	}
+}
+
+// Carregar configuração: padrão, YAML opcional e variáveis de ambiente
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = "config.yaml"
+	}
+	if path != "" {
+		if err := loadYAML(&cfg, path, explicit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.normalize()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Ler YAML por cima dos valores atuais. O arquivo padrão é opcional; um
+// CONFIG_FILE explícito precisa existir.
+func loadYAML(cfg *Config, path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // chave desconhecida costuma ser erro de digitação
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("erro ao interpretar %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) normalize() {
+	c.Env = strings.ToLower(strings.TrimSpace(c.Env))
+	c.Log.Level = strings.ToLower(c.Log.Level)
+	c.Log.Format = strings.ToLower(c.Log.Format)
+	c.App.URL = strings.TrimRight(c.App.URL, "/")
+	c.LLM.BaseURL = strings.TrimRight(c.LLM.BaseURL, "/")
+	c.OpenAPI.ValidateRequests = strings.ToLower(c.OpenAPI.ValidateRequests)
+	c.Payments.Gateway = strings.ToLower(strings.TrimSpace(c.Payments.Gateway))
+	c.Payments.Currency = strings.ToUpper(strings.TrimSpace(c.Payments.Currency))
+	c.Payments.PIX.Key = strings.TrimSpace(c.Payments.PIX.Key)
+	c.Taxes.Jurisdiction = strings.ToUpper(strings.TrimSpace(c.Taxes.Jurisdiction))
+	for i := range c.OIDC.Providers {
+		c.OIDC.Providers[i].Name = strings.ToLower(strings.TrimSpace(c.OIDC.Providers[i].Name))
+	}
+}
+
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Validação de requisições pelo contrato OpenAPI está ativa?
+func (c *Config) ValidateRequests() bool {
+	switch c.OpenAPI.ValidateRequests {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return c.Env == EnvDevelopment
+}
@@ -0,0 +1,213 @@
+// Arquivo: backend/config/env.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Leitor de variáveis de ambiente que acumula erros de conversão
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) string(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func (e *envReader) int(dst *int, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: número inválido %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) float(dst *float64, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: número inválido %q", key, v))
+		return
+	}
+	*dst = f
+}
+
+func (e *envReader) bool(dst *bool, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: booleano inválido %q", key, v))
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) duration(dst *time.Duration, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: duração inválida %q (ex.: 15s, 1h)", key, v))
+		return
+	}
+	*dst = d
+}
+
+// Lista separada por vírgulas
+func (e *envReader) list(dst *[]string, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+// Sobrescrever a configuração com as variáveis de ambiente
+func applyEnv(cfg *Config) error {
+	var e envReader
+
+	e.string(&cfg.Env, "APP_ENV")
+
+	e.int(&cfg.Server.Port, "PORT")
+	e.duration(&cfg.Server.ReadTimeout, "HTTP_READ_TIMEOUT")
+	e.duration(&cfg.Server.ReadHeaderTimeout, "HTTP_READ_HEADER_TIMEOUT")
+	e.duration(&cfg.Server.WriteTimeout, "HTTP_WRITE_TIMEOUT")
+	e.duration(&cfg.Server.IdleTimeout, "HTTP_IDLE_TIMEOUT")
+	e.duration(&cfg.Server.ShutdownTimeout, "HTTP_SHUTDOWN_TIMEOUT")
+	e.bool(&cfg.Server.TrustProxyHeaders, "TRUST_PROXY_HEADERS")
+	e.string(&cfg.Server.TLS.CertFile, "TLS_CERT_FILE")
+	e.string(&cfg.Server.TLS.KeyFile, "TLS_KEY_FILE")
+	e.bool(&cfg.Server.TLS.SelfSigned, "TLS_SELF_SIGNED")
+	e.duration(&cfg.Server.HSTS.MaxAge, "HSTS_MAX_AGE")
+	e.bool(&cfg.Server.HSTS.IncludeSubdomains, "HSTS_INCLUDE_SUBDOMAINS")
+
+	e.list(&cfg.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+
+	e.string(&cfg.Database.Host, "DB_HOST")
+	e.int(&cfg.Database.Port, "DB_PORT")
+	e.string(&cfg.Database.User, "DB_USER")
+	e.string(&cfg.Database.Password, "DB_PASSWORD")
+	e.string(&cfg.Database.Name, "DB_NAME")
+	e.string(&cfg.Database.SSLMode, "DB_SSLMODE")
+	e.string(&cfg.Database.SSLRootCert, "DB_SSLROOTCERT")
+	e.int(&cfg.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	e.int(&cfg.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	e.duration(&cfg.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+
+	e.string(&cfg.Auth.JWTSecret, "JWT_SECRET")
+	e.duration(&cfg.Auth.SessionTTL, "SESSION_TTL")
+	e.bool(&cfg.Auth.CookieSecure, "COOKIE_SECURE")
+
+	e.int(&cfg.Password.MinLength, "PASSWORD_MIN_LENGTH")
+	e.int(&cfg.Password.MinScore, "PASSWORD_MIN_SCORE")
+	e.bool(&cfg.Password.CheckBreached, "PASSWORD_CHECK_BREACHED")
+	e.int(&cfg.Password.BcryptCost, "BCRYPT_COST")
+
+	e.string(&cfg.LLM.APIKey, "GROQ_API_KEY")
+	e.string(&cfg.LLM.Model, "GROQ_MODEL")
+	e.string(&cfg.LLM.BaseURL, "GROQ_BASE_URL")
+
+	e.string(&cfg.Log.Level, "LOG_LEVEL")
+	e.string(&cfg.Log.Format, "LOG_FORMAT")
+
+	e.string(&cfg.Metrics.Token, "METRICS_TOKEN")
+
+	e.string(&cfg.App.URL, "APP_URL")
+
+	e.string(&cfg.OpenAPI.ValidateRequests, "OPENAPI_VALIDATE_REQUESTS")
+
+	e.string(&cfg.Payments.Gateway, "PAYMENTS_GATEWAY")
+	e.string(&cfg.Payments.Currency, "PAYMENTS_CURRENCY")
+	e.bool(&cfg.Payments.AutoCapture, "PAYMENTS_AUTO_CAPTURE")
+	e.duration(&cfg.Payments.FakeLatency, "PAYMENTS_FAKE_LATENCY")
+	e.string(&cfg.Payments.PIX.Key, "PIX_KEY")
+	e.string(&cfg.Payments.PIX.MerchantName, "PIX_MERCHANT_NAME")
+	e.string(&cfg.Payments.PIX.MerchantCity, "PIX_MERCHANT_CITY")
+	e.duration(&cfg.Payments.PIX.ChargeTTL, "PIX_CHARGE_TTL")
+	e.string(&cfg.Payments.PIX.WebhookSecret, "PIX_WEBHOOK_SECRET")
+	e.string(&cfg.Payments.PIX.PSPURL, "PIX_PSP_URL")
+
+	e.duration(&cfg.Idempotency.TTL, "IDEMPOTENCY_TTL")
+
+	e.string(&cfg.Promotions.Timezone, "PROMOTIONS_TIMEZONE")
+
+	e.int(&cfg.Loyalty.PointsPerReal, "LOYALTY_POINTS_PER_REAL")
+	e.int(&cfg.Loyalty.PointValueCents, "LOYALTY_POINT_VALUE_CENTS")
+	e.duration(&cfg.Loyalty.PointsTTL, "LOYALTY_POINTS_TTL")
+	e.int(&cfg.Loyalty.MaxRedeemPercent, "LOYALTY_MAX_REDEEM_PERCENT")
+
+	e.string(&cfg.Taxes.Jurisdiction, "TAXES_JURISDICTION")
+
+	e.int(&cfg.Delivery.FeeCents, "DELIVERY_FEE_CENTS")
+	e.int(&cfg.Delivery.FreeAboveCents, "DELIVERY_FREE_ABOVE_CENTS")
+
+	e.string(&cfg.Receipts.Authority, "RECEIPTS_AUTHORITY")
+	e.string(&cfg.Receipts.Environment, "RECEIPTS_ENVIRONMENT")
+	e.int(&cfg.Receipts.Series, "RECEIPTS_SERIES")
+	e.string(&cfg.Receipts.CNPJ, "RECEIPTS_CNPJ")
+	e.string(&cfg.Receipts.CompanyName, "RECEIPTS_COMPANY_NAME")
+	e.string(&cfg.Receipts.StateRegistration, "RECEIPTS_STATE_REGISTRATION")
+	e.string(&cfg.Receipts.Address, "RECEIPTS_ADDRESS")
+	e.string(&cfg.Receipts.City, "RECEIPTS_CITY")
+	e.int(&cfg.Receipts.CityCode, "RECEIPTS_CITY_CODE")
+	e.string(&cfg.Receipts.State, "RECEIPTS_STATE")
+	e.int(&cfg.Receipts.StateCode, "RECEIPTS_STATE_CODE")
+	e.string(&cfg.Receipts.CSCID, "RECEIPTS_CSC_ID")
+	e.string(&cfg.Receipts.CSC, "RECEIPTS_CSC")
+	e.string(&cfg.Receipts.ConsultURL, "RECEIPTS_CONSULT_URL")
+	e.float(&cfg.Receipts.ApproxTaxPercent, "RECEIPTS_APPROX_TAX_PERCENT")
+
+	// OIDC_PROVIDERS lista os nomes (ex.: "google,apple") e cada um usa
+	// OIDC_<NOME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET e _REDIRECT_URL.
+	// Provedores do YAML com o mesmo nome são sobrescritos campo a campo.
+	var names []string
+	e.list(&names, "OIDC_PROVIDERS")
+	for _, name := range names {
+		name = strings.ToLower(name)
+		p := cfg.OIDC.provider(name)
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		e.string(&p.Issuer, prefix+"ISSUER")
+		e.string(&p.ClientID, prefix+"CLIENT_ID")
+		e.string(&p.ClientSecret, prefix+"CLIENT_SECRET")
+		e.string(&p.RedirectURL, prefix+"REDIRECT_URL")
+	}
+
+	return errors.Join(e.errs...)
+}
+
+// Provedor pelo nome, criando-o se ainda não existir
+func (o *OIDCConfig) provider(name string) *OIDCProviderConfig {
+	for i := range o.Providers {
+		if strings.EqualFold(o.Providers[i].Name, name) {
+			return &o.Providers[i]
+		}
+	}
+	o.Providers = append(o.Providers, OIDCProviderConfig{Name: name})
+	return &o.Providers[len(o.Providers)-1]
+}
@@ -0,0 +1,395 @@
+// Arquivo: backend/config/validate.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Tamanho mínimo do JWT_SECRET em produção (HS256 usa chave de 256 bits)
+const minJWTSecretLength = 32
+
+// Tamanho mínimo do segredo HMAC de webhooks
+const minWebhookSecretLength = 16
+
+// Validar a configuração, reunindo todos os problemas encontrados
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		fail("APP_ENV deve ser %q ou %q", EnvDevelopment, EnvProduction)
+	}
+
+	// Servidor
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("PORT inválida: %d", c.Server.Port)
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.ReadHeaderTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 {
+		fail("timeouts HTTP não podem ser negativos")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("HTTP_SHUTDOWN_TIMEOUT deve ser positivo")
+	}
+	tls := c.Server.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		fail("TLS_CERT_FILE e TLS_KEY_FILE devem ser definidos juntos")
+	}
+	if tls.CertFile != "" && tls.SelfSigned {
+		fail("TLS_SELF_SIGNED não pode ser usado junto com TLS_CERT_FILE")
+	}
+	for _, f := range []string{tls.CertFile, tls.KeyFile} {
+		if f != "" && !fileExists(f) {
+			fail("arquivo TLS não encontrado: %s", f)
+		}
+	}
+	if c.Server.HSTS.MaxAge < 0 {
+		fail("HSTS_MAX_AGE não pode ser negativo")
+	}
+
+	// CORS
+	if len(c.CORS.AllowedOrigins) == 0 {
+		fail("CORS_ALLOWED_ORIGINS não pode ser vazio")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			// Com credenciais (cookie de sessão) o navegador rejeita "*"
+			fail("CORS_ALLOWED_ORIGINS não aceita \"*\" (a API usa cookies)")
+			continue
+		}
+		if !isHTTPURL(origin) {
+			fail("origem CORS inválida: %q", origin)
+		}
+	}
+
+	// Banco de dados
+	if c.Database.Host == "" || c.Database.User == "" || c.Database.Name == "" {
+		fail("DB_HOST, DB_USER e DB_NAME são obrigatórios")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		fail("DB_PORT inválida: %d", c.Database.Port)
+	}
+	switch c.Database.SSLMode {
+	case "disable", "require":
+	case "verify-ca", "verify-full":
+		if c.Database.SSLRootCert == "" {
+			fail("DB_SSLROOTCERT é obrigatório com DB_SSLMODE=%s", c.Database.SSLMode)
+		}
+	default:
+		fail("DB_SSLMODE inválido: %q", c.Database.SSLMode)
+	}
+	if c.Database.SSLRootCert != "" && !fileExists(c.Database.SSLRootCert) {
+		fail("DB_SSLROOTCERT não encontrado: %s", c.Database.SSLRootCert)
+	}
+	if c.Database.MaxOpenConns < 1 {
+		fail("DB_MAX_OPEN_CONNS deve ser positivo")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("DB_MAX_IDLE_CONNS deve estar entre 0 e DB_MAX_OPEN_CONNS")
+	}
+
+	// Autenticação
+	if c.Auth.SessionTTL <= 0 {
+		fail("SESSION_TTL deve ser positivo")
+	}
+
+	// Senhas
+	if c.Password.MinLength < 1 || c.Password.MinLength > 72 {
+		fail("PASSWORD_MIN_LENGTH deve estar entre 1 e 72")
+	}
+	if c.Password.MinScore < 0 || c.Password.MinScore > 4 {
+		fail("PASSWORD_MIN_SCORE deve estar entre 0 e 4")
+	}
+	if c.Password.BcryptCost < 4 || c.Password.BcryptCost > 31 {
+		fail("BCRYPT_COST deve estar entre 4 e 31")
+	}
+
+	// LLM
+	if c.LLM.APIKey == "" {
+		fail("GROQ_API_KEY é obrigatória")
+	}
+	if c.LLM.Model == "" {
+		fail("GROQ_MODEL não pode ser vazio")
+	}
+	if !isHTTPURL(c.LLM.BaseURL) {
+		fail("GROQ_BASE_URL inválida: %q", c.LLM.BaseURL)
+	}
+
+	// Logs
+	switch c.Log.Level {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		fail("LOG_LEVEL inválido: %q", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		fail("LOG_FORMAT deve ser json ou text")
+	}
+
+	switch c.OpenAPI.ValidateRequests {
+	case "auto", "on", "off":
+	default:
+		fail("OPENAPI_VALIDATE_REQUESTS deve ser auto, on ou off")
+	}
+
+	// Pagamentos
+	if c.Payments.Gateway != "fake" {
+		fail("PAYMENTS_GATEWAY inválido: %q", c.Payments.Gateway)
+	}
+	if !isCurrencyCode(c.Payments.Currency) {
+		fail("PAYMENTS_CURRENCY deve ser um código ISO 4217 (ex.: BRL)")
+	}
+	if c.Payments.FakeLatency < 0 {
+		fail("PAYMENTS_FAKE_LATENCY não pode ser negativo")
+	}
+	if pix := c.Payments.PIX; pix.Enabled() {
+		if c.Payments.Currency != "BRL" {
+			fail("PIX exige PAYMENTS_CURRENCY=BRL")
+		}
+		if pix.MerchantName == "" || pix.MerchantCity == "" {
+			fail("PIX_MERCHANT_NAME e PIX_MERCHANT_CITY são obrigatórios com PIX_KEY")
+		}
+		if pix.ChargeTTL <= 0 {
+			fail("PIX_CHARGE_TTL deve ser positivo")
+		}
+		if len(pix.WebhookSecret) < minWebhookSecretLength {
+			fail("PIX_WEBHOOK_SECRET deve ter no mínimo %d caracteres", minWebhookSecretLength)
+		}
+		if pix.PSPURL != "" && !isHTTPURL(pix.PSPURL) {
+			fail("PIX_PSP_URL inválida: %q", pix.PSPURL)
+		}
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		fail("IDEMPOTENCY_TTL deve ser positivo")
+	}
+
+	if _, err := time.LoadLocation(c.Promotions.Timezone); c.Promotions.Timezone == "" || err != nil {
+		fail("PROMOTIONS_TIMEZONE inválido: %q", c.Promotions.Timezone)
+	}
+
+	receipts := c.Receipts
+	if receipts.Authority != "stub" {
+		fail("RECEIPTS_AUTHORITY desconhecido: %q (disponível: stub)", receipts.Authority)
+	}
+	if receipts.Environment != "homologation" && receipts.Environment != "production" {
+		fail("RECEIPTS_ENVIRONMENT deve ser homologation ou production")
+	}
+	if receipts.Series < 0 || receipts.Series > 999 {
+		fail("RECEIPTS_SERIES deve estar entre 0 e 999")
+	}
+	if !isDigits(receipts.CNPJ, 14) {
+		fail("RECEIPTS_CNPJ deve ter 14 dígitos")
+	}
+	if receipts.StateCode < 11 || receipts.StateCode > 53 || len(receipts.State) != 2 {
+		fail("RECEIPTS_STATE e RECEIPTS_STATE_CODE inválidos")
+	}
+	if !isHTTPURL(receipts.ConsultURL) {
+		fail("RECEIPTS_CONSULT_URL inválida: %q", receipts.ConsultURL)
+	}
+	if receipts.ApproxTaxPercent < 0 || receipts.ApproxTaxPercent >= 100 {
+		fail("RECEIPTS_APPROX_TAX_PERCENT deve estar entre 0 e 100")
+	}
+	if c.IsProduction() && receipts.Environment == "production" && receipts.CSC == "" {
+		fail("RECEIPTS_CSC é obrigatório para emitir NFC-e em produção")
+	}
+
+	loyalty := c.Loyalty
+	if loyalty.PointsPerReal < 0 {
+		fail("LOYALTY_POINTS_PER_REAL não pode ser negativo")
+	}
+	if loyalty.PointValueCents < 1 {
+		fail("LOYALTY_POINT_VALUE_CENTS deve ser positivo")
+	}
+	if loyalty.PointsTTL <= 0 {
+		fail("LOYALTY_POINTS_TTL deve ser positivo")
+	}
+	if loyalty.MaxRedeemPercent < 0 || loyalty.MaxRedeemPercent > 100 {
+		fail("LOYALTY_MAX_REDEEM_PERCENT deve estar entre 0 e 100")
+	}
+
+	if c.Taxes.Jurisdiction == "" {
+		fail("TAXES_JURISDICTION é obrigatório")
+	}
+	if c.Delivery.FeeCents < 0 || c.Delivery.FreeAboveCents < 0 {
+		fail("DELIVERY_FEE_CENTS e DELIVERY_FREE_ABOVE_CENTS não podem ser negativos")
+	}
+
+	if !isHTTPURL(c.App.URL) {
+		fail("APP_URL inválida: %q", c.App.URL)
+	}
+
+	// OIDC
+	seen := map[string]bool{}
+	for _, p := range c.OIDC.Providers {
+		if p.Name == "" {
+			fail("provedor OIDC sem nome")
+			continue
+		}
+		if seen[p.Name] {
+			fail("provedor OIDC duplicado: %s", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			fail("provedor OIDC %s: issuer, client_id e redirect_url são obrigatórios", p.Name)
+		}
+	}
+
+	// Regras extras de produção
+	if c.IsProduction() {
+		if c.Auth.JWTSecret == "" || c.Auth.JWTSecret == DevJWTSecret {
+			fail("JWT_SECRET é obrigatório em produção")
+		} else if len(c.Auth.JWTSecret) < minJWTSecretLength {
+			fail("JWT_SECRET deve ter no mínimo %d caracteres em produção", minJWTSecretLength)
+		}
+		if !c.Auth.CookieSecure {
+			fail("COOKIE_SECURE deve ser true em produção")
+		}
+		// HTTPS no próprio backend ou terminado em um proxy reverso confiável
+		if tls.CertFile == "" && !c.Server.TrustProxyHeaders {
+			fail("produção exige TLS_CERT_FILE/TLS_KEY_FILE ou TRUST_PROXY_HEADERS=true (TLS no proxy)")
+		}
+		if tls.SelfSigned {
+			fail("TLS_SELF_SIGNED não é permitido em produção")
+		}
+		if c.Database.SSLMode == "disable" {
+			fail("DB_SSLMODE=disable não é permitido em produção (use verify-full)")
+		}
+		if c.Metrics.Token == "" {
+			fail("METRICS_TOKEN é obrigatório em produção")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Resumo da configuração para log, com segredos redigidos. Implementar
+// slog.LogValuer garante que logar a struct inteira não vaza credenciais.
+func (c *Config) LogValue() slog.Value {
+	providers := make([]string, 0, len(c.OIDC.Providers))
+	for _, p := range c.OIDC.Providers {
+		providers = append(providers, p.Name)
+	}
+
+	return slog.GroupValue(
+		slog.String("env", c.Env),
+		slog.Group("server",
+			slog.Int("port", c.Server.Port),
+			slog.String("read_timeout", c.Server.ReadTimeout.String()),
+			slog.String("write_timeout", c.Server.WriteTimeout.String()),
+			slog.String("idle_timeout", c.Server.IdleTimeout.String()),
+			slog.String("shutdown_timeout", c.Server.ShutdownTimeout.String()),
+			slog.Bool("trust_proxy_headers", c.Server.TrustProxyHeaders),
+			slog.Bool("tls", c.Server.TLS.Enabled()),
+			slog.Bool("tls_self_signed", c.Server.TLS.SelfSigned),
+			slog.String("hsts_max_age", c.Server.HSTS.MaxAge.String()),
+		),
+		slog.String("cors_allowed_origins", strings.Join(c.CORS.AllowedOrigins, ",")),
+		slog.Group("database",
+			slog.String("host", c.Database.Host),
+			slog.Int("port", c.Database.Port),
+			slog.String("user", c.Database.User),
+			slog.String("password", secret(c.Database.Password)),
+			slog.String("name", c.Database.Name),
+			slog.String("sslmode", c.Database.SSLMode),
+			slog.String("sslrootcert", c.Database.SSLRootCert),
+			slog.Int("max_open_conns", c.Database.MaxOpenConns),
+			slog.Int("max_idle_conns", c.Database.MaxIdleConns),
+		),
+		slog.Group("auth",
+			slog.String("jwt_secret", secret(c.Auth.JWTSecret)),
+			slog.String("session_ttl", c.Auth.SessionTTL.String()),
+			slog.Bool("cookie_secure", c.Auth.CookieSecure),
+		),
+		slog.Group("password",
+			slog.Int("min_length", c.Password.MinLength),
+			slog.Int("min_score", c.Password.MinScore),
+			slog.Bool("check_breached", c.Password.CheckBreached),
+			slog.Int("bcrypt_cost", c.Password.BcryptCost),
+		),
+		slog.Group("llm",
+			slog.String("api_key", secret(c.LLM.APIKey)),
+			slog.String("model", c.LLM.Model),
+			slog.String("base_url", c.LLM.BaseURL),
+		),
+		slog.String("log_level", c.Log.Level),
+		slog.String("metrics_token", secret(c.Metrics.Token)),
+		slog.String("app_url", c.App.URL),
+		slog.String("oidc_providers", strings.Join(providers, ",")),
+		slog.Bool("openapi_validate_requests", c.ValidateRequests()),
+		slog.Group("payments",
+			slog.String("gateway", c.Payments.Gateway),
+			slog.String("currency", c.Payments.Currency),
+			slog.Bool("auto_capture", c.Payments.AutoCapture),
+			slog.Bool("pix", c.Payments.PIX.Enabled()),
+			slog.String("pix_webhook_secret", secret(c.Payments.PIX.WebhookSecret)),
+		),
+		slog.String("idempotency_ttl", c.Idempotency.TTL.String()),
+		slog.String("promotions_timezone", c.Promotions.Timezone),
+		slog.Group("receipts",
+			slog.String("authority", c.Receipts.Authority),
+			slog.String("environment", c.Receipts.Environment),
+			slog.Int("series", c.Receipts.Series),
+			slog.String("cnpj", c.Receipts.CNPJ),
+			slog.String("csc", secret(c.Receipts.CSC)),
+		),
+		slog.Group("loyalty",
+			slog.Int("points_per_real", c.Loyalty.PointsPerReal),
+			slog.Int("point_value_cents", c.Loyalty.PointValueCents),
+			slog.String("points_ttl", c.Loyalty.PointsTTL.String()),
+			slog.Int("max_redeem_percent", c.Loyalty.MaxRedeemPercent),
+		),
+		slog.String("taxes_jurisdiction", c.Taxes.Jurisdiction),
+		slog.Group("delivery",
+			slog.Int("fee_cents", c.Delivery.FeeCents),
+			slog.Int("free_above_cents", c.Delivery.FreeAboveCents),
+		),
+	)
+}
+
+func secret(s string) string {
+	if s == "" {
+		return "(vazio)"
+	}
+	return "[REDACTED]"
+}
@@ -2,10 +2,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"finplay/backend/config"
+	"log/slog"
+	"strings"
 
 	_ "github.com/lib/pq"
 )
@@ -13,31 +15,16 @@ import (
 var DB *sql.DB
 
 // Conectar ao banco de dados PostgreSQL
-func Connect() error {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	// Valores padrão se não estiverem no .env
-	if host == "" {
-		host = "localhost"
-	}
-	if port == "" {
-		port = "5432"
-	}
-	if user == "" {
-		user = "postgres"
-	}
-	if dbname == "" {
-		dbname = "finplay_db"
-	}
-
+func Connect(cfg config.DatabaseConfig) error {
 	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteConnValue(cfg.Host), cfg.Port, quoteConnValue(cfg.User),
+		quoteConnValue(cfg.Password), quoteConnValue(cfg.Name), cfg.SSLMode,
 	)
+	// CA usada para validar o certificado do servidor (verify-ca/verify-full)
+	if cfg.SSLRootCert != "" {
+		connStr += " sslrootcert=" + quoteConnValue(cfg.SSLRootCert)
+	}
 
 	var err error
 	DB, err = sql.Open("postgres", connStr)
@@ -51,17 +38,36 @@ func Connect() error {
 	}
 
 	// Configurar pool de conexões
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(5)
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	log.Println("✅ Conectado ao PostgreSQL com sucesso!")
+	slog.Info("conectado ao PostgreSQL", "host", cfg.Host, "database", cfg.Name, "sslmode", cfg.SSLMode)
 	return nil
 }
 
+// Aspas simples na string de conexão (senhas com espaço ou aspas)
+func quoteConnValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
 // Fechar conexão com o banco
 func Close() {
 	if DB != nil {
 		DB.Close()
-		log.Println("🔒 Conexão com PostgreSQL fechada")
+		slog.Info("conexão com PostgreSQL fechada")
+	}
+}
+
+// Versão do schema esperada por este binário (ver schema_migrations em schema.sql)
+const SchemaVersion = 18
+
+// Versão mais recente registrada em schema_migrations (0 se vazia)
+func CurrentSchemaVersion(ctx context.Context) (int, error) {
+	var version sql.NullInt64
+	err := DB.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, err
 	}
+	return int(version.Int64), nil
 }
@@ -27,7 +27,7 @@ CREATE INDEX idx_users_email ON users(email);
 CREATE TABLE IF NOT EXISTS orders (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    status VARCHAR(50) DEFAULT 'pending', -- pending, completed, cancelled
+    status VARCHAR(50) DEFAULT 'pending', -- pending, authorized, paid, completed, cancelled, refunded
     total_items INTEGER DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     completed_at TIMESTAMP,
@@ -113,8 +113,557 @@ WHERE o.status = 'completed'
 GROUP BY o.id, o.user_id, u.email, o.status, o.total_items, o.created_at, o.completed_at
 ORDER BY o.completed_at DESC;
 
+-- Autenticação em dois fatores (TOTP)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN DEFAULT false;
+
+-- Códigos de recuperação do 2FA (armazenados com hash bcrypt)
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+
+-- Exclusão de conta (LGPD): usuário é anonimizado, pedidos são mantidos
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Troca de email pendente de confirmação
+CREATE TABLE IF NOT EXISTS email_change_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_email VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 do token enviado por email
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_change_requests_user_id ON email_change_requests(user_id);
+
+-- Exportações de dados pessoais (LGPD), geradas de forma assíncrona
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(50) DEFAULT 'pending', -- pending, ready, failed
+    archive BYTEA, -- arquivo ZIP gerado
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id);
+
+-- Identidades externas (login social via OIDC) vinculadas a usuários
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL, -- google, apple, ...
+    subject VARCHAR(255) NOT NULL, -- claim "sub" do ID token
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- Versão do schema (verificada pelo /readyz). Ao alterar este arquivo,
+-- incrementar a versão aqui e em database.SchemaVersion.
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO schema_migrations (version) VALUES (1) ON CONFLICT DO NOTHING;
+
+-- Cardápio com preços em centavos (fonte de verdade para o valor dos pedidos)
+CREATE TABLE IF NOT EXISTS products (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    category VARCHAR(100) NOT NULL, -- hamburguer, bebidas, sobremesas
+    name VARCHAR(255) NOT NULL,
+    price_cents INTEGER NOT NULL CHECK (price_cents >= 0),
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (category, name)
+);
+
+INSERT INTO products (category, name, price_cents) VALUES
+('hamburguer', 'Cheese', 2890),
+('hamburguer', 'Vegano', 3190),
+('hamburguer', 'Recheado', 3590),
+('hamburguer', 'Gourmet', 4290),
+('hamburguer', 'Picanha', 3990),
+('hamburguer', 'Frango Grelhado', 2990),
+('bebidas', 'Caipirinha', 2200),
+('bebidas', 'Negroni', 3200),
+('bebidas', 'Margarita', 2900),
+('bebidas', 'Água', 500),
+('bebidas', 'Coca Cola', 700),
+('bebidas', 'Suco de Laranja', 900),
+('sobremesas', 'Pudim', 1400),
+('sobremesas', 'Cheesecake', 1800),
+('sobremesas', 'Sorbet', 1200),
+('sobremesas', 'Mousse', 1300),
+('sobremesas', 'Açaí', 1600),
+('sobremesas', 'Pavê', 1400)
+ON CONFLICT (category, name) DO NOTHING;
+
+-- Total do pedido calculado no servidor a partir do cardápio
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_cents BIGINT DEFAULT 0;
+
+-- Pagamentos (uma linha por tentativa; valores em centavos)
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    gateway VARCHAR(50) NOT NULL,
+    gateway_ref VARCHAR(255), -- identificador da transação no gateway
+    status VARCHAR(50) NOT NULL, -- pending, authorized, captured, refunded, voided, declined, expired
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    captured_cents BIGINT DEFAULT 0,
+    refunded_cents BIGINT DEFAULT 0,
+    currency CHAR(3) NOT NULL DEFAULT 'BRL',
+    decline_code VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_order_id ON payments(order_id);
+CREATE INDEX idx_payments_user_id ON payments(user_id);
+-- No máximo um pagamento em vigor por pedido
+CREATE UNIQUE INDEX idx_payments_active_order ON payments(order_id) WHERE status IN ('authorized', 'captured');
+
+INSERT INTO schema_migrations (version) VALUES (2) ON CONFLICT DO NOTHING;
+
+-- Cobranças PIX (BR Code). O pagamento associado fica pending até o PSP
+-- notificar a transferência e expired após expires_at.
+CREATE TABLE IF NOT EXISTS pix_charges (
+    payment_id UUID PRIMARY KEY REFERENCES payments(id) ON DELETE CASCADE,
+    txid VARCHAR(25) UNIQUE NOT NULL,
+    payload TEXT NOT NULL, -- "copia e cola"
+    expires_at TIMESTAMP NOT NULL,
+    paid_at TIMESTAMP,
+    end_to_end_id VARCHAR(32) UNIQUE, -- identificador da transferência no SPI
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Cobrança PIX pendente também ocupa o pedido
+DROP INDEX IF EXISTS idx_payments_active_order;
+CREATE UNIQUE INDEX idx_payments_active_order ON payments(order_id) WHERE status IN ('pending', 'authorized', 'captured');
+
+INSERT INTO schema_migrations (version) VALUES (3) ON CONFLICT DO NOTHING;
+
+-- Cancelamento de itens: o total do pedido é recalculado sem as unidades canceladas
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS cancelled_quantity INTEGER NOT NULL DEFAULT 0;
+
+-- Estornos (totais ou parciais) de pagamentos capturados
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    reason VARCHAR(50) NOT NULL, -- customer_request, item_unavailable, wrong_item, quality_issue, duplicate, other
+    note TEXT,
+    gateway_ref VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
+CREATE INDEX idx_refunds_user_id ON refunds(user_id);
+
+-- Itens cancelados junto com o estorno
+CREATE TABLE IF NOT EXISTS refund_items (
+    refund_id UUID NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount_cents BIGINT NOT NULL,
+    PRIMARY KEY (refund_id, order_item_id)
+);
+
+INSERT INTO schema_migrations (version) VALUES (4) ON CONFLICT DO NOTHING;
+
+-- Respostas de POSTs com Idempotency-Key (por usuário). status_code NULL
+-- indica requisição original ainda em andamento.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL, -- SHA-256 de método, rota e corpo
+    status_code INTEGER,
+    content_type VARCHAR(255),
+    body BYTEA,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+INSERT INTO schema_migrations (version) VALUES (5) ON CONFLICT DO NOTHING;
+
+-- Promoções e cupons. Sem code = promoção automática; com code = cupom.
+-- Escopo por categoria/produto (vazio = pedido todo); limites NULL = sem limite.
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    code VARCHAR(50), -- comparado sem diferenciar maiúsculas
+    kind VARCHAR(50) NOT NULL CHECK (kind IN ('percentage', 'fixed', 'buy_x_get_y', 'free_item')),
+    percent INTEGER CHECK (percent BETWEEN 1 AND 100),
+    amount_cents BIGINT CHECK (amount_cents > 0),
+    buy_quantity INTEGER CHECK (buy_quantity > 0),
+    get_quantity INTEGER CHECK (get_quantity > 0),
+    category VARCHAR(100),
+    product_name VARCHAR(255),
+    min_order_cents BIGINT NOT NULL DEFAULT 0,
+    weekdays INTEGER[], -- 0 = domingo; NULL = todos os dias
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    max_uses INTEGER CHECK (max_uses > 0),
+    max_uses_per_user INTEGER CHECK (max_uses_per_user > 0),
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (kind <> 'percentage' OR percent IS NOT NULL),
+    CHECK (kind <> 'fixed' OR amount_cents IS NOT NULL),
+    CHECK (kind <> 'buy_x_get_y' OR (buy_quantity IS NOT NULL AND get_quantity IS NOT NULL)),
+    CHECK (kind <> 'free_item' OR get_quantity IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX idx_promotions_code ON promotions(upper(code)) WHERE code IS NOT NULL;
+
+-- Descontos aplicados (uma linha por promoção e pedido), base dos limites de uso
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(50),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX idx_promotion_redemptions_order_id ON promotion_redemptions(order_id);
+CREATE INDEX idx_promotion_redemptions_user ON promotion_redemptions(promotion_id, user_id);
+
+-- Detalhamento do valor: total_cents = subtotal_cents - discount_cents
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal_cents BIGINT DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_cents BIGINT DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_code VARCHAR(50);
+UPDATE orders SET subtotal_cents = total_cents WHERE subtotal_cents = 0 AND discount_cents = 0;
+
+-- Desconto rateado para a linha (todas as unidades)
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_cents BIGINT NOT NULL DEFAULT 0;
+
+INSERT INTO schema_migrations (version) VALUES (6) ON CONFLICT DO NOTHING;
+
+-- Programa de fidelidade: extrato de pontos por usuário. Créditos (earn,
+-- restore) formam lotes com saldo em remaining e validade; débitos (redeem,
+-- expire, reverse) consomem os lotes que vencem primeiro.
+CREATE TABLE IF NOT EXISTS loyalty_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('earn', 'redeem', 'restore', 'expire', 'reverse')),
+    points INTEGER NOT NULL, -- negativo nos débitos
+    remaining INTEGER NOT NULL DEFAULT 0 CHECK (remaining >= 0),
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_loyalty_transactions_user ON loyalty_transactions(user_id, created_at);
+CREATE INDEX idx_loyalty_transactions_lots ON loyalty_transactions(user_id, expires_at) WHERE remaining > 0;
+CREATE INDEX idx_loyalty_transactions_order_id ON loyalty_transactions(order_id);
+
+-- Pontos resgatados no pedido (o desconto entra em discount_cents)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS loyalty_points INTEGER DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS loyalty_discount_cents BIGINT DEFAULT 0;
+
+INSERT INTO schema_migrations (version) VALUES (7) ON CONFLICT DO NOTHING;
+
+-- Cupons fiscais (NFC-e) dos pedidos finalizados. O número é reservado antes
+-- do envio ao autorizador e reaproveitado se a nota for rejeitada.
+CREATE SEQUENCE IF NOT EXISTS receipt_number_seq;
+
+CREATE TABLE IF NOT EXISTS receipts (
+    order_id UUID PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    number BIGINT NOT NULL DEFAULT nextval('receipt_number_seq'),
+    series INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL, -- pending, authorized, rejected
+    access_key CHAR(44) UNIQUE,
+    protocol VARCHAR(20),
+    message TEXT, -- motivo da última rejeição
+    xml TEXT, -- nfeProc (nota + protocolo)
+    pdf BYTEA, -- DANFE NFC-e
+    issued_at TIMESTAMPTZ,
+    authorized_at TIMESTAMPTZ,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (series, number)
+);
+
+INSERT INTO schema_migrations (version) VALUES (8) ON CONFLICT DO NOTHING;
+
+-- Categoria fiscal do produto (NULL = a própria categoria do cardápio); é por
+-- ela que as regras de tributo se aplicam
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_category VARCHAR(100);
+UPDATE products SET tax_category = 'bebidas_alcoolicas'
+WHERE category = 'bebidas' AND name IN ('Caipirinha', 'Negroni', 'Margarita') AND tax_category IS NULL;
+
+-- Regras de tributo por categoria fiscal e jurisdição (UF); NULL vale para
+-- todas. Para cada tributo (name) prevalece a regra mais específica. Tributos
+-- inclusive já estão no preço do cardápio; os demais somam ao total.
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL,
+    jurisdiction VARCHAR(10),
+    tax_category VARCHAR(100),
+    rate_bps INTEGER NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000), -- 1800 = 18%
+    inclusive BOOLEAN NOT NULL DEFAULT true,
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_tax_rules_scope ON tax_rules(upper(name), COALESCE(jurisdiction, ''), COALESCE(tax_category, '')) WHERE active;
+
+-- Tributos calculados por item (todas as unidades), base do detalhamento do pedido
+CREATE TABLE IF NOT EXISTS order_item_taxes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    tax_rule_id UUID REFERENCES tax_rules(id) ON DELETE SET NULL,
+    name VARCHAR(50) NOT NULL,
+    jurisdiction VARCHAR(10),
+    rate_bps INTEGER NOT NULL,
+    inclusive BOOLEAN NOT NULL,
+    base_cents BIGINT NOT NULL,
+    amount_cents BIGINT NOT NULL
+);
+
+CREATE INDEX idx_order_item_taxes_order_id ON order_item_taxes(order_id);
+
+-- Detalhamento do valor: total_cents = subtotal_cents - discount_cents +
+-- tax_cents + delivery_fee_cents. included_tax_cents é informativo (tributos
+-- embutidos nos preços).
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_cents BIGINT DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS included_tax_cents BIGINT DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_fee_cents BIGINT DEFAULT 0;
+
+-- Tributos da linha (todas as unidades): somados ao preço e embutidos nele
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_cents BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS included_tax_cents BIGINT NOT NULL DEFAULT 0;
+
+INSERT INTO schema_migrations (version) VALUES (9) ON CONFLICT DO NOTHING;
+
+-- Carrinho do usuário (um por usuário), guardado no servidor para sobreviver a
+-- troca de dispositivo. Preços não são gravados: o carrinho é precificado a
+-- cada leitura com as mesmas regras da criação do pedido.
+CREATE TABLE IF NOT EXISTS carts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    coupon_code VARCHAR(50),
+    redeem_points INTEGER NOT NULL DEFAULT 0 CHECK (redeem_points >= 0),
+    delivery BOOLEAN NOT NULL DEFAULT false,
+    notes TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Itens do carrinho; o mesmo produto com os mesmos ingredientes soma a quantidade
+CREATE TABLE IF NOT EXISTS cart_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES carts(user_id) ON DELETE CASCADE,
+    product_category VARCHAR(100) NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    ingredients TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_cart_items_product ON cart_items(user_id, product_category, lower(product_name), ingredients);
+
+INSERT INTO schema_migrations (version) VALUES (10) ON CONFLICT DO NOTHING;
+
+-- Grupos de opções dos produtos. kind: remove (retirar ingrediente), add
+-- (adicional) ou choice (escolha entre variações, como pão ou tamanho). O
+-- cliente escolhe entre min_select e max_select opções do grupo; grupo sem
+-- escolha recebe as opções padrão (is_default).
+CREATE TABLE IF NOT EXISTS product_modifier_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('remove', 'add', 'choice')),
+    min_select INTEGER NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INTEGER NOT NULL DEFAULT 1 CHECK (max_select > 0 AND max_select >= min_select),
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name)
+);
+
+CREATE INDEX idx_product_modifier_groups_product_id ON product_modifier_groups(product_id);
+
+-- Opções do grupo; o acréscimo soma ao preço unitário (negativo para
+-- variações mais baratas, desde que o preço final não fique negativo)
+CREATE TABLE IF NOT EXISTS product_modifiers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES product_modifier_groups(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    price_delta_cents INTEGER NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    active BOOLEAN NOT NULL DEFAULT true,
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (group_id, name)
+);
+
+-- Opções escolhidas em cada item do pedido (cópia do cardápio no momento do
+-- pedido; o preço do item já inclui os acréscimos)
+CREATE TABLE IF NOT EXISTS order_item_modifiers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    modifier_id UUID REFERENCES product_modifiers(id) ON DELETE SET NULL,
+    group_name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    price_delta_cents INTEGER NOT NULL,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX idx_order_item_modifiers_order_id ON order_item_modifiers(order_id);
+
+-- ingredients passa a ser observação livre do item em texto simples (era
+-- gravado como string JSON)
+UPDATE order_items SET ingredients = ingredients::json #>> '{}'
+WHERE ingredients LIKE '"%"' AND NOT EXISTS (SELECT 1 FROM schema_migrations WHERE version = 11);
+
+-- Opções do item no carrinho ([{"group", "option"}] na ordem do cardápio);
+-- entram na chave que soma quantidades
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS modifiers JSONB NOT NULL DEFAULT '[]';
+DROP INDEX IF EXISTS idx_cart_items_product;
+CREATE UNIQUE INDEX idx_cart_items_product ON cart_items(user_id, product_category, lower(product_name), ingredients, modifiers);
+
+INSERT INTO schema_migrations (version) VALUES (11) ON CONFLICT DO NOTHING;
+
+-- Última janela TOTP (unix/30s) aceita por usuário; códigos da mesma janela
+-- ou anteriores são recusados para impedir reuso
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
+
+INSERT INTO schema_migrations (version) VALUES (12) ON CONFLICT DO NOTHING;
+
+-- Versão dos tokens de sessão: o JWT carrega a versão da emissão e deixa de
+-- valer quando ela é incrementada (troca de senha, desativação, exclusão)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+INSERT INTO schema_migrations (version) VALUES (13) ON CONFLICT DO NOTHING;
+
+-- Vínculo de login social compara o email sem diferenciar maiúsculas
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
+
+INSERT INTO schema_migrations (version) VALUES (14) ON CONFLICT DO NOTHING;
+
+-- Valor efetivamente transferido no PIX. Se diferir da cobrança o pagamento
+-- fica em mismatch (pedido continua pendente) até o estorno pelo suporte.
+ALTER TABLE pix_charges ADD COLUMN IF NOT EXISTS received_cents BIGINT;
+
+INSERT INTO schema_migrations (version) VALUES (15) ON CONFLICT DO NOTHING;
+
+-- Papel do usuário. Captura, cancelamento e estorno de pagamentos são
+-- restritos à equipe da loja; para promover um funcionário:
+--   UPDATE users SET role = 'staff' WHERE email = '...';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer'
+    CHECK (role IN ('customer', 'staff', 'admin'));
+
+INSERT INTO schema_migrations (version) VALUES (16) ON CONFLICT DO NOTHING;
+
+-- Códigos 2FA incorretos seguidos na segunda etapa do login; ao atingir o
+-- limite a etapa fica bloqueada até mfa_locked_until
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_locked_until TIMESTAMP;
+
+INSERT INTO schema_migrations (version) VALUES (17) ON CONFLICT DO NOTHING;
+
+-- Lotes consumidos por cada débito de pontos. A devolução dos pontos de um
+-- pedido cancelado recria o resgate com a validade dos lotes de origem.
+CREATE TABLE IF NOT EXISTS loyalty_lot_debits (
+    debit_id UUID NOT NULL REFERENCES loyalty_transactions(id) ON DELETE CASCADE,
+    lot_id UUID NOT NULL REFERENCES loyalty_transactions(id) ON DELETE CASCADE,
+    points INTEGER NOT NULL CHECK (points > 0),
+    PRIMARY KEY (debit_id, lot_id)
+);
+
+INSERT INTO schema_migrations (version) VALUES (18) ON CONFLICT DO NOTHING;
+
 -- Dados de exemplo (remover em produção)
 -- Senha: "senha123" (hash bcrypt)
 INSERT INTO users (email, password_hash, full_name) VALUES 
 ('teste@gmail.com', '$2a$10$XQ.V5/K5J5J5J5J5J5J5J5J5J5J5J5J5J5J5J5J5J5J5J5J', 'Usuário Teste')
-ON CONFLICT (email) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (email) DO NOTHING;
+-- Promoções de exemplo
+INSERT INTO promotions (name, description, kind, get_quantity, category, product_name, min_order_cents)
+SELECT 'Pudim grátis', 'Um pudim grátis em pedidos acima de R$ 50', 'free_item', 1, 'sobremesas', 'Pudim', 5000
+WHERE NOT EXISTS (SELECT 1 FROM promotions WHERE name = 'Pudim grátis');
+
+INSERT INTO promotions (name, description, kind, percent, category, weekdays)
+SELECT 'Terça do hambúrguer', '10% de desconto nos hambúrgueres às terças', 'percentage', 10, 'hamburguer', '{2}'
+WHERE NOT EXISTS (SELECT 1 FROM promotions WHERE name = 'Terça do hambúrguer');
+
+INSERT INTO promotions (name, description, code, kind, percent, max_uses_per_user)
+SELECT 'Boas-vindas', '10% de desconto no primeiro pedido', 'BEMVINDO10', 'percentage', 10, 1
+WHERE NOT EXISTS (SELECT 1 FROM promotions WHERE name = 'Boas-vindas');
+
+-- Regras de tributo de exemplo (alíquotas ilustrativas, embutidas nos preços)
+INSERT INTO tax_rules (name, jurisdiction, tax_category, rate_bps, inclusive)
+SELECT 'ICMS', 'SP', NULL, 320, true
+WHERE NOT EXISTS (SELECT 1 FROM tax_rules WHERE name = 'ICMS' AND jurisdiction = 'SP' AND tax_category IS NULL);
+
+INSERT INTO tax_rules (name, jurisdiction, tax_category, rate_bps, inclusive)
+SELECT 'ICMS', 'SP', 'bebidas_alcoolicas', 2500, true
+WHERE NOT EXISTS (SELECT 1 FROM tax_rules WHERE name = 'ICMS' AND jurisdiction = 'SP' AND tax_category = 'bebidas_alcoolicas');
+
+INSERT INTO tax_rules (name, jurisdiction, tax_category, rate_bps, inclusive)
+SELECT 'PIS/COFINS', NULL, NULL, 365, true
+WHERE NOT EXISTS (SELECT 1 FROM tax_rules WHERE name = 'PIS/COFINS' AND jurisdiction IS NULL AND tax_category IS NULL);
+
+-- Opções de exemplo: pão, retirar e adicionais nos hambúrgueres; tamanho nas
+-- bebidas não alcoólicas
+INSERT INTO product_modifier_groups (product_id, name, kind, min_select, max_select, position)
+SELECT p.id, g.name, g.kind, g.min_select, g.max_select, g.position
+FROM products p
+CROSS JOIN (VALUES
+    ('Pão', 'choice', 1, 1, 1),
+    ('Retirar', 'remove', 0, 4, 2),
+    ('Adicionais', 'add', 0, 3, 3)
+) AS g(name, kind, min_select, max_select, position)
+WHERE p.category = 'hamburguer'
+ON CONFLICT (product_id, name) DO NOTHING;
+
+INSERT INTO product_modifier_groups (product_id, name, kind, min_select, max_select, position)
+SELECT p.id, 'Tamanho', 'choice', 1, 1, 1
+FROM products p
+WHERE p.category = 'bebidas' AND p.name IN ('Coca Cola', 'Suco de Laranja')
+ON CONFLICT (product_id, name) DO NOTHING;
+
+INSERT INTO product_modifiers (group_id, name, price_delta_cents, is_default, position)
+SELECT g.id, m.name, m.price_delta_cents, m.is_default, m.position
+FROM product_modifier_groups g
+JOIN products p ON p.id = g.product_id
+JOIN (VALUES
+    ('hamburguer', NULL, 'Pão', 'Tradicional', 0, true, 1),
+    ('hamburguer', NULL, 'Pão', 'Brioche', 300, false, 2),
+    ('hamburguer', NULL, 'Pão', 'Australiano', 300, false, 3),
+    ('hamburguer', NULL, 'Pão', 'Sem glúten', 500, false, 4),
+    ('hamburguer', NULL, 'Retirar', 'Cebola', 0, false, 1),
+    ('hamburguer', NULL, 'Retirar', 'Tomate', 0, false, 2),
+    ('hamburguer', NULL, 'Retirar', 'Alface', 0, false, 3),
+    ('hamburguer', NULL, 'Retirar', 'Molho', 0, false, 4),
+    ('hamburguer', NULL, 'Adicionais', 'Bacon', 500, false, 1),
+    ('hamburguer', NULL, 'Adicionais', 'Queijo extra', 400, false, 2),
+    ('hamburguer', NULL, 'Adicionais', 'Ovo', 300, false, 3),
+    ('bebidas', 'Coca Cola', 'Tamanho', 'Lata 350 ml', 0, true, 1),
+    ('bebidas', 'Coca Cola', 'Tamanho', 'Garrafa 600 ml', 300, false, 2),
+    ('bebidas', 'Suco de Laranja', 'Tamanho', '300 ml', 0, true, 1),
+    ('bebidas', 'Suco de Laranja', 'Tamanho', '500 ml', 400, false, 2)
+) AS m(category, product_name, group_name, name, price_delta_cents, is_default, position)
+    ON m.category = p.category AND (m.product_name IS NULL OR m.product_name = p.name) AND m.group_name = g.name
+ON CONFLICT (group_id, name) DO NOTHING;
@@ -0,0 +1,95 @@
+// Arquivo: backend/database/schema_test.go
+package database
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Todo produto oferecido no catálogo do chat (web/src/utils/chatbot.js)
+// precisa estar no cardápio semeado em schema.sql; caso contrário o pedido
+// é recusado com "produto desconhecido".
+func TestSchemaSeedsClientCatalog(t *testing.T) {
+	schema, err := os.ReadFile("schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := os.ReadFile("../../web/src/utils/chatbot.js")
+	if os.IsNotExist(err) {
+		t.Skip("frontend não disponível")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeded := seededProducts(t, string(schema))
+	offered := catalogProducts(t, string(catalog))
+
+	var missing []string
+	for _, p := range offered {
+		if !seeded[p] {
+			missing = append(missing, p)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("produtos do catálogo sem linha em products: %s", strings.Join(missing, ", "))
+	}
+}
+
+// Produtos do INSERT INTO products, como "categoria/nome"
+func seededProducts(t *testing.T, schema string) map[string]bool {
+	start := strings.Index(schema, "INSERT INTO products (category, name, price_cents) VALUES")
+	if start < 0 {
+		t.Fatal("INSERT INTO products não encontrado em schema.sql")
+	}
+	block := schema[start:]
+	block = block[:strings.Index(block, ";")]
+
+	row := regexp.MustCompile(`\('([^']+)', '([^']+)', \d+\)`)
+	seeded := make(map[string]bool)
+	for _, m := range row.FindAllStringSubmatch(block, -1) {
+		seeded[m[1]+"/"+m[2]] = true
+	}
+	if len(seeded) == 0 {
+		t.Fatal("nenhum produto semeado em schema.sql")
+	}
+	return seeded
+}
+
+// Produtos das listas por categoria do CATALOGO, como "categoria/nome"
+func catalogProducts(t *testing.T, js string) []string {
+	start := strings.Index(js, "export const CATALOGO = {")
+	if start < 0 {
+		t.Fatal("CATALOGO não encontrado em chatbot.js")
+	}
+	js = js[start:]
+	js = js[:strings.Index(js, "\n};")]
+
+	// Listas de primeiro nível (as de ingredientes ficam mais indentadas)
+	list := regexp.MustCompile(`(?m)^ {4}(\w+): \[`)
+	name := regexp.MustCompile(`nome: '([^']+)'`)
+
+	var products []string
+	locs := list.FindAllStringSubmatchIndex(js, -1)
+	for i, loc := range locs {
+		category := js[loc[2]:loc[3]]
+		if category == "categorias" {
+			continue
+		}
+		end := len(js)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		for _, m := range name.FindAllStringSubmatch(js[loc[1]:end], -1) {
+			products = append(products, category+"/"+m[1])
+		}
+	}
+	if len(products) == 0 {
+		t.Fatal("nenhum produto encontrado no CATALOGO")
+	}
+	return products
+}
@@ -3,22 +3,45 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"finplay/backend/config"
 	"finplay/backend/database"
 	"finplay/backend/middleware"
 	"finplay/backend/models"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
+// Configuração dos cookies de sessão, definida na inicialização por Configure
+var authConfig = config.Default().Auth
+
+// Aplicar a configuração carregada
+func Configure(auth config.AuthConfig) {
+	authConfig = auth
+}
+
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token     string      `json:"token"`
+	CSRFToken string      `json:"csrf_token,omitempty"` // exigido em X-CSRF-Token ao usar o cookie
+	User      models.User `json:"user"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	ReauthToken     string `json:"reauth_token,omitempty"` // no lugar da senha atual
+	NewPassword     string `json:"new_password"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// Resposta do login quando o usuário tem 2FA ativo
+type MFARequiredResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
 // POST /api/auth/register
 func HandleRegister(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -27,45 +50,37 @@ func HandleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var reg models.UserRegistration
-	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
-		sendError(w, "Dados inválidos", http.StatusBadRequest)
+	if !decodeJSON(w, r, &reg) {
 		return
 	}
 
-	log.Printf("📝 Tentativa de registro: %s", reg.Email)
+	slog.DebugContext(r.Context(), "tentativa de registro", "email", reg.Email)
 
 	// Criar usuário
 	user, err := models.CreateUser(database.DB, reg)
 	if err != nil {
-		log.Printf("❌ Erro no registro: %v", err)
+		slog.WarnContext(r.Context(), "erro no registro", "email", reg.Email, "error", err)
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Gerar token
-	token, err := middleware.GenerateToken(user.ID, user.Email)
+	token, err := middleware.GenerateToken(user.ID, user.Email, user.TokenVersion)
 	if err != nil {
 		sendError(w, "Erro ao gerar token", http.StatusInternalServerError)
 		return
 	}
 
 	// Definir cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    token,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false, // true em produção com HTTPS
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   86400, // 24 horas
-	})
+	csrfToken := setAuthCookie(w, token)
 
-	log.Printf("✅ Usuário registrado: %s", user.Email)
+	slog.InfoContext(r.Context(), "usuário registrado", "user_id", user.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(AuthResponse{
-		Token: token,
-		User:  *user,
+		Token:     token,
+		CSRFToken: csrfToken,
+		User:      *user,
 	})
 }
 
@@ -77,75 +92,114 @@ func HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var login models.UserLogin
-	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
-		sendError(w, "Dados inválidos", http.StatusBadRequest)
+	if !decodeJSON(w, r, &login) {
 		return
 	}
 
-	log.Printf("🔐 Tentativa de login: %s", login.Email)
+	slog.DebugContext(r.Context(), "tentativa de login", "email", login.Email)
 
 	// Buscar usuário
 	user, err := models.GetUserByEmail(database.DB, login.Email)
 	if err != nil {
-		log.Printf("❌ Usuário não encontrado: %s", login.Email)
+		slog.WarnContext(r.Context(), "login com usuário inexistente", "email", login.Email)
 		sendError(w, "Email ou senha incorretos", http.StatusUnauthorized)
 		return
 	}
 
 	// Verificar senha
 	if !models.CheckPassword(login.Password, user.PasswordHash) {
-		log.Printf("❌ Senha incorreta para: %s", login.Email)
+		slog.WarnContext(r.Context(), "login com senha incorreta", "user_id", user.ID)
 		sendError(w, "Email ou senha incorretos", http.StatusUnauthorized)
 		return
 	}
 
+	// Refazer hash de forma transparente se o custo do bcrypt mudou
+	if models.PasswordNeedsRehash(user.PasswordHash) {
+		if hash, err := models.HashPassword(login.Password); err == nil {
+			if err := models.UpdatePasswordHash(database.DB, user.ID, hash); err != nil {
+				slog.ErrorContext(r.Context(), "erro ao atualizar hash da senha", "user_id", user.ID, "error", err)
+			}
+		}
+	}
+
+	// Com 2FA ativo, emitir apenas token intermediário até o código TOTP ser validado
+	if user.TOTPEnabled {
+		mfaToken, err := middleware.GenerateMFAPendingToken(user.ID, user.Email)
+		if err != nil {
+			sendError(w, "Erro ao gerar token", http.StatusInternalServerError)
+			return
+		}
+
+		slog.InfoContext(r.Context(), "login aguardando 2FA", "user_id", user.ID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(MFARequiredResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
+		return
+	}
+
 	// Atualizar último login
 	models.UpdateLastLogin(database.DB, user.ID)
 
 	// Gerar token
-	token, err := middleware.GenerateToken(user.ID, user.Email)
+	token, err := middleware.GenerateToken(user.ID, user.Email, user.TokenVersion)
 	if err != nil {
 		sendError(w, "Erro ao gerar token", http.StatusInternalServerError)
 		return
 	}
 
 	// Definir cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    token,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   86400,
-	})
+	csrfToken := setAuthCookie(w, token)
 
-	log.Printf("✅ Login bem-sucedido: %s", user.Email)
+	slog.InfoContext(r.Context(), "login bem-sucedido", "user_id", user.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(AuthResponse{
-		Token: token,
-		User:  *user,
+		Token:     token,
+		CSRFToken: csrfToken,
+		User:      *user,
 	})
 }
 
 // POST /api/auth/logout
 func HandleLogout(w http.ResponseWriter, r *http.Request) {
 	// Remover cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   -1,
-	})
+	clearAuthCookie(w)
 
-	log.Println("👋 Usuário desconectado")
+	slog.InfoContext(r.Context(), "usuário desconectado")
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Logout realizado com sucesso"})
 }
 
+// GET /api/auth/csrf - Emite novo token CSRF para a sessão do cookie
+// (ex.: após recarregar a página, quando o token da resposta de login se perdeu)
+func HandleCSRFToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cookie, err := r.Cookie(middleware.AuthCookieName())
+	if err != nil {
+		sendError(w, "Sessão por cookie não encontrada", http.StatusBadRequest)
+		return
+	}
+
+	csrfToken, err := middleware.GenerateCSRFToken(cookie.Value)
+	if err != nil {
+		sendError(w, "Erro ao gerar token CSRF", http.StatusInternalServerError)
+		return
+	}
+	setCSRFCookie(w, csrfToken)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"csrf_token": csrfToken})
+}
+
 // GET /api/auth/me - Retorna usuário logado
 func HandleGetMe(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserFromContext(r)
@@ -154,7 +208,7 @@ func HandleGetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := models.GetUserByEmail(database.DB, claims.Email)
+	user, err := models.GetUserByID(database.DB, claims.UserID)
 	if err != nil {
 		sendError(w, "Usuário não encontrado", http.StatusNotFound)
 		return
@@ -164,6 +218,126 @@ func HandleGetMe(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
+// POST /api/auth/password - Trocar senha do usuário logado
+func HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var req ChangePasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, claims.UserID)
+	if err != nil {
+		sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+
+	// Contas do login social não têm senha atual: definem a primeira depois de
+	// reautenticar no provedor
+	if req.ReauthToken != "" {
+		if !reauthenticated(user, "", req.ReauthToken) {
+			sendError(w, "Reautenticação inválida ou expirada", http.StatusUnauthorized)
+			return
+		}
+		err = models.SetPassword(database.DB, user, req.NewPassword)
+	} else {
+		err = models.ChangePassword(database.DB, user, req.CurrentPassword, req.NewPassword)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidCurrentPassword):
+			sendError(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, models.ErrSamePassword), errors.Is(err, models.ErrPasswordRejected):
+			sendError(w, err.Error(), http.StatusBadRequest)
+		default:
+			slog.ErrorContext(r.Context(), "erro ao trocar senha", "user_id", user.ID, "error", err)
+			sendError(w, "Erro ao trocar senha", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Sessões abertas foram revogadas; emitir token novo para esta
+	token, err := middleware.GenerateToken(user.ID, user.Email, user.TokenVersion)
+	if err != nil {
+		sendError(w, "Erro ao gerar token", http.StatusInternalServerError)
+		return
+	}
+
+	csrfToken := setAuthCookie(w, token)
+
+	slog.InfoContext(r.Context(), "senha alterada", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AuthResponse{
+		Token:     token,
+		CSRFToken: csrfToken,
+		User:      *user,
+	})
+}
+
+// Definir cookie de sessão e o cookie CSRF correspondente. Retorna o token
+// CSRF (vazio se não pôde ser gerado; o cliente pode pedir outro em /api/auth/csrf).
+func setAuthCookie(w http.ResponseWriter, token string) string {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.AuthCookieName(),
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   authConfig.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(authConfig.SessionTTL.Seconds()), // mesma validade do JWT
+	})
+
+	csrfToken, err := middleware.GenerateCSRFToken(token)
+	if err != nil {
+		slog.Error("erro ao gerar token CSRF", "error", err)
+		return ""
+	}
+	setCSRFCookie(w, csrfToken)
+	return csrfToken
+}
+
+// Cookie CSRF legível por JavaScript (não HttpOnly) para o double submit
+func setCSRFCookie(w http.ResponseWriter, csrfToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.CSRFCookieName(),
+		Value:    csrfToken,
+		Path:     "/",
+		Secure:   authConfig.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(authConfig.SessionTTL.Seconds()),
+	})
+}
+
+// Remover cookie de sessão
+func clearAuthCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.AuthCookieName(),
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   authConfig.CookieSecure,
+		MaxAge:   -1,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:   middleware.CSRFCookieName(),
+		Value:  "",
+		Path:   "/",
+		Secure: authConfig.CookieSecure,
+		MaxAge: -1,
+	})
+}
+
 func sendError(w http.ResponseWriter, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
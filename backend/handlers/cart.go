@@ -0,0 +1,161 @@
+// Arquivo: backend/handlers/cart.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"finplay/backend/database"
+	"finplay/backend/metrics"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"log/slog"
+	"net/http"
+)
+
+// GET/PUT/DELETE /api/cart - Carrinho do usuário
+func HandleCart(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		handleGetCart(w, r)
+	case http.MethodPut:
+		handleReplaceCart(w, r)
+	case http.MethodDelete:
+		handleClearCart(w, r)
+	default:
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /api/cart - Carrinho com o valor recalculado
+func handleGetCart(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	cart, err := models.GetCart(database.DB, claims.UserID)
+	sendCart(w, r, cart, err)
+}
+
+// PUT /api/cart - Substituir itens e opções do carrinho
+func handleReplaceCart(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.UpdateCartRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	cart, err := models.ReplaceCart(database.DB, claims.UserID, req)
+	sendCart(w, r, cart, err)
+}
+
+// DELETE /api/cart - Esvaziar o carrinho
+func handleClearCart(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	if err := models.ClearCart(database.DB, claims.UserID); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao esvaziar carrinho", "user_id", claims.UserID, "error", err)
+		sendError(w, "Erro ao esvaziar carrinho", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/cart/items - Adicionar item
+// PUT /api/cart/items?id=uuid - Alterar quantidade (0 remove)
+// DELETE /api/cart/items?id=uuid - Remover item
+func HandleCartItems(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var item models.CartItemInput
+		if !decodeJSON(w, r, &item) {
+			return
+		}
+		cart, err := models.AddCartItem(database.DB, claims.UserID, item)
+		sendCart(w, r, cart, err)
+	case http.MethodPut, http.MethodDelete:
+		itemID := r.URL.Query().Get("id")
+		if itemID == "" {
+			sendError(w, "ID do item é obrigatório", http.StatusBadRequest)
+			return
+		}
+		var req models.UpdateCartItemRequest
+		if r.Method == http.MethodPut && !decodeJSON(w, r, &req) {
+			return
+		}
+		cart, err := models.UpdateCartItem(database.DB, claims.UserID, itemID, req.Quantity)
+		sendCart(w, r, cart, err)
+	default:
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// POST /api/cart/checkout - Criar pedido com o conteúdo do carrinho
+func HandleCartCheckout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := models.CheckoutCart(database.DB, claims.UserID)
+	if errors.Is(err, models.ErrCartEmpty) || isOrderInputError(err) {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro no checkout do carrinho", "user_id", claims.UserID, "error", err)
+		sendError(w, "Erro ao criar pedido", http.StatusInternalServerError)
+		return
+	}
+
+	metrics.OrdersCreated.Inc()
+	slog.InfoContext(r.Context(), "pedido criado pelo carrinho", "order_id", order.ID, "items", order.TotalItems,
+		"total_cents", order.TotalCents, "discount_cents", order.DiscountCents)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// Responder com o carrinho ou com o erro correspondente
+func sendCart(w http.ResponseWriter, r *http.Request, cart *models.Cart, err error) {
+	switch {
+	case errors.Is(err, models.ErrCartItemNotFound):
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrCartFull) || errors.Is(err, models.ErrUnknownProduct) ||
+		errors.Is(err, models.ErrInvalidQuantity) || errors.Is(err, models.ErrInvalidModifier) ||
+		errors.Is(err, models.ErrInsufficientPoints):
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "erro no carrinho", "error", err)
+		sendError(w, "Erro ao processar carrinho", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
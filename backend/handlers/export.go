@@ -0,0 +1,169 @@
+// Arquivo: backend/handlers/export.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"finplay/backend/database"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"finplay/backend/server"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type DataExportResponse struct {
+	models.DataExport
+	StatusURL   string `json:"status_url"`
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// GET /api/users/me/export - Exportar dados pessoais (LGPD)
+// Contas pequenas recebem o ZIP direto; contas grandes (ou ?async=true)
+// recebem 202 e acompanham a geração pelo status_url.
+func HandleDataExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := models.CountUserOrders(database.DB, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao contar pedidos", "user_id", claims.UserID, "error", err)
+		sendError(w, "Erro ao exportar dados", http.StatusInternalServerError)
+		return
+	}
+
+	if count <= models.DataExportSyncMaxOrders && r.URL.Query().Get("async") != "true" {
+		archive, err := models.BuildUserDataExport(database.DB, claims.UserID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro ao exportar dados", "user_id", claims.UserID, "error", err)
+			sendError(w, "Erro ao exportar dados", http.StatusInternalServerError)
+			return
+		}
+
+		slog.InfoContext(r.Context(), "dados exportados", "user_id", claims.UserID)
+		sendExportArchive(w, archive)
+		return
+	}
+
+	export, err := models.CreateDataExport(database.DB, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao criar exportação", "user_id", claims.UserID, "error", err)
+		sendError(w, "Erro ao exportar dados", http.StatusInternalServerError)
+		return
+	}
+
+	// Contexto desacoplado do cancelamento da requisição, mantendo o request ID
+	ctx := context.WithoutCancel(r.Context())
+	exportID, userID := export.ID, claims.UserID
+	server.Go(func() {
+		if err := models.ProcessDataExport(database.DB, exportID, userID); err != nil {
+			slog.ErrorContext(ctx, "erro ao gerar exportação", "export_id", exportID, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "exportação pronta", "export_id", exportID)
+	})
+
+	slog.InfoContext(r.Context(), "exportação agendada", "export_id", export.ID, "orders", count)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newDataExportResponse(export))
+}
+
+// GET /api/users/me/export/status?id= - Situação de uma exportação assíncrona
+func HandleDataExportStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	exportID := r.URL.Query().Get("id")
+	if exportID == "" {
+		sendError(w, "ID da exportação é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	export, err := models.GetDataExport(database.DB, exportID, claims.UserID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Exportação não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar exportação", "export_id", exportID, "error", err)
+		sendError(w, "Erro ao buscar exportação", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newDataExportResponse(export))
+}
+
+// GET /api/users/me/export/download?id= - Baixar ZIP de uma exportação pronta
+func HandleDataExportDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	exportID := r.URL.Query().Get("id")
+	if exportID == "" {
+		sendError(w, "ID da exportação é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := models.GetDataExportArchive(database.DB, exportID, claims.UserID)
+	if errors.Is(err, models.ErrExportNotReady) {
+		sendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar arquivo da exportação", "export_id", exportID, "error", err)
+		sendError(w, "Erro ao buscar exportação", http.StatusInternalServerError)
+		return
+	}
+
+	sendExportArchive(w, archive)
+}
+
+func newDataExportResponse(export *models.DataExport) DataExportResponse {
+	resp := DataExportResponse{
+		DataExport: *export,
+		StatusURL:  "/api/users/me/export/status?id=" + export.ID,
+	}
+	if export.Status == "ready" {
+		resp.DownloadURL = "/api/users/me/export/download?id=" + export.ID
+	}
+	return resp
+}
+
+func sendExportArchive(w http.ResponseWriter, archive []byte) {
+	filename := fmt.Sprintf("finplay-dados-%s.zip", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(archive)
+}
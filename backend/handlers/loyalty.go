@@ -0,0 +1,35 @@
+// Arquivo: backend/handlers/loyalty.go
+package handlers
+
+import (
+	"encoding/json"
+	"finplay/backend/database"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"log/slog"
+	"net/http"
+)
+
+// GET /api/loyalty - Saldo de pontos e extrato do programa de fidelidade
+func HandleGetLoyalty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	account, err := models.GetLoyaltyAccount(database.DB, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pontos de fidelidade", "user_id", claims.UserID, "error", err)
+		sendError(w, "Erro ao buscar pontos de fidelidade", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
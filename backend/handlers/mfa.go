@@ -0,0 +1,299 @@
+// Arquivo: backend/handlers/mfa.go
+package handlers
+
+import (
+	"encoding/json"
+	"finplay/backend/database"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFAVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+type MFAVerifyResponse struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFADisableRequest struct {
+	Password    string `json:"password"`
+	ReauthToken string `json:"reauth_token,omitempty"` // no lugar da senha
+	Code        string `json:"code"`
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// POST /api/auth/2fa/enroll - Gera segredo TOTP e URI otpauth
+func HandleMFAEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, claims.UserID)
+	if err != nil {
+		sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+	if user.TOTPEnabled {
+		sendError(w, "2FA já está ativo", http.StatusConflict)
+		return
+	}
+
+	secret, err := models.GenerateTOTPSecret()
+	if err != nil {
+		sendError(w, "Erro ao gerar segredo", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.SetUserTOTPSecret(database.DB, user.ID, secret); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao salvar segredo TOTP", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao iniciar 2FA", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "cadastro de 2FA iniciado", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MFAEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: models.TOTPURI(secret, user.Email),
+	})
+}
+
+// POST /api/auth/2fa/verify - Confirma o primeiro código e ativa o 2FA
+func HandleMFAVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var req MFAVerifyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, claims.UserID)
+	if err != nil {
+		sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+	if user.TOTPEnabled {
+		sendError(w, "2FA já está ativo", http.StatusConflict)
+		return
+	}
+	if user.TOTPSecret == "" {
+		sendError(w, "Cadastro de 2FA não iniciado", http.StatusBadRequest)
+		return
+	}
+
+	valid, err := models.VerifyUserTOTP(database.DB, user.ID, user.TOTPSecret, req.Code)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao validar código 2FA", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao validar código", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		sendError(w, "Código inválido", http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := models.GenerateRecoveryCodes()
+	if err != nil {
+		sendError(w, "Erro ao gerar códigos de recuperação", http.StatusInternalServerError)
+		return
+	}
+
+	if err := models.EnableUserTOTP(database.DB, user.ID, codes); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao ativar 2FA", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao ativar 2FA", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "2FA ativado", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MFAVerifyResponse{
+		Message:       "2FA ativado com sucesso. Guarde os códigos de recuperação em local seguro.",
+		RecoveryCodes: codes,
+	})
+}
+
+// POST /api/auth/2fa/disable - Desativa o 2FA (exige senha e código atual)
+func HandleMFADisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var req MFADisableRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, claims.UserID)
+	if err != nil {
+		sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+	if !user.TOTPEnabled {
+		sendError(w, "2FA não está ativo", http.StatusBadRequest)
+		return
+	}
+
+	if !reauthenticated(user, req.Password, req.ReauthToken) {
+		sendError(w, "Senha ou código inválidos", http.StatusUnauthorized)
+		return
+	}
+	valid, err := models.VerifyUserTOTP(database.DB, user.ID, user.TOTPSecret, req.Code)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao validar código 2FA", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao validar código", http.StatusInternalServerError)
+		return
+	}
+	if !valid {
+		sendError(w, "Senha ou código inválidos", http.StatusUnauthorized)
+		return
+	}
+
+	if err := models.DisableUserTOTP(database.DB, user.ID); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao desativar 2FA", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao desativar 2FA", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "2FA desativado", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "2FA desativado com sucesso"})
+}
+
+// POST /api/auth/login/2fa - Segunda etapa do login com código TOTP ou de recuperação
+func HandleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req MFALoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	pending, err := middleware.VerifyMFAPendingToken(req.MFAToken)
+	if err != nil {
+		sendError(w, "Token 2FA inválido ou expirado", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, pending.UserID)
+	if err != nil || !user.TOTPEnabled {
+		sendError(w, "Token 2FA inválido ou expirado", http.StatusUnauthorized)
+		return
+	}
+
+	lockedUntil, err := models.MFALockedUntil(database.DB, user.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao consultar bloqueio do 2FA", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao validar código", http.StatusInternalServerError)
+		return
+	}
+	if !lockedUntil.IsZero() {
+		sendMFALocked(w, lockedUntil)
+		return
+	}
+
+	valid := false
+	switch {
+	case req.Code != "":
+		valid, err = models.VerifyUserTOTP(database.DB, user.ID, user.TOTPSecret, req.Code)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro ao validar código 2FA", "user_id", user.ID, "error", err)
+		}
+	case req.RecoveryCode != "":
+		valid, err = models.UseRecoveryCode(database.DB, user.ID, req.RecoveryCode)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro ao validar código de recuperação", "user_id", user.ID, "error", err)
+		}
+		if valid {
+			slog.WarnContext(r.Context(), "código de recuperação utilizado", "user_id", user.ID)
+		}
+	}
+
+	if !valid {
+		slog.WarnContext(r.Context(), "código 2FA incorreto", "user_id", user.ID)
+		lockedUntil, err := models.RecordMFAFailure(database.DB, user.ID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro ao registrar falha do 2FA", "user_id", user.ID, "error", err)
+		}
+		if !lockedUntil.IsZero() {
+			slog.WarnContext(r.Context(), "2FA bloqueado após códigos incorretos", "user_id", user.ID, "locked_until", lockedUntil)
+			sendMFALocked(w, lockedUntil)
+			return
+		}
+		sendError(w, "Código inválido", http.StatusUnauthorized)
+		return
+	}
+
+	if err := models.ResetMFAFailures(database.DB, user.ID); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao zerar falhas do 2FA", "user_id", user.ID, "error", err)
+	}
+
+	// Atualizar último login
+	models.UpdateLastLogin(database.DB, user.ID)
+
+	token, err := middleware.GenerateToken(user.ID, user.Email, user.TokenVersion)
+	if err != nil {
+		sendError(w, "Erro ao gerar token", http.StatusInternalServerError)
+		return
+	}
+
+	csrfToken := setAuthCookie(w, token)
+
+	slog.InfoContext(r.Context(), "login com 2FA bem-sucedido", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AuthResponse{
+		Token:     token,
+		CSRFToken: csrfToken,
+		User:      *user,
+	})
+}
+
+func sendMFALocked(w http.ResponseWriter, until time.Time) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+	sendError(w, "Muitos códigos incorretos. Tente novamente mais tarde.", http.StatusTooManyRequests)
+}
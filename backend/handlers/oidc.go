@@ -0,0 +1,240 @@
+// Arquivo: backend/handlers/oidc.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"finplay/backend/database"
+	"finplay/backend/mailer"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"finplay/backend/oidc"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Com cookies seguros usa o prefixo __Secure- (__Host- exigiria Path=/)
+func oidcStateCookie() string {
+	if authConfig.CookieSecure {
+		return "__Secure-oidc_state"
+	}
+	return "oidc_state"
+}
+
+// GET /api/auth/oidc/providers - Provedores de login social disponíveis
+func HandleOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	names := oidc.Names()
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"providers": names})
+}
+
+// GET /api/auth/oidc/login?provider=google - Redireciona para o provedor
+func HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	beginOIDCFlow(w, r, "")
+}
+
+// GET /api/auth/oidc/reauth?provider=google - Reautenticar o usuário logado
+// com um login novo no provedor; o callback devolve um token de
+// reautenticação, aceito no lugar da senha (contas do login social não têm)
+func HandleOIDCReauth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+	beginOIDCFlow(w, r, claims.UserID)
+}
+
+func beginOIDCFlow(w http.ResponseWriter, r *http.Request, reauthUserID string) {
+	provider, ok := oidc.Get(r.URL.Query().Get("provider"))
+	if !ok {
+		sendError(w, "Provedor de login não configurado", http.StatusNotFound)
+		return
+	}
+
+	var (
+		flow    *oidc.AuthFlow
+		authURL string
+		err     error
+	)
+	if reauthUserID != "" {
+		flow, authURL, err = provider.BeginReauth(reauthUserID)
+	} else {
+		flow, authURL, err = provider.BeginAuth()
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao iniciar login OIDC", "provider", provider.Config.Name, "error", err)
+		sendError(w, "Provedor de login indisponível", http.StatusBadGateway)
+		return
+	}
+	oidc.Flows.Save(flow)
+
+	// Amarra o state ao navegador que iniciou o login
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie(),
+		Value:    flow.State,
+		Path:     "/api/auth/oidc",
+		HttpOnly: true,
+		Secure:   authConfig.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oidc.FlowTTL.Seconds()),
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// GET /api/auth/oidc/callback - Retorno do provedor com o código de autorização
+func HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie(),
+		Value:    "",
+		Path:     "/api/auth/oidc",
+		HttpOnly: true,
+		Secure:   authConfig.CookieSecure,
+		MaxAge:   -1,
+	})
+
+	if errCode := q.Get("error"); errCode != "" {
+		slog.WarnContext(r.Context(), "login OIDC recusado pelo provedor", "oidc_error", errCode)
+		redirectOIDCError(w, r, "login_recusado")
+		return
+	}
+
+	state := q.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie())
+	if state == "" || err != nil || cookie.Value != state {
+		redirectOIDCError(w, r, "state_invalido")
+		return
+	}
+
+	flow, ok := oidc.Flows.Take(state)
+	if !ok {
+		redirectOIDCError(w, r, "login_expirado")
+		return
+	}
+
+	provider, ok := oidc.Get(flow.Provider)
+	if !ok {
+		redirectOIDCError(w, r, "provedor_invalido")
+		return
+	}
+
+	idClaims, err := provider.Exchange(q.Get("code"), flow)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro no callback OIDC", "provider", flow.Provider, "error", err)
+		redirectOIDCError(w, r, "falha_autenticacao")
+		return
+	}
+
+	if flow.UserID != "" {
+		finishOIDCReauth(w, r, flow, idClaims)
+		return
+	}
+
+	user, created, err := models.FindOrCreateUserByIdentity(database.DB, models.ExternalIdentity{
+		Provider:      flow.Provider,
+		Subject:       idClaims.Subject,
+		Email:         idClaims.Email,
+		EmailVerified: idClaims.EmailVerified,
+		FullName:      idClaims.Name,
+	})
+	if err == models.ErrEmailNotVerified {
+		redirectOIDCError(w, r, "email_nao_verificado")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao vincular identidade OIDC", "provider", flow.Provider, "error", err)
+		redirectOIDCError(w, r, "falha_autenticacao")
+		return
+	}
+
+	if created {
+		slog.InfoContext(r.Context(), "usuário criado via login social", "provider", flow.Provider, "user_id", user.ID)
+	}
+
+	// Usuários com 2FA seguem para a segunda etapa no frontend
+	if user.TOTPEnabled {
+		mfaToken, err := middleware.GenerateMFAPendingToken(user.ID, user.Email)
+		if err != nil {
+			redirectOIDCError(w, r, "falha_autenticacao")
+			return
+		}
+		http.Redirect(w, r, mailer.AppURL()+"/login#mfa_token="+url.QueryEscape(mfaToken), http.StatusFound)
+		return
+	}
+
+	models.UpdateLastLogin(database.DB, user.ID)
+
+	token, err := middleware.GenerateToken(user.ID, user.Email, user.TokenVersion)
+	if err != nil {
+		redirectOIDCError(w, r, "falha_autenticacao")
+		return
+	}
+
+	setAuthCookie(w, token)
+
+	slog.InfoContext(r.Context(), "login social bem-sucedido", "provider", flow.Provider, "user_id", user.ID)
+
+	// Token vai no fragmento para não ser enviado a servidores nem registrado em logs
+	http.Redirect(w, r, mailer.AppURL()+"/#token="+url.QueryEscape(token), http.StatusFound)
+}
+
+// Reautenticação: a identidade tem que ser uma das já vinculadas à conta que
+// iniciou o fluxo; nada é vinculado nem criado aqui
+func finishOIDCReauth(w http.ResponseWriter, r *http.Request, flow *oidc.AuthFlow, idClaims *oidc.IDTokenClaims) {
+	userID, err := models.GetUserIDByIdentity(database.DB, flow.Provider, idClaims.Subject)
+	if errors.Is(err, models.ErrIdentityNotLinked) || (err == nil && userID != flow.UserID) {
+		slog.WarnContext(r.Context(), "reautenticação com identidade de outra conta", "provider", flow.Provider, "user_id", flow.UserID)
+		redirectOIDCError(w, r, "conta_diferente")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao consultar identidade OIDC", "provider", flow.Provider, "error", err)
+		redirectOIDCError(w, r, "falha_autenticacao")
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, userID)
+	if err != nil {
+		redirectOIDCError(w, r, "falha_autenticacao")
+		return
+	}
+	token, err := middleware.GenerateReauthToken(user.ID, user.Email, user.TokenVersion)
+	if err != nil {
+		redirectOIDCError(w, r, "falha_autenticacao")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "reautenticação social bem-sucedida", "provider", flow.Provider, "user_id", user.ID)
+
+	http.Redirect(w, r, mailer.AppURL()+"/#reauth_token="+url.QueryEscape(token), http.StatusFound)
+}
+
+func redirectOIDCError(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, mailer.AppURL()+"/login?oidc_error="+url.QueryEscape(code), http.StatusFound)
+}
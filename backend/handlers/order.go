@@ -2,11 +2,15 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"finplay/backend/database"
+	"finplay/backend/metrics"
 	"finplay/backend/middleware"
 	"finplay/backend/models"
-	"log"
+	"finplay/backend/payments"
+	"log/slog"
 	"net/http"
 )
 
@@ -24,8 +28,7 @@ func HandleCreateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.CreateOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "Dados inválidos", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -34,23 +37,39 @@ func HandleCreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("🛒 Criando pedido para usuário: %s", claims.Email)
+	slog.DebugContext(r.Context(), "criando pedido", "user_id", claims.UserID, "items", len(req.Items))
 
-	order, err := models.CreateOrder(database.DB, claims.UserID, req.Items, req.Notes)
+	order, err := models.CreateOrder(database.DB, claims.UserID, req)
+	if isOrderInputError(err) {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		log.Printf("❌ Erro ao criar pedido: %v", err)
+		slog.ErrorContext(r.Context(), "erro ao criar pedido", "user_id", claims.UserID, "error", err)
 		sendError(w, "Erro ao criar pedido", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("✅ Pedido criado: %s (%d itens)", order.ID, order.TotalItems)
+	metrics.OrdersCreated.Inc()
+	slog.InfoContext(r.Context(), "pedido criado", "order_id", order.ID, "items", order.TotalItems,
+		"total_cents", order.TotalCents, "discount_cents", order.DiscountCents)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(order)
 }
 
-// POST /api/orders/:id/complete - Finalizar pedido
+// Erros de itens, cupom ou pontos do pedido (400)
+func isOrderInputError(err error) bool {
+	return errors.Is(err, models.ErrUnknownProduct) || errors.Is(err, models.ErrInvalidQuantity) ||
+		errors.Is(err, models.ErrInvalidModifier) ||
+		errors.Is(err, models.ErrCouponInvalid) || errors.Is(err, models.ErrCouponExpired) ||
+		errors.Is(err, models.ErrCouponUsageLimit) || errors.Is(err, models.ErrCouponNotApplicable) ||
+		errors.Is(err, models.ErrInsufficientPoints) || errors.Is(err, models.ErrRedeemDisabled)
+}
+
+// POST /api/orders/:id/complete - Finalizar pedido (já pago). Restrito à
+// equipe da loja, que confirma a entrega: só então os pontos são creditados.
 func HandleCompleteOrder(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
@@ -70,16 +89,31 @@ func HandleCompleteOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("✅ Finalizando pedido: %s", orderID)
+	slog.DebugContext(r.Context(), "finalizando pedido", "order_id", orderID)
 
-	err := models.CompleteOrder(database.DB, orderID, claims.UserID)
+	ownerID, err := models.CompleteOrder(database.DB, orderID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Pedido não encontrado", http.StatusNotFound)
+		return
+	}
+	if err == models.ErrOrderNotPaid {
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		log.Printf("❌ Erro ao finalizar pedido: %v", err)
+		slog.ErrorContext(r.Context(), "erro ao finalizar pedido", "order_id", orderID, "error", err)
 		sendError(w, "Erro ao finalizar pedido", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("✅ Pedido finalizado: %s", orderID)
+	metrics.OrdersCompleted.Inc()
+	slog.InfoContext(r.Context(), "pedido finalizado", "order_id", orderID, "staff_id", claims.UserID)
+
+	// Emitir o cupom fiscal já na finalização; se falhar, a consulta em
+	// /api/orders/{id}/receipt tenta de novo
+	if _, err := issueReceipt(r.Context(), orderID, ownerID); err != nil {
+		slog.WarnContext(r.Context(), "cupom fiscal não emitido na finalização", "order_id", orderID, "error", err)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Pedido finalizado com sucesso"})
@@ -98,11 +132,11 @@ func HandleGetOrderHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("📜 Buscando histórico de pedidos: %s", claims.Email)
+	slog.DebugContext(r.Context(), "buscando histórico de pedidos", "user_id", claims.UserID)
 
 	orders, err := models.GetUserOrderHistory(database.DB, claims.UserID)
 	if err != nil {
-		log.Printf("❌ Erro ao buscar histórico: %v", err)
+		slog.ErrorContext(r.Context(), "erro ao buscar histórico", "user_id", claims.UserID, "error", err)
 		sendError(w, "Erro ao buscar histórico", http.StatusInternalServerError)
 		return
 	}
@@ -111,13 +145,15 @@ func HandleGetOrderHistory(w http.ResponseWriter, r *http.Request) {
 		orders = []models.Order{} // Retornar array vazio ao invés de null
 	}
 
-	log.Printf("✅ Histórico encontrado: %d pedidos", len(orders))
+	slog.DebugContext(r.Context(), "histórico encontrado", "user_id", claims.UserID, "orders", len(orders))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(orders)
 }
 
-// POST /api/orders/:id/cancel - Cancelar pedido
+// POST /api/orders/:id/cancel - Cancelar pedido (pendente, com pagamento
+// autorizado, que é liberado no gateway, ou pago e ainda não finalizado, que é
+// estornado)
 func HandleCancelOrder(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
@@ -136,17 +172,129 @@ func HandleCancelOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("❌ Cancelando pedido: %s", orderID)
+	slog.DebugContext(r.Context(), "cancelando pedido", "order_id", orderID)
 
-	err := models.CancelOrder(database.DB, orderID, claims.UserID)
+	err := payments.CancelOrder(r.Context(), database.DB, orderID, claims.UserID)
+	if err == models.ErrOrderState {
+		sendError(w, "Pedido já finalizado ou cancelado; fale com a loja para solicitar o estorno", http.StatusConflict)
+		return
+	}
 	if err != nil {
-		log.Printf("❌ Erro ao cancelar pedido: %v", err)
-		sendError(w, "Erro ao cancelar pedido", http.StatusInternalServerError)
+		sendPaymentError(w, r, err, "order_id", orderID)
 		return
 	}
 
-	log.Printf("✅ Pedido cancelado: %s", orderID)
+	metrics.OrdersCancelled.Inc()
+	slog.InfoContext(r.Context(), "pedido cancelado", "order_id", orderID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Pedido cancelado com sucesso"})
 }
+
+// GET /api/orders/detail?id=uuid - Pedido com itens, pagamentos e estornos
+func HandleGetOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	orderID := r.URL.Query().Get("id")
+	if orderID == "" {
+		sendError(w, "ID do pedido é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	detail, err := models.GetOrderDetail(database.DB, orderID, claims.UserID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Pedido não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pedido", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao buscar pedido", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+type CancelItemsRequest struct {
+	Items  []models.ItemCancellation `json:"items"`
+	Reason string                    `json:"reason"`
+	Note   string                    `json:"note"`
+}
+
+// POST /api/orders/items/cancel?id=uuid - Cancelar itens do pedido. O total é
+// recalculado e, se o pedido já foi pago, o valor dos itens é estornado.
+func HandleCancelOrderItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	orderID := r.URL.Query().Get("id")
+	if orderID == "" {
+		sendError(w, "ID do pedido é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	var req CancelItemsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Items) == 0 {
+		sendError(w, "Informe pelo menos um item", http.StatusBadRequest)
+		return
+	}
+	reason, err := models.NormalizeRefundReason(req.Reason, req.Note)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, err := models.GetOrderByID(database.DB, orderID, claims.UserID)
+	if err == nil {
+		var refund *models.Refund
+		refund, err = payments.CancelItems(r.Context(), database.DB, order, req.Items, reason, req.Note)
+		if err == nil && refund != nil {
+			slog.InfoContext(r.Context(), "itens estornados", "order_id", orderID, "refund_id", refund.ID, "amount_cents", refund.AmountCents)
+		}
+	}
+	if err == models.ErrOrderState {
+		if order != nil && order.Status == models.OrderCompleted {
+			sendError(w, "Pedido já entregue; fale com a loja para solicitar o estorno", http.StatusConflict)
+			return
+		}
+		sendError(w, "Pedido cancelado ou estornado não permite cancelar itens", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		sendPaymentError(w, r, err, "order_id", orderID)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "itens cancelados", "order_id", orderID, "items", len(req.Items), "reason", reason)
+
+	detail, err := models.GetOrderDetail(database.DB, orderID, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pedido", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao buscar pedido", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
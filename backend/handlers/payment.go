@@ -0,0 +1,229 @@
+// Arquivo: backend/handlers/payment.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"finplay/backend/database"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"finplay/backend/payments"
+	"log/slog"
+	"net/http"
+)
+
+type PayOrderRequest struct {
+	// Token do meio de pagamento gerado pelo SDK do gateway no frontend
+	PaymentMethod string `json:"payment_method"`
+}
+
+// Resposta de pagamento recusado (402)
+type PaymentDeclinedResponse struct {
+	Error       string         `json:"error"`
+	DeclineCode string         `json:"decline_code"`
+	Payment     models.Payment `json:"payment"`
+}
+
+// POST /api/orders/pay?id=uuid - Pagar pedido pendente
+func HandlePayOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	orderID := r.URL.Query().Get("id")
+	if orderID == "" {
+		sendError(w, "ID do pedido é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	var req PayOrderRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.PaymentMethod == "" {
+		sendError(w, "Meio de pagamento é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	order, err := models.GetOrderByID(database.DB, orderID, claims.UserID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Pedido não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pedido", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+		return
+	}
+
+	payment, err := payments.Pay(r.Context(), database.DB, order, req.PaymentMethod)
+	if errors.Is(err, payments.ErrDeclined) {
+		slog.InfoContext(r.Context(), "pagamento recusado", "order_id", orderID, "decline_code", payment.DeclineCode)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(PaymentDeclinedResponse{
+			Error:       "Pagamento recusado",
+			DeclineCode: payment.DeclineCode,
+			Payment:     *payment,
+		})
+		return
+	}
+	if err != nil {
+		sendPaymentError(w, r, err, "order_id", orderID)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "pagamento aprovado", "order_id", orderID, "payment_id", payment.ID, "status", payment.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+// GET /api/payments?order_id=uuid - Tentativas de pagamento do pedido
+func HandleListPayments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	orderID := r.URL.Query().Get("order_id")
+	if orderID == "" {
+		sendError(w, "ID do pedido é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	list, err := models.GetOrderPayments(database.DB, orderID, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pagamentos", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao buscar pagamentos", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// POST /api/payments/capture?id=uuid - Capturar pagamento autorizado
+func HandleCapturePayment(w http.ResponseWriter, r *http.Request) {
+	handlePaymentOperation(w, r, "capturado", func(p *models.Payment) error {
+		return payments.Capture(r.Context(), database.DB, p)
+	})
+}
+
+// POST /api/payments/void?id=uuid - Liberar autorização (o pedido volta a pending)
+func HandleVoidPayment(w http.ResponseWriter, r *http.Request) {
+	handlePaymentOperation(w, r, "liberado", func(p *models.Payment) error {
+		return payments.Void(r.Context(), database.DB, p, false)
+	})
+}
+
+// Estorno total ou parcial. Sem corpo (ou sem amount_cents) estorna todo o
+// saldo capturado.
+type RefundRequest struct {
+	AmountCents int64  `json:"amount_cents"`
+	Reason      string `json:"reason"`
+	Note        string `json:"note"`
+}
+
+// POST /api/payments/refund?id=uuid - Estornar pagamento capturado
+func HandleRefundPayment(w http.ResponseWriter, r *http.Request) {
+	var req RefundRequest
+	if r.Method == http.MethodPost && r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.AmountCents < 0 {
+		sendError(w, models.ErrInvalidRefundAmount.Error(), http.StatusBadRequest)
+		return
+	}
+	reason, err := models.NormalizeRefundReason(req.Reason, req.Note)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handlePaymentOperation(w, r, "estornado", func(p *models.Payment) error {
+		_, err := payments.Refund(r.Context(), database.DB, p, req.AmountCents, reason, req.Note)
+		return err
+	})
+}
+
+// Estrutura comum das operações da equipe sobre um pagamento existente
+// (rotas protegidas por middleware.RequireStaff)
+func handlePaymentOperation(w http.ResponseWriter, r *http.Request, done string, op func(p *models.Payment) error) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID := r.URL.Query().Get("id")
+	if paymentID == "" {
+		sendError(w, "ID do pagamento é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := models.GetPaymentByID(database.DB, paymentID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Pagamento não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pagamento", "payment_id", paymentID, "error", err)
+		sendError(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+		return
+	}
+
+	if err := op(payment); err != nil {
+		sendPaymentError(w, r, err, "payment_id", paymentID)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "pagamento "+done, "payment_id", payment.ID, "order_id", payment.OrderID, "staff_id", claims.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// Traduzir erros de pagamento para status HTTP
+func sendPaymentError(w http.ResponseWriter, r *http.Request, err error, args ...any) {
+	var unavailable *payments.UnavailableError
+	switch {
+	case err == sql.ErrNoRows:
+		sendError(w, "Pedido não encontrado", http.StatusNotFound)
+	case errors.Is(err, models.ErrOrderNotPayable), errors.Is(err, models.ErrOrderState),
+		errors.Is(err, models.ErrPaymentState), errors.Is(err, models.ErrPaymentInProgress),
+		errors.Is(err, payments.ErrInvalidState), errors.Is(err, payments.ErrUnsupported),
+		errors.Is(err, payments.ErrRefundRejected):
+		sendError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, models.ErrInvalidRefundAmount), errors.Is(err, models.ErrOrderItemNotFound),
+		errors.Is(err, models.ErrInvalidQuantity):
+		sendError(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &unavailable):
+		slog.WarnContext(r.Context(), "gateway de pagamento indisponível", append(args, "error", err)...)
+		sendError(w, "Gateway de pagamento indisponível, tente novamente", http.StatusBadGateway)
+	default:
+		slog.ErrorContext(r.Context(), "erro no pagamento", append(args, "error", err)...)
+		sendError(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+	}
+}
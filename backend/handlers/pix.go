@@ -0,0 +1,137 @@
+// Arquivo: backend/handlers/pix.go
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"finplay/backend/database"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"finplay/backend/payments"
+	"finplay/backend/payments/pix"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Cobrança PIX com o QR Code em PNG (base64) para exibir no checkout
+type PixChargeResponse struct {
+	models.PixCharge
+	QRCodePNG string `json:"qr_code_png,omitempty"`
+}
+
+// /api/orders/pix?id=uuid
+//
+//	POST - gerar (ou reaproveitar) a cobrança PIX do pedido
+//	GET  - consultar a cobrança mais recente (para acompanhar o pagamento)
+func HandleOrderPix(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	if !payments.PixEnabled() {
+		sendError(w, "Pagamento via PIX indisponível", http.StatusServiceUnavailable)
+		return
+	}
+
+	orderID := r.URL.Query().Get("id")
+	if orderID == "" {
+		sendError(w, "ID do pedido é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	var charge *models.PixCharge
+	var err error
+	status := http.StatusOK
+
+	if r.Method == http.MethodGet {
+		if _, err = models.ExpirePixCharges(database.DB); err == nil {
+			charge, err = models.GetLatestPixCharge(database.DB, orderID, claims.UserID)
+		}
+		if err == sql.ErrNoRows {
+			sendError(w, "Cobrança PIX não encontrada", http.StatusNotFound)
+			return
+		}
+	} else {
+		var order *models.Order
+		order, err = models.GetOrderByID(database.DB, orderID, claims.UserID)
+		if err == nil {
+			charge, err = payments.CreatePixCharge(r.Context(), database.DB, order)
+			status = http.StatusCreated
+		}
+	}
+	if err != nil {
+		sendPaymentError(w, r, err, "order_id", orderID)
+		return
+	}
+
+	resp := PixChargeResponse{PixCharge: *charge}
+	if charge.Status == models.PaymentPending {
+		png, err := pix.QRCodePNG(charge.Payload, pix.QRCodeSize)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro ao gerar QR Code PIX", "order_id", orderID, "error", err)
+			sendError(w, "Erro ao gerar QR Code", http.StatusInternalServerError)
+			return
+		}
+		resp.QRCodePNG = base64.StdEncoding.EncodeToString(png)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// POST /api/webhooks/pix - Notificação de PIX recebido enviada pelo PSP.
+// Rota pública: a autenticidade vem da assinatura HMAC no header X-Pix-Signature.
+func HandlePixWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !payments.PixEnabled() {
+		sendError(w, "Pagamento via PIX indisponível", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		bodyErr := bodyError(err)
+		sendError(w, bodyErr.Message, bodyErr.Status)
+		return
+	}
+
+	err = pix.VerifySignature(payments.PixWebhookSecret(), r.Header.Get(pix.SignatureHeader), body, time.Now())
+	if err != nil {
+		slog.WarnContext(r.Context(), "webhook PIX com assinatura inválida", "error", err)
+		sendError(w, "Assinatura inválida", http.StatusUnauthorized)
+		return
+	}
+
+	var notification pix.Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		sendError(w, "JSON malformado", http.StatusBadRequest)
+		return
+	}
+
+	// Falha de banco responde 500 para o PSP reenviar; as transferências já
+	// processadas são ignoradas na repetição
+	for _, transfer := range notification.Pix {
+		if err := payments.ConfirmPix(r.Context(), database.DB, transfer); err != nil {
+			slog.ErrorContext(r.Context(), "erro ao processar PIX", "txid", transfer.TxID, "error", err)
+			sendError(w, "Erro ao processar notificação", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
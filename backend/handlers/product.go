@@ -0,0 +1,28 @@
+// Arquivo: backend/handlers/product.go
+package handlers
+
+import (
+	"encoding/json"
+	"finplay/backend/database"
+	"finplay/backend/models"
+	"log/slog"
+	"net/http"
+)
+
+// GET /api/products - Cardápio com preços e grupos de opções
+func HandleListProducts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	products, err := models.GetActiveProducts(database.DB)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao listar produtos", "error", err)
+		sendError(w, "Erro ao listar produtos", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]models.Product{"products": products})
+}
@@ -0,0 +1,28 @@
+// Arquivo: backend/handlers/promotion.go
+package handlers
+
+import (
+	"encoding/json"
+	"finplay/backend/database"
+	"finplay/backend/models"
+	"log/slog"
+	"net/http"
+)
+
+// GET /api/promotions - Promoções automáticas vigentes (cupons não são listados)
+func HandleListPromotions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	promotions, err := models.GetActivePromotions(database.DB)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao listar promoções", "error", err)
+		sendError(w, "Erro ao listar promoções", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]models.Promotion{"promotions": promotions})
+}
@@ -0,0 +1,134 @@
+// Arquivo: backend/handlers/receipt.go
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"finplay/backend/database"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"finplay/backend/receipts"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// GET /api/orders/{id}/receipt - Documentos do pedido
+func HandleOrderDocument(w http.ResponseWriter, r *http.Request) {
+	orderID, doc, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/orders/"), "/")
+	if !ok || orderID == "" || doc != "receipt" {
+		sendError(w, "Rota não encontrada", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	handleOrderReceipt(w, r, orderID, claims.UserID)
+}
+
+// GET /api/orders/{id}/receipt?format=pdf|xml - Cupom fiscal (NFC-e) do
+// pedido finalizado; emitido na primeira consulta se ainda não existir
+func handleOrderReceipt(w http.ResponseWriter, r *http.Request, orderID, userID string) {
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "xml" {
+		sendError(w, "Formato inválido (use pdf ou xml)", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := issueReceipt(r.Context(), orderID, userID)
+	var rejected *receipts.RejectedError
+	switch {
+	case err == sql.ErrNoRows:
+		sendError(w, "Pedido não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, receipts.ErrOrderNotCompleted):
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, models.ErrReceiptPending):
+		w.Header().Set("Retry-After", "1")
+		sendError(w, "Cupom fiscal em emissão; tente novamente", http.StatusConflict)
+		return
+	case errors.As(err, &rejected):
+		slog.WarnContext(r.Context(), "NFC-e rejeitada", "order_id", orderID, "status", rejected.Status, "message", rejected.Message)
+		sendError(w, rejected.Error(), http.StatusBadGateway)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "erro ao emitir cupom fiscal", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao emitir cupom fiscal", http.StatusInternalServerError)
+		return
+	}
+
+	name := fmt.Sprintf("nfce-%03d-%09d", receipt.Series, receipt.Number)
+	if format == "xml" {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.xml"`, name))
+		w.Write(receipt.XML)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, name))
+	w.Write(receipt.PDF)
+}
+
+// Buscar o pedido do usuário e emitir (ou recuperar) o cupom
+func issueReceipt(ctx context.Context, orderID, userID string) (*models.Receipt, error) {
+	order, err := models.GetOrderByID(database.DB, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return receipts.Issue(ctx, database.DB, order)
+}
+
+// GET /api/orders/ticket?id=uuid - Comanda da cozinha em texto (pedido pago).
+// Restrita à equipe da loja, que imprime a comanda de qualquer cliente.
+func HandleKitchenTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	orderID := r.URL.Query().Get("id")
+	if orderID == "" {
+		sendError(w, "ID do pedido é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	order, err := models.GetOrderForStaff(database.DB, orderID)
+	if err == sql.ErrNoRows {
+		sendError(w, "Pedido não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao buscar pedido", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao gerar comanda", http.StatusInternalServerError)
+		return
+	}
+
+	ticket, err := receipts.KitchenTicket(order)
+	if errors.Is(err, receipts.ErrTicketUnavailable) {
+		sendError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao gerar comanda", "order_id", orderID, "error", err)
+		sendError(w, "Erro ao gerar comanda", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(ticket)
+}
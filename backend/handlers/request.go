@@ -0,0 +1,73 @@
+// Arquivo: backend/handlers/request.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Erro de leitura do corpo da requisição, com status e mensagem para o cliente
+type BodyError struct {
+	Status  int
+	Message string
+}
+
+func (e *BodyError) Error() string {
+	return e.Message
+}
+
+// Decodificar o corpo JSON em dst. Rejeita campos desconhecidos, conteúdo
+// após o objeto e corpos acima do limite definido por middleware.MaxBodySize.
+func DecodeJSON(r *http.Request, dst any) *BodyError {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return bodyError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return &BodyError{Status: http.StatusBadRequest, Message: "Corpo deve conter um único objeto JSON"}
+	}
+	return nil
+}
+
+func bodyError(err error) *BodyError {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &BodyError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("Corpo da requisição excede o limite de %d bytes", maxBytesErr.Limit),
+		}
+	case errors.Is(err, io.EOF):
+		return &BodyError{Status: http.StatusBadRequest, Message: "Corpo da requisição vazio"}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{Status: http.StatusBadRequest, Message: "JSON malformado"}
+	case errors.As(err, &typeErr):
+		return &BodyError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("Tipo inválido para o campo %q", typeErr.Field),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json não exporta um tipo para este erro
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return &BodyError{Status: http.StatusBadRequest, Message: "Campo desconhecido: " + field}
+	}
+	return &BodyError{Status: http.StatusBadRequest, Message: "Dados inválidos"}
+}
+
+// Decodificar e responder o erro no formato padrão; false se a requisição foi rejeitada
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := DecodeJSON(r, dst); err != nil {
+		sendError(w, err.Message, err.Status)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,286 @@
+// Arquivo: backend/handlers/user.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"finplay/backend/database"
+	"finplay/backend/mailer"
+	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"log/slog"
+	"net/http"
+)
+
+type PasswordConfirmRequest struct {
+	Password    string `json:"password"`
+	ReauthToken string `json:"reauth_token,omitempty"` // no lugar da senha
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// GET/PATCH/DELETE /api/users/me - Perfil do usuário logado
+func HandleUserMe(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		HandleGetMe(w, r)
+	case http.MethodPatch:
+		handleUpdateProfile(w, r)
+	case http.MethodDelete:
+		handleDeleteAccount(w, r)
+	default:
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// PATCH /api/users/me - Atualizar perfil
+func handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := models.UpdateUserProfile(database.DB, claims.UserID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidFullName):
+			sendError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrUserNotFound):
+			sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		default:
+			slog.ErrorContext(r.Context(), "erro ao atualizar perfil", "user_id", claims.UserID, "error", err)
+			sendError(w, "Erro ao atualizar perfil", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "perfil atualizado", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// DELETE /api/users/me - Excluir conta (anonimização LGPD, pedidos são mantidos)
+func handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	user, ok := confirmPassword(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if err := models.AnonymizeUser(database.DB, user.ID); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao excluir conta", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao excluir conta", http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookie(w)
+
+	slog.InfoContext(r.Context(), "conta excluída e anonimizada", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Conta excluída com sucesso"})
+}
+
+// POST /api/users/me/deactivate - Desativar conta
+func HandleDeactivateAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	user, ok := confirmPassword(w, r, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if err := models.DeactivateUser(database.DB, user.ID); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao desativar conta", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao desativar conta", http.StatusInternalServerError)
+		return
+	}
+
+	clearAuthCookie(w)
+
+	slog.InfoContext(r.Context(), "conta desativada", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Conta desativada com sucesso"})
+}
+
+// POST /api/users/me/email - Solicitar troca de email (confirmação pelo novo endereço)
+func HandleRequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		sendError(w, "Não autenticado", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.EmailChangeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := models.GetUserByID(database.DB, claims.UserID)
+	if err != nil {
+		sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		return
+	}
+	if !reauthenticated(user, req.Password, req.ReauthToken) {
+		sendError(w, "Senha incorreta", http.StatusUnauthorized)
+		return
+	}
+
+	token, newEmail, err := models.CreateEmailChangeRequest(database.DB, user.ID, req.NewEmail)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEmailTaken):
+			sendError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, models.ErrInvalidEmail):
+			sendError(w, err.Error(), http.StatusBadRequest)
+		default:
+			slog.ErrorContext(r.Context(), "erro ao solicitar troca de email", "user_id", user.ID, "error", err)
+			sendError(w, "Erro ao solicitar troca de email", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirme seu novo email - FinPlay",
+		Body: "Para confirmar a troca de email da sua conta FinPlay, acesse:\n" +
+			mailer.AppURL() + "/confirmar-email?token=" + token,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "erro ao enviar email de confirmação", "user_id", user.ID, "error", err)
+		sendError(w, "Erro ao enviar email de confirmação", http.StatusInternalServerError)
+		return
+	}
+
+	// Avisar o endereço atual, que pode não ser de quem pediu a troca
+	notifyOldEmail(r, user.ID, user.Email, "Troca de email solicitada - FinPlay",
+		"Recebemos um pedido para trocar o email da sua conta FinPlay para "+newEmail+".\n"+
+			"A troca só vale depois de confirmada pelo link enviado ao novo endereço.\n"+
+			"Se não foi você, troque sua senha em "+mailer.AppURL()+" e fale com o suporte.")
+
+	slog.InfoContext(r.Context(), "troca de email solicitada", "user_id", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Enviamos um link de confirmação para o novo email"})
+}
+
+// POST /api/users/me/email/confirm - Confirmar troca de email (rota pública, vinda do link)
+func HandleConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ConfirmEmailChangeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Token == "" {
+		sendError(w, "Token é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	user, oldEmail, err := models.ConfirmEmailChange(database.DB, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrEmailTaken):
+			sendError(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, models.ErrInvalidEmailChangeToken), errors.Is(err, models.ErrUserNotFound):
+			sendError(w, models.ErrInvalidEmailChangeToken.Error(), http.StatusBadRequest)
+		default:
+			slog.ErrorContext(r.Context(), "erro ao confirmar troca de email", "error", err)
+			sendError(w, "Erro ao confirmar troca de email", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Token antigo carrega o email anterior; emitir um novo
+	token, err := middleware.GenerateToken(user.ID, user.Email, user.TokenVersion)
+	if err != nil {
+		sendError(w, "Erro ao gerar token", http.StatusInternalServerError)
+		return
+	}
+
+	csrfToken := setAuthCookie(w, token)
+
+	slog.InfoContext(r.Context(), "email alterado", "user_id", user.ID)
+
+	notifyOldEmail(r, user.ID, oldEmail, "Email da conta alterado - FinPlay",
+		"O email da sua conta FinPlay foi alterado para "+user.Email+".\n"+
+			"Se não foi você, fale com o suporte.")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AuthResponse{
+		Token:     token,
+		CSRFToken: csrfToken,
+		User:      *user,
+	})
+}
+
+// Aviso ao email anterior; falha no envio não desfaz a operação
+func notifyOldEmail(r *http.Request, userID, to, subject, body string) {
+	if err := mailer.Send(mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+		slog.WarnContext(r.Context(), "erro ao avisar o email anterior", "user_id", userID, "error", err)
+	}
+}
+
+// Reautenticar com a senha (ou token de reautenticação) antes de operações destrutivas
+func confirmPassword(w http.ResponseWriter, r *http.Request, userID string) (*models.User, bool) {
+	var req PasswordConfirmRequest
+	if !decodeJSON(w, r, &req) {
+		return nil, false
+	}
+
+	user, err := models.GetUserByID(database.DB, userID)
+	if err != nil {
+		sendError(w, "Usuário não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+
+	if !reauthenticated(user, req.Password, req.ReauthToken) {
+		sendError(w, "Senha incorreta", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// Conferir a senha ou, para contas sem senha (login social), o token de um
+// login recente no provedor (GET /api/auth/oidc/reauth)
+func reauthenticated(user *models.User, password, reauthToken string) bool {
+	if reauthToken == "" {
+		return models.CheckPassword(password, user.PasswordHash)
+	}
+	claims, err := middleware.VerifyReauthToken(reauthToken)
+	return err == nil && claims.UserID == user.ID && claims.TokenVersion == user.TokenVersion
+}
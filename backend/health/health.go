@@ -0,0 +1,135 @@
+// Arquivo: backend/health/health.go
+
+// Verificações de liveness (/healthz) e readiness (/readyz).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Dependência verificada pelo /readyz. Se Critical, a falha torna o
+// serviço indisponível (503); caso contrário o status fica "degraded".
+type Check struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+// Timeout usado quando a verificação não define um
+const defaultTimeout = 2 * time.Second
+
+var (
+	mu     sync.RWMutex
+	checks []Check
+)
+
+// Registrar uma verificação de dependência
+func Register(c Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks = append(checks, c)
+}
+
+// Executar todas as verificações em paralelo
+func Run(ctx context.Context) Report {
+	mu.RLock()
+	list := append([]Check(nil), checks...)
+	mu.RUnlock()
+
+	report := Report{
+		Status:     "ok",
+		Components: make(map[string]ComponentStatus, len(list)),
+		CheckedAt:  time.Now().UTC(),
+	}
+
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	for _, c := range list {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			status := runCheck(ctx, c)
+
+			resultMu.Lock()
+			report.Components[c.Name] = status
+			resultMu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	for _, status := range report.Components {
+		if status.Status == "ok" {
+			continue
+		}
+		if status.Critical {
+			report.Status = "down"
+			break
+		}
+		report.Status = "degraded"
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, c Check) ComponentStatus {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Run(ctx)
+	status := ComponentStatus{
+		Status:    "ok",
+		Critical:  c.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = "down"
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// GET /healthz - Liveness: o processo está de pé e respondendo
+func HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// GET /readyz - Readiness: dependências verificadas; 503 se alguma crítica falhar
+func HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	report := Run(r.Context())
+
+	code := http.StatusOK
+	if report.Status == "down" {
+		code = http.StatusServiceUnavailable
+		slog.WarnContext(r.Context(), "serviço não está pronto", "components", report.Components)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,130 @@
+// Arquivo: backend/logger/logger.go
+package logger
+
+import (
+	"context"
+	"finplay/backend/config"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type contextKey string
+
+const requestIDKey contextKey = "request_id"
+
+// Campos com dados pessoais ou sensíveis, redigidos automaticamente
+var redactedKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"mfa_token":     true,
+	"code":          true,
+	"recovery_code": true,
+	"message":       true, // mensagens do chat
+	"content":       true,
+	"history":       true,
+	"notes":         true,
+	"full_name":     true,
+	"ip":            true,
+	"body":          true,
+}
+
+// Campos de email: mantém apenas o domínio e a primeira letra
+var emailKeys = map[string]bool{
+	"email":     true,
+	"new_email": true,
+	"to":        true,
+}
+
+// Configurar o logger padrão (slog + pacote log) com o nível
+// (debug, info, warn, error) e o formato (json ou text) configurados
+func Setup(cfg config.LogConfig) {
+	slog.SetDefault(New(os.Stdout, cfg.Level, cfg.Format))
+}
+
+// Criar logger com redação de PII e request ID vindo do contexto
+func New(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redact,
+	}
+
+	var h slog.Handler
+	if strings.EqualFold(format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(&contextHandler{Handler: h})
+}
+
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Adicionar request ID ao contexto
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// Request ID do contexto (vazio fora de uma requisição)
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Handler que inclui o request_id do contexto em toda linha de log
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	// Chaves internas do slog (time, level, msg) nunca são redigidas
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+		return a
+	}
+
+	key := strings.ToLower(a.Key)
+	switch {
+	case redactedKeys[key]:
+		return slog.String(a.Key, "[REDACTED]")
+	case emailKeys[key]:
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	}
+	return a
+}
+
+// Mascarar email: "rodrigo@gmail.com" -> "r***@gmail.com"
+func MaskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return "[REDACTED]"
+	}
+	return email[:1] + "***" + email[at:]
+}
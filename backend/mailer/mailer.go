@@ -0,0 +1,47 @@
+// Arquivo: backend/mailer/mailer.go
+package mailer
+
+import (
+	"finplay/backend/config"
+	"log/slog"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Envio de emails transacionais (verificação de email, avisos de conta)
+type Sender interface {
+	Send(msg Message) error
+}
+
+// Implementação padrão: apenas registra o email no log (desenvolvimento).
+// O conteúdo não é registrado em nenhum nível, pois contém links com token.
+type LogSender struct{}
+
+func (LogSender) Send(msg Message) error {
+	slog.Info("email enviado (log)", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+// Sender usado pelos handlers (substituir por SMTP/provedor em produção)
+var Default Sender = LogSender{}
+
+// Enviar email com o Sender padrão
+func Send(msg Message) error {
+	return Default.Send(msg)
+}
+
+var appURL = config.Default().App.URL
+
+// Aplicar a configuração carregada
+func Configure(cfg config.AppConfig) {
+	appURL = cfg.URL
+}
+
+// URL pública do frontend usada nos links enviados por email
+func AppURL() string {
+	return appURL
+}
@@ -2,15 +2,29 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"finplay/backend/config"
 	"finplay/backend/database"
 	"finplay/backend/handlers"
+	"finplay/backend/health"
+	"finplay/backend/logger"
+	"finplay/backend/mailer"
+	"finplay/backend/metrics"
 	"finplay/backend/middleware"
+	"finplay/backend/models"
+	"finplay/backend/oidc"
+	"finplay/backend/openapi"
+	"finplay/backend/payments"
+	"finplay/backend/receipts"
+	"finplay/backend/server"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
+	_ "time/tzdata" // fuso das promoções mesmo em imagens sem zoneinfo
 
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
@@ -31,6 +45,10 @@ type GroqResponse struct {
 	Choices []struct {
 		Message Message `json:"message"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
@@ -47,102 +65,242 @@ type ChatResponse struct {
 	Error    string `json:"error,omitempty"`
 }
 
-var apiKey string
+// Configuração do provedor de LLM usada pelo chat
+var llmConfig config.LLMConfig
 
 func main() {
-	// Carrega variáveis de ambiente
-	err := godotenv.Load()
+	// Carrega variáveis de ambiente do .env (opcional)
+	envErr := godotenv.Load()
+
+	// Configuração tipada: padrão, config.yaml e variáveis de ambiente
+	cfg, err := config.Load()
 	if err != nil {
-		log.Println("⚠️  Aviso: arquivo .env não encontrado")
+		slog.Error("configuração inválida", "error", err)
+		os.Exit(1)
 	}
 
-	// API Key do Groq
-	apiKey = os.Getenv("GROQ_API_KEY")
-	if apiKey == "" {
-		log.Fatal("❌ GROQ_API_KEY não configurada no arquivo .env")
+	// Logs estruturados
+	logger.Setup(cfg.Log)
+	if envErr != nil {
+		slog.Warn("arquivo .env não encontrado")
+	}
+	slog.Info("configuração carregada", "config", cfg)
+	if cfg.Auth.JWTSecret == config.DevJWTSecret {
+		slog.Warn("JWT_SECRET não definido, usando secret de desenvolvimento")
 	}
 
-	// Conectar ao PostgreSQL
-	if err := database.Connect(); err != nil {
-		log.Fatal("❌ Erro ao conectar ao PostgreSQL:", err)
+	// Injetar configuração nos pacotes
+	llmConfig = cfg.LLM
+	middleware.Configure(cfg.Auth, cfg.Server)
+	handlers.Configure(cfg.Auth)
+	models.ConfigurePasswordPolicy(cfg.Password)
+	mailer.Configure(cfg.App)
+	payments.Configure(cfg.Payments)
+	models.ConfigurePromotions(cfg.Promotions)
+	models.ConfigureLoyalty(cfg.Loyalty)
+	models.ConfigureTaxes(cfg.Taxes)
+	models.ConfigureDelivery(cfg.Delivery)
+	storeLocation, _ := time.LoadLocation(cfg.Promotions.Timezone) // validado em config
+	receipts.Configure(cfg.Receipts, storeLocation)
+	if cfg.IsProduction() && cfg.Payments.Gateway == "fake" {
+		slog.Warn("gateway de pagamento falso em produção: pagamentos são apenas simulados")
 	}
-	defer database.Close()
 
-	log.Printf("✅ API Key carregada (primeiros 10 chars): %s...\n", apiKey[:min(10, len(apiKey))])
+	// Conectar ao PostgreSQL
+	if err := database.Connect(cfg.Database); err != nil {
+		slog.Error("erro ao conectar ao PostgreSQL", "error", err)
+		os.Exit(1)
+	}
+	metrics.RegisterDBStats(database.DB)
+	middleware.ConfigureIdempotency(cfg.Idempotency, models.NewIdempotencyStore(database.DB))
+	middleware.ConfigureAccounts(models.NewAccountStore(database.DB))
+	registerHealthChecks()
+
+	// Provedores de login social (OIDC)
+	oidc.LoadProviders(cfg.OIDC.Providers)
+
+	// Políticas de rate limit por rota
+	authLimit := middleware.PerMinute("auth", 10)         // login e verificação de códigos
+	registerLimit := middleware.PerHour("register", 5)    // criação de contas por IP
+	chatLimit := middleware.PerMinute("chat", 10)         // cada mensagem custa uma chamada ao Groq
+	sensitiveLimit := middleware.PerHour("sensitive", 10) // troca de email, exportação de dados
+	ordersLimit := middleware.PerMinute("orders", 30)
+	apiLimit := middleware.PerMinute("api", 300) // limite geral por IP
+
+	// Contrato da API (OpenAPI 3)
+	spec, err := openapi.Load()
+	if err != nil {
+		slog.Error("erro ao carregar especificação OpenAPI", "error", err)
+		os.Exit(1)
+	}
 
 	// Configurar rotas
 	mux := http.NewServeMux()
 
 	// Rotas públicas (sem autenticação)
-	mux.HandleFunc("/health", handleHealth)
-	mux.HandleFunc("/api/auth/register", handlers.HandleRegister)
-	mux.HandleFunc("/api/auth/login", handlers.HandleLogin)
+	mux.HandleFunc("/healthz", health.HandleLiveness)
+	mux.HandleFunc("/readyz", health.HandleReadiness)
+	mux.HandleFunc("/health", health.HandleReadiness) // compatibilidade
+	mux.HandleFunc("/metrics", metrics.Handler(cfg.Metrics.Token))
+	mux.HandleFunc("/api/openapi.json", spec.HandleJSON)
+	mux.HandleFunc("/api/docs", openapi.HandleDocs)
+	mux.HandleFunc("/api/auth/register", middleware.RateLimit(registerLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleRegister)))
+	mux.HandleFunc("/api/auth/login", middleware.RateLimit(authLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleLogin)))
+	mux.HandleFunc("/api/auth/login/2fa", middleware.RateLimit(authLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleLoginMFA)))
 	mux.HandleFunc("/api/auth/logout", handlers.HandleLogout)
-	mux.HandleFunc("/api/chat", handleChat)
+	mux.HandleFunc("/api/users/me/email/confirm", middleware.RateLimit(authLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleConfirmEmailChange)))
+	mux.HandleFunc("/api/auth/oidc/providers", handlers.HandleOIDCProviders)
+	mux.HandleFunc("/api/auth/oidc/login", handlers.HandleOIDCLogin)
+	mux.HandleFunc("/api/auth/oidc/callback", handlers.HandleOIDCCallback)
+	mux.HandleFunc("/api/webhooks/pix", handlers.HandlePixWebhook)
+	mux.HandleFunc("/api/promotions", handlers.HandleListPromotions)
+	mux.HandleFunc("/api/products", handlers.HandleListProducts)
+	mux.HandleFunc("/api/chat", middleware.RateLimit(chatLimit, middleware.MaxBodySize(middleware.ChatBodyLimit, handleChat)))
 
 	// Rotas protegidas (com autenticação)
 	mux.HandleFunc("/api/auth/me", middleware.AuthMiddleware(handlers.HandleGetMe))
-	mux.HandleFunc("/api/orders", middleware.AuthMiddleware(handlers.HandleCreateOrder))
-	mux.HandleFunc("/api/orders/complete", middleware.AuthMiddleware(handlers.HandleCompleteOrder))
+	mux.HandleFunc("/api/auth/csrf", middleware.AuthMiddleware(handlers.HandleCSRFToken))
+	mux.HandleFunc("/api/auth/password", middleware.AuthMiddleware(middleware.RateLimit(authLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleChangePassword))))
+	mux.HandleFunc("/api/auth/oidc/reauth", middleware.AuthMiddleware(handlers.HandleOIDCReauth))
+	mux.HandleFunc("/api/auth/2fa/enroll", middleware.AuthMiddleware(handlers.HandleMFAEnroll))
+	mux.HandleFunc("/api/auth/2fa/verify", middleware.AuthMiddleware(middleware.RateLimit(authLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleMFAVerify))))
+	mux.HandleFunc("/api/auth/2fa/disable", middleware.AuthMiddleware(middleware.RateLimit(authLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleMFADisable))))
+	mux.HandleFunc("/api/users/me", middleware.AuthMiddleware(middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleUserMe)))
+	mux.HandleFunc("/api/users/me/email", middleware.AuthMiddleware(middleware.RateLimit(sensitiveLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleRequestEmailChange))))
+	mux.HandleFunc("/api/users/me/deactivate", middleware.AuthMiddleware(middleware.MaxBodySize(middleware.AuthBodyLimit, handlers.HandleDeactivateAccount)))
+	mux.HandleFunc("/api/users/me/export", middleware.AuthMiddleware(middleware.RateLimit(sensitiveLimit, handlers.HandleDataExport)))
+	mux.HandleFunc("/api/users/me/export/status", middleware.AuthMiddleware(handlers.HandleDataExportStatus))
+	mux.HandleFunc("/api/users/me/export/download", middleware.AuthMiddleware(handlers.HandleDataExportDownload))
+	mux.HandleFunc("/api/orders", middleware.AuthMiddleware(middleware.RateLimit(ordersLimit, middleware.MaxBodySize(middleware.OrderBodyLimit, middleware.Idempotent(handlers.HandleCreateOrder)))))
 	mux.HandleFunc("/api/orders/history", middleware.AuthMiddleware(handlers.HandleGetOrderHistory))
-	mux.HandleFunc("/api/orders/cancel", middleware.AuthMiddleware(handlers.HandleCancelOrder))
+	mux.HandleFunc("/api/orders/cancel", middleware.AuthMiddleware(middleware.Idempotent(handlers.HandleCancelOrder)))
+	mux.HandleFunc("/api/orders/detail", middleware.AuthMiddleware(handlers.HandleGetOrder))
+	mux.HandleFunc("/api/orders/items/cancel", middleware.AuthMiddleware(middleware.RateLimit(ordersLimit, middleware.MaxBodySize(middleware.OrderBodyLimit, middleware.Idempotent(handlers.HandleCancelOrderItems)))))
+	mux.HandleFunc("/api/orders/pay", middleware.AuthMiddleware(middleware.RateLimit(ordersLimit, middleware.MaxBodySize(middleware.AuthBodyLimit, middleware.Idempotent(handlers.HandlePayOrder)))))
+	mux.HandleFunc("/api/orders/", middleware.AuthMiddleware(handlers.HandleOrderDocument)) // /api/orders/{id}/receipt
+	mux.HandleFunc("/api/orders/pix", middleware.AuthMiddleware(middleware.RateLimit(ordersLimit, middleware.Idempotent(handlers.HandleOrderPix))))
+	mux.HandleFunc("/api/loyalty", middleware.AuthMiddleware(handlers.HandleGetLoyalty))
+	mux.HandleFunc("/api/cart", middleware.AuthMiddleware(middleware.MaxBodySize(middleware.OrderBodyLimit, handlers.HandleCart)))
+	mux.HandleFunc("/api/cart/items", middleware.AuthMiddleware(middleware.MaxBodySize(middleware.OrderBodyLimit, handlers.HandleCartItems)))
+	mux.HandleFunc("/api/cart/checkout", middleware.AuthMiddleware(middleware.RateLimit(ordersLimit, middleware.Idempotent(handlers.HandleCartCheckout))))
+	mux.HandleFunc("/api/payments", middleware.AuthMiddleware(handlers.HandleListPayments))
+
+	// Rotas da equipe da loja (users.role staff ou admin)
+	mux.HandleFunc("/api/orders/complete", middleware.AuthMiddleware(middleware.RequireStaff(middleware.Idempotent(handlers.HandleCompleteOrder))))
+	mux.HandleFunc("/api/orders/ticket", middleware.AuthMiddleware(middleware.RequireStaff(handlers.HandleKitchenTicket)))
+	mux.HandleFunc("/api/payments/capture", middleware.AuthMiddleware(middleware.RequireStaff(middleware.Idempotent(handlers.HandleCapturePayment))))
+	mux.HandleFunc("/api/payments/void", middleware.AuthMiddleware(middleware.RequireStaff(middleware.Idempotent(handlers.HandleVoidPayment))))
+	mux.HandleFunc("/api/payments/refund", middleware.AuthMiddleware(middleware.RequireStaff(middleware.MaxBodySize(middleware.AuthBodyLimit, middleware.Idempotent(handlers.HandleRefundPayment)))))
+
+	// Em desenvolvimento, requisições fora do contrato são rejeitadas com 400
+	var api http.Handler = mux
+	if cfg.ValidateRequests() {
+		api = spec.Validator(mux)
+		slog.Info("validação de requisições pelo OpenAPI ativa")
+	}
 
 	// Configurar CORS
 	handler := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", middleware.RequestIDHeader, middleware.CSRFHeader, middleware.IdempotencyHeader},
+		ExposedHeaders:   []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", middleware.RequestIDHeader, middleware.IdempotentReplayedHeader},
 		AllowCredentials: true,
 		Debug:            false,
-	}).Handler(mux)
+	}).Handler(middleware.RateLimit(apiLimit, middleware.MaxBodySize(middleware.DefaultBodyLimit, api.ServeHTTP)))
+
+	// Cabeçalhos de segurança em todas as respostas (inclusive erros de CORS/rate limit)
+	handler = middleware.SecurityHeaders(middleware.HSTS(cfg.Server.HSTS, handler))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Request ID e log de acesso envolvem toda a pilha
+	handler = middleware.RequestID(middleware.AccessLog(metrics.Instrument(mux, handler)))
+
+	scheme := "http"
+	if cfg.Server.TLS.Enabled() {
+		scheme = "https"
 	}
 
-	fmt.Printf("\n🚀 Servidor rodando na porta %s\n", port)
-	fmt.Printf("📡 Health check: http://localhost:%s/health\n", port)
-	fmt.Printf("💬 Chat endpoint: http://localhost:%s/api/chat\n", port)
-	fmt.Printf("🔐 Auth endpoints: http://localhost:%s/api/auth/*\n", port)
-	fmt.Printf("🛒 Orders endpoints: http://localhost:%s/api/orders/*\n", port)
-	fmt.Printf("🤖 Usando Groq API (llama-3.1-8b-instant)\n")
-	fmt.Printf("🗄️  PostgreSQL conectado\n\n")
+	slog.Info("servidor iniciado",
+		"port", cfg.Server.Port,
+		"readyz", fmt.Sprintf("%s://localhost:%d/readyz", scheme, cfg.Server.Port),
+		"llm_model", llmConfig.Model,
+	)
 
-	log.Fatal(http.ListenAndServe(":"+port, handler))
-}
+	err = server.Run(cfg.Server, handler)
 
-func min(a, b int) int {
-	if a < b {
-		return a
+	// Pool fechado só depois de drenar requisições e tarefas em andamento
+	database.Close()
+
+	if err != nil {
+		slog.Error("erro no servidor", "error", err)
+		os.Exit(1)
 	}
-	return b
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":   "ok",
-		"database": "connected",
+// Verificações de dependências usadas pelo /readyz
+func registerHealthChecks() {
+	health.Register(health.Check{
+		Name:     "database",
+		Critical: true,
+		Run: func(ctx context.Context) error {
+			return database.DB.PingContext(ctx)
+		},
+	})
+	health.Register(health.Check{
+		Name:     "schema",
+		Critical: true,
+		Run: func(ctx context.Context) error {
+			version, err := database.CurrentSchemaVersion(ctx)
+			if err != nil {
+				return err
+			}
+			if version < database.SchemaVersion {
+				return fmt.Errorf("schema na versão %d, esperado %d", version, database.SchemaVersion)
+			}
+			return nil
+		},
+	})
+	// O chat é opcional: indisponibilidade do LLM degrada, mas não derruba o serviço
+	health.Register(health.Check{
+		Name:    "llm",
+		Timeout: 3 * time.Second,
+		Run:     checkGroqAPI,
 	})
 }
 
-func handleChat(w http.ResponseWriter, r *http.Request) {
-	log.Printf("📨 Nova requisição: %s %s\n", r.Method, r.URL.Path)
+func checkGroqAPI(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, llmConfig.BaseURL+"/models", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+llmConfig.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func handleChat(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var req ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ Erro ao decodificar: %v\n", err)
-		sendChatError(w, "Erro ao ler requisição", http.StatusBadRequest)
+	if err := handlers.DecodeJSON(r, &req); err != nil {
+		slog.WarnContext(r.Context(), "erro ao decodificar requisição do chat", "error", err.Message)
+		sendChatError(w, err.Message, err.Status)
 		return
 	}
 
-	log.Printf("💬 Mensagem: %s\n", req.Message)
+	slog.DebugContext(r.Context(), "mensagem recebida", "message", req.Message, "history_len", len(req.History))
 
 	messages := []Message{
 		{
@@ -191,24 +349,32 @@ Seja educado, objetivo e prestativo. Responda em português do Brasil.`,
 		Content: req.Message,
 	})
 
-	log.Println("🤖 Chamando Groq API...")
-
 	response, err := callGroqAPI(messages)
 	if err != nil {
-		log.Printf("❌ Erro: %v\n", err)
+		slog.ErrorContext(r.Context(), "erro ao chamar Groq API", "error", err)
 		sendChatError(w, "Erro ao processar mensagem", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("✅ Resposta recebida\n")
+	slog.DebugContext(r.Context(), "resposta do Groq recebida", "response_chars", len(response))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ChatResponse{Response: response})
 }
 
-func callGroqAPI(messages []Message) (string, error) {
+func callGroqAPI(messages []Message) (content string, err error) {
+	start := time.Now()
+	defer func() {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+			metrics.LLMErrorsTotal.Inc("groq", llmConfig.Model)
+		}
+		metrics.LLMRequestDuration.Observe(time.Since(start).Seconds(), "groq", llmConfig.Model, outcome)
+	}()
+
 	reqBody := GroqRequest{
-		Model:    "llama-3.1-8b-instant",
+		Model:    llmConfig.Model,
 		Messages: messages,
 	}
 
@@ -217,13 +383,13 @@ func callGroqAPI(messages []Message) (string, error) {
 		return "", err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.groq.com/openai/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", llmConfig.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Authorization", "Bearer "+llmConfig.APIKey)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -238,7 +404,6 @@ func callGroqAPI(messages []Message) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("❌ Erro API: %s\n", string(body))
 		return "", fmt.Errorf("API erro %d: %s", resp.StatusCode, string(body))
 	}
 
@@ -251,6 +416,9 @@ func callGroqAPI(messages []Message) (string, error) {
 		return "", fmt.Errorf("erro: %s", apiResp.Error.Message)
 	}
 
+	metrics.LLMTokensTotal.Add(float64(apiResp.Usage.PromptTokens), "groq", llmConfig.Model, "prompt")
+	metrics.LLMTokensTotal.Add(float64(apiResp.Usage.CompletionTokens), "groq", llmConfig.Model, "completion")
+
 	if len(apiResp.Choices) == 0 {
 		return "", fmt.Errorf("nenhuma resposta")
 	}
@@ -0,0 +1,134 @@
+// Arquivo: backend/metrics/app.go
+package metrics
+
+import (
+	"database/sql"
+	"finplay/backend/middleware"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Métricas HTTP
+var (
+	HTTPRequestDuration = NewHistogram(
+		"finplay_http_request_duration_seconds",
+		"Duração das requisições HTTP por rota e status.",
+		DefBuckets, "method", "route", "status",
+	)
+	HTTPRequestsTotal = NewCounter(
+		"finplay_http_requests_total",
+		"Total de requisições HTTP por rota e status.",
+		"method", "route", "status",
+	)
+)
+
+// Métricas de pedidos
+var (
+	OrdersCreated = NewCounter(
+		"finplay_orders_created_total",
+		"Pedidos criados.",
+	)
+	OrdersCompleted = NewCounter(
+		"finplay_orders_completed_total",
+		"Pedidos finalizados.",
+	)
+	OrdersCancelled = NewCounter(
+		"finplay_orders_cancelled_total",
+		"Pedidos cancelados.",
+	)
+)
+
+// Métricas de pagamentos (operation: authorize, capture, void, refund;
+// outcome: approved, declined, error)
+var PaymentsTotal = NewCounter(
+	"finplay_payments_total",
+	"Operações no gateway de pagamento por resultado.",
+	"gateway", "operation", "outcome",
+)
+
+// Métricas do provedor de LLM (chat)
+var (
+	LLMRequestDuration = NewHistogram(
+		"finplay_llm_request_duration_seconds",
+		"Latência das chamadas ao provedor de LLM.",
+		[]float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 16, 30}, "provider", "model", "outcome",
+	)
+	LLMTokensTotal = NewCounter(
+		"finplay_llm_tokens_total",
+		"Tokens consumidos no provedor de LLM por tipo (prompt, completion).",
+		"provider", "model", "type",
+	)
+	LLMErrorsTotal = NewCounter(
+		"finplay_llm_errors_total",
+		"Erros nas chamadas ao provedor de LLM.",
+		"provider", "model",
+	)
+)
+
+// Expor estatísticas do pool de conexões (database/sql) no momento da coleta
+func RegisterDBStats(db *sql.DB) {
+	stat := func(f func(sql.DBStats) float64) func() float64 {
+		return func() float64 { return f(db.Stats()) }
+	}
+
+	NewGaugeFunc("finplay_db_max_open_connections", "Limite de conexões abertas do pool.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	NewGaugeFunc("finplay_db_open_connections", "Conexões abertas (em uso + ociosas).",
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	NewGaugeFunc("finplay_db_in_use_connections", "Conexões em uso.",
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	NewGaugeFunc("finplay_db_idle_connections", "Conexões ociosas.",
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	NewCounterFunc("finplay_db_wait_count_total", "Total de esperas por uma conexão livre.",
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	NewCounterFunc("finplay_db_wait_duration_seconds_total", "Tempo total esperando por conexões.",
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	NewCounterFunc("finplay_db_max_idle_closed_total", "Conexões fechadas por excesso de ociosas.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	NewCounterFunc("finplay_db_max_lifetime_closed_total", "Conexões fechadas por tempo de vida.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
+
+// Medir requisições HTTP. A rota é o padrão registrado no mux (não o path
+// bruto) e o método é normalizado, evitando explosão de cardinalidade com
+// paths e métodos desconhecidos.
+func Instrument(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := middleware.RecordStatus(w)
+
+		next.ServeHTTP(rec, r)
+
+		route := "other"
+		if _, pattern := mux.Handler(r); pattern != "" {
+			route = pattern
+		}
+		method := methodLabel(r.Method)
+		status := strconv.Itoa(rec.Status())
+
+		HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route, status)
+		HTTPRequestsTotal.Inc(method, route, status)
+	})
+}
+
+// Métodos HTTP conhecidos; os demais viram "other" para limitar a
+// cardinalidade do label
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodConnect: true,
+	http.MethodTrace:   true,
+}
+
+func methodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "other"
+}
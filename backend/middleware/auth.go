@@ -4,8 +4,10 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"finplay/backend/config"
+	"finplay/backend/store"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -13,44 +15,100 @@ import (
 )
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID       string `json:"user_id"`
+	Email        string `json:"email"`
+	Purpose      string `json:"purpose,omitempty"` // vazio = sessão completa
+	TokenVersion int    `json:"ver"`               // users.token_version na emissão
+	Role         string `json:"-"`                 // papel atual, lido da conta a cada requisição
 	jwt.RegisteredClaims
 }
 
+// Papéis de usuário (users.role)
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff" // equipe da loja: captura, cancelamento e estorno de pagamentos
+	RoleAdmin    = "admin"
+)
+
+// Equipe da loja (staff ou admin)
+func (c *Claims) IsStaff() bool {
+	return c.Role == RoleStaff || c.Role == RoleAdmin
+}
+
+// Consulta da conta para revogar sessões: tokens de contas desativadas ou
+// excluídas, ou emitidos antes de uma troca de senha, deixam de valer
+type AccountStore interface {
+	Account(userID string) (*store.Account, error)
+}
+
 type contextKey string
 
 const UserContextKey contextKey = "user"
 
-// Gerar JWT token
-func GenerateToken(userID, email string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "seu-secret-super-secreto-aqui" // MUDAR EM PRODUÇÃO
-	}
+// Token intermediário emitido após a senha quando o usuário tem 2FA ativo
+const PurposeMFAPending = "mfa_pending"
+
+const MFAPendingTTL = 5 * time.Minute
+
+// Token emitido após um login social recente; substitui a senha nas operações
+// sensíveis (contas criadas via login social não têm senha)
+const PurposeReauth = "reauth"
+
+const ReauthTTL = 5 * time.Minute
+
+// Configuração de autenticação, definida na inicialização por Configure
+var (
+	authConfig        = config.Default().Auth
+	trustProxyHeaders bool
+	accountStore      AccountStore // nil = só valida o JWT
+)
+
+// Aplicar a configuração carregada (secret/validade do JWT e proxy confiável)
+func Configure(auth config.AuthConfig, server config.ServerConfig) {
+	authConfig = auth
+	trustProxyHeaders = server.TrustProxyHeaders
+}
+
+// Definir a consulta de contas usada pelo AuthMiddleware
+func ConfigureAccounts(store AccountStore) {
+	accountStore = store
+}
+
+// Gerar JWT token com a versão atual de token do usuário
+func GenerateToken(userID, email string, tokenVersion int) (string, error) {
+	return generateToken(userID, email, "", tokenVersion, authConfig.SessionTTL)
+}
+
+// Gerar token "mfa pending" de curta duração (não dá acesso às rotas protegidas)
+func GenerateMFAPendingToken(userID, email string) (string, error) {
+	return generateToken(userID, email, PurposeMFAPending, 0, MFAPendingTTL)
+}
 
+// Gerar token de reautenticação, amarrado à versão de token do usuário
+func GenerateReauthToken(userID, email string, tokenVersion int) (string, error) {
+	return generateToken(userID, email, PurposeReauth, tokenVersion, ReauthTTL)
+}
+
+func generateToken(userID, email, purpose string, tokenVersion int, ttl time.Duration) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:       userID,
+		Email:        email,
+		Purpose:      purpose,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token.SignedString([]byte(authConfig.JWTSecret))
 }
 
 // Verificar JWT token
 func VerifyToken(tokenString string) (*Claims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "seu-secret-super-secreto-aqui"
-	}
-
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
+		return []byte(authConfig.JWTSecret), nil
 	})
 
 	if err != nil {
@@ -64,6 +122,30 @@ func VerifyToken(tokenString string) (*Claims, error) {
 	return nil, jwt.ErrSignatureInvalid
 }
 
+// Verificar token "mfa pending"
+func VerifyMFAPendingToken(tokenString string) (*Claims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeMFAPending {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// Verificar token de reautenticação
+func VerifyReauthToken(tokenString string) (*Claims, error) {
+	claims, err := VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeReauth {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
 // Middleware de autenticação
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -80,10 +162,12 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Se não encontrou no header, tentar cookie
+		fromCookie := false
 		if tokenString == "" {
-			cookie, err := r.Cookie("auth_token")
+			cookie, err := r.Cookie(AuthCookieName())
 			if err == nil {
 				tokenString = cookie.Value
+				fromCookie = true
 			}
 		}
 
@@ -94,17 +178,55 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		// Verificar token
 		claims, err := VerifyToken(tokenString)
-		if err != nil {
+		if err != nil || claims.Purpose != "" {
 			sendError(w, "Token inválido ou expirado", http.StatusUnauthorized)
 			return
 		}
 
+		// Conta desativada/excluída ou token anterior à última troca de senha
+		if accountStore != nil {
+			account, err := accountStore.Account(claims.UserID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "erro ao consultar conta", "user_id", claims.UserID, "error", err)
+				sendError(w, "Erro interno do servidor", http.StatusInternalServerError)
+				return
+			}
+			if account == nil || !account.Active || account.TokenVersion != claims.TokenVersion {
+				sendError(w, "Token inválido ou expirado", http.StatusUnauthorized)
+				return
+			}
+			claims.Role = account.Role
+		}
+
+		// Sessão via cookie é enviada automaticamente pelo navegador: exigir CSRF
+		if fromCookie && !isSafeMethod(r.Method) && !validCSRFRequest(r, tokenString) {
+			sendError(w, "Token CSRF inválido ou ausente", http.StatusForbidden)
+			return
+		}
+
 		// Adicionar claims ao contexto
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// Restringir a rota à equipe da loja (usar dentro de AuthMiddleware). Sem
+// consulta de contas configurada o papel é desconhecido e o acesso é negado.
+func RequireStaff(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetUserFromContext(r)
+		if !ok {
+			sendError(w, "Não autenticado", http.StatusUnauthorized)
+			return
+		}
+		if !claims.IsStaff() {
+			sendError(w, "Acesso restrito à equipe da loja", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
 // Extrair usuário do contexto
 func GetUserFromContext(r *http.Request) (*Claims, bool) {
 	claims, ok := r.Context().Value(UserContextKey).(*Claims)
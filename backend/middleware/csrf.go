@@ -0,0 +1,70 @@
+// Arquivo: backend/middleware/csrf.go
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Proteção CSRF por "double submit" assinado: o token vai num cookie legível
+// e deve ser reenviado no header X-CSRF-Token. O token é um nonce com HMAC
+// amarrado ao JWT da sessão, então um cookie plantado por outro subdomínio
+// ou de outra sessão não é aceito. Só se aplica quando a autenticação vem
+// do cookie; requisições com "Authorization: Bearer" não são forjáveis.
+const CSRFHeader = "X-CSRF-Token"
+
+// Nome do cookie CSRF (prefixo __Host- com cookies seguros)
+func CSRFCookieName() string {
+	if authConfig.CookieSecure {
+		return "__Host-csrf_token"
+	}
+	return "csrf_token"
+}
+
+// Gerar token CSRF para a sessão identificada pelo JWT
+func GenerateCSRFToken(sessionToken string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	n := hex.EncodeToString(nonce)
+	return n + "." + csrfSignature(n, sessionToken), nil
+}
+
+func csrfSignature(nonce, sessionToken string) string {
+	mac := hmac.New(sha256.New, []byte(authConfig.JWTSecret))
+	mac.Write([]byte("csrf|" + nonce + "|" + sessionToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Validar o header contra o cookie e a assinatura contra a sessão atual
+func validCSRFRequest(r *http.Request, sessionToken string) bool {
+	header := r.Header.Get(CSRFHeader)
+	cookie, err := r.Cookie(CSRFCookieName())
+	if header == "" || err != nil {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+		return false
+	}
+
+	nonce, signature, ok := strings.Cut(header, ".")
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(csrfSignature(nonce, sessionToken)))
+}
+
+// Métodos sem efeito colateral não precisam de token
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+// Arquivo: backend/middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"finplay/backend/config"
+	"finplay/backend/store"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Header enviado pelo cliente para deduplicar POSTs (duplo clique, retry de rede)
+const IdempotencyHeader = "Idempotency-Key"
+
+// Header presente nas respostas repetidas a partir do armazenamento
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const (
+	maxIdempotencyKeyLength = 255
+	// Respostas maiores não são guardadas (a repetição executa de novo)
+	maxIdempotentBody = 1 << 20
+)
+
+// Armazenamento das chaves por usuário. Reserve grava a chave como em
+// andamento se ela não existir (ou tiver expirado/sido abandonada após
+// store.IdempotencyLockTimeout) e retorna true; caso contrário retorna o
+// registro existente. A implementação em
+// memória serve para uma instância; em produção o store é o PostgreSQL.
+type IdempotencyStore interface {
+	Reserve(userID, key, fingerprint string, now time.Time, ttl time.Duration) (*store.IdempotencyRecord, bool, error)
+	Complete(userID, key string, resp store.IdempotentResponse) error
+	Release(userID, key string) error
+}
+
+var (
+	idempotencyTTL                    = config.Default().Idempotency.TTL
+	idempotencyStore IdempotencyStore = NewMemoryIdempotencyStore()
+)
+
+// Aplicar a janela configurada e o store compartilhado
+func ConfigureIdempotency(cfg config.IdempotencyConfig, store IdempotencyStore) {
+	idempotencyTTL = cfg.TTL
+	if store != nil {
+		idempotencyStore = store
+	}
+}
+
+// Middleware de idempotência para POSTs autenticados (dentro de
+// AuthMiddleware e MaxBodySize). Com Idempotency-Key, a primeira resposta por
+// chave e usuário é guardada e repetida nas tentativas seguintes; a mesma chave
+// com outra requisição é rejeitada. Respostas 5xx não são guardadas, para que
+// a repetição tente de novo.
+func Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		claims, ok := GetUserFromContext(r)
+		if r.Method != http.MethodPost || key == "" || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			sendError(w, "Idempotency-Key inválida", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				sendError(w, "Corpo da requisição excede o limite", http.StatusRequestEntityTooLarge)
+				return
+			}
+			sendError(w, "Erro ao ler corpo da requisição", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(r, body)
+		record, reserved, err := idempotencyStore.Reserve(claims.UserID, key, fingerprint, time.Now(), idempotencyTTL)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro no armazenamento de idempotência", "error", err)
+			sendError(w, "Serviço temporariamente indisponível", http.StatusServiceUnavailable)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.Fingerprint != fingerprint:
+				sendError(w, "Idempotency-Key já usada em outra requisição", http.StatusUnprocessableEntity)
+			case record.Response == nil:
+				w.Header().Set("Retry-After", "1")
+				sendError(w, "Requisição com esta Idempotency-Key ainda em processamento", http.StatusConflict)
+			default:
+				slog.InfoContext(r.Context(), "resposta idempotente repetida", "path", r.URL.Path, "status", record.Response.Status)
+				if record.Response.ContentType != "" {
+					w.Header().Set("Content-Type", record.Response.ContentType)
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(record.Response.Status)
+				w.Write(record.Response.Body)
+			}
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status >= 500 || rec.overflow {
+			err = idempotencyStore.Release(claims.UserID, key)
+		} else {
+			err = idempotencyStore.Complete(claims.UserID, key, store.IdempotentResponse{
+				Status:      rec.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "erro ao gravar resposta idempotente", "path", r.URL.Path, "error", err)
+		}
+	}
+}
+
+// Mesma chave só vale para a mesma rota, parâmetros e corpo
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ASCII imprimível, até 255 caracteres (ex.: UUID gerado pelo cliente)
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// ResponseWriter que copia status e corpo para guardar a resposta
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *idempotencyRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxIdempotentBody {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+type memoryIdempotencyEntry struct {
+	store.IdempotencyRecord
+	expiresAt time.Time
+}
+
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*memoryIdempotencyEntry), lastSweep: time.Now()}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(userID, key, fingerprint string, now time.Time, ttl time.Duration) (*store.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	id := userID + ":" + key
+	if e, ok := s.entries[id]; ok && now.Before(e.expiresAt) &&
+		(e.Response != nil || now.Sub(e.CreatedAt) < store.IdempotencyLockTimeout) {
+		record := e.IdempotencyRecord
+		return &record, false, nil
+	}
+
+	s.entries[id] = &memoryIdempotencyEntry{
+		IdempotencyRecord: store.IdempotencyRecord{Fingerprint: fingerprint, CreatedAt: now},
+		expiresAt:         now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(userID, key string, resp store.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[userID+":"+key]; ok {
+		e.Response = &resp
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, userID+":"+key)
+	return nil
+}
+
+// Remover chaves expiradas periodicamente
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for id, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, id)
+		}
+	}
+}
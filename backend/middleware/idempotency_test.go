@@ -0,0 +1,142 @@
+// Arquivo: backend/middleware/idempotency_test.go
+package middleware
+
+import (
+	"context"
+	"finplay/backend/store"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStoreReserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ttl := time.Hour
+	done := store.IdempotentResponse{Status: http.StatusCreated, Body: []byte("ok")}
+
+	steps := []struct {
+		name         string
+		user, key    string
+		after        time.Duration // desde start
+		complete     bool          // gravar a resposta depois do passo
+		release      bool          // liberar a chave depois do passo
+		wantReserved bool
+		wantResponse bool // registro existente já tem resposta
+	}{
+		{"primeira vez", "u1", "k1", 0, false, false, true, false},
+		{"em andamento", "u1", "k1", time.Second, true, false, false, false},
+		{"resposta guardada", "u1", "k1", 2 * time.Second, false, false, false, true},
+		{"outro usuário com a mesma chave", "u2", "k1", 2 * time.Second, false, false, true, false},
+		{"guardada continua após o tempo de bloqueio", "u1", "k1", 2 * store.IdempotencyLockTimeout, false, false, false, true},
+		{"reutilizada depois do TTL", "u1", "k1", ttl, false, false, true, false},
+		{"abandonada", "u1", "k2", 0, false, false, true, false},
+		{"ainda em andamento", "u1", "k2", store.IdempotencyLockTimeout - time.Second, false, false, false, false},
+		{"retomada após o tempo de bloqueio", "u1", "k2", store.IdempotencyLockTimeout, false, true, true, false},
+		{"liberada", "u1", "k2", store.IdempotencyLockTimeout + time.Second, false, false, true, false},
+	}
+
+	memory := NewMemoryIdempotencyStore()
+	for _, s := range steps {
+		record, reserved, err := memory.Reserve(s.user, s.key, "fp", start.Add(s.after), ttl)
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if reserved != s.wantReserved {
+			t.Errorf("%s: reservada %v, esperado %v", s.name, reserved, s.wantReserved)
+		}
+		if !reserved && (record == nil || (record.Response != nil) != s.wantResponse) {
+			t.Errorf("%s: registro %+v, esperado resposta guardada %v", s.name, record, s.wantResponse)
+		}
+		if s.complete {
+			memory.Complete(s.user, s.key, done)
+		}
+		if s.release {
+			memory.Release(s.user, s.key)
+		}
+	}
+}
+
+func TestIdempotentMiddleware(t *testing.T) {
+	prevStore, prevTTL := idempotencyStore, idempotencyTTL
+	memory := NewMemoryIdempotencyStore()
+	idempotencyStore, idempotencyTTL = memory, time.Hour
+	defer func() { idempotencyStore, idempotencyTTL = prevStore, prevTTL }()
+
+	calls := 0
+	handler := Idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/falha":
+			sendError(w, "erro", http.StatusBadGateway)
+		case "/grande":
+			w.Write(make([]byte, maxIdempotentBody+1))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write(append([]byte(`{"pedido":`), append(body, '}')...))
+		}
+	})
+
+	request := func(method, path, key, body string) *http.Request {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		return req.WithContext(context.WithValue(req.Context(), UserContextKey, &Claims{UserID: "u1"}))
+	}
+
+	// Chave reservada por uma requisição ainda em andamento
+	inFlight := request(http.MethodPost, "/pedidos", "k-andamento", `{"a":1}`)
+	memory.Reserve("u1", "k-andamento", requestFingerprint(inFlight, []byte(`{"a":1}`)), time.Now(), time.Hour)
+
+	tests := []struct {
+		name         string
+		req          *http.Request
+		wantStatus   int
+		wantCalled   bool
+		wantReplayed bool
+		wantBody     string
+	}{
+		{"primeira requisição", request(http.MethodPost, "/pedidos", "k1", `{"a":1}`), http.StatusCreated, true, false, `{"pedido":{"a":1}}`},
+		{"repetição", request(http.MethodPost, "/pedidos", "k1", `{"a":1}`), http.StatusCreated, false, true, `{"pedido":{"a":1}}`},
+		{"mesma chave com outro corpo", request(http.MethodPost, "/pedidos", "k1", `{"a":2}`), http.StatusUnprocessableEntity, false, false, ""},
+		{"mesma chave em outra rota", request(http.MethodPost, "/pedidos?id=1", "k1", `{"a":1}`), http.StatusUnprocessableEntity, false, false, ""},
+		{"em andamento", inFlight, http.StatusConflict, false, false, ""},
+		{"sem chave", request(http.MethodPost, "/pedidos", "", `{"a":1}`), http.StatusCreated, true, false, ""},
+		{"chave inválida", request(http.MethodPost, "/pedidos", "com espaço", `{"a":1}`), http.StatusBadRequest, false, false, ""},
+		{"GET não passa pelo armazenamento", request(http.MethodGet, "/pedidos", "k1", ""), http.StatusCreated, true, false, ""},
+		{"erro 5xx", request(http.MethodPost, "/falha", "k2", ""), http.StatusBadGateway, true, false, ""},
+		{"5xx libera a chave", request(http.MethodPost, "/falha", "k2", ""), http.StatusBadGateway, true, false, ""},
+		{"corpo acima do limite", request(http.MethodPost, "/grande", "k3", ""), http.StatusOK, true, false, ""},
+		{"corpo grande não é guardado", request(http.MethodPost, "/grande", "k3", ""), http.StatusOK, true, false, ""},
+	}
+
+	for _, tt := range tests {
+		before := calls
+		rec := httptest.NewRecorder()
+		handler(rec, tt.req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, esperado %d", tt.name, rec.Code, tt.wantStatus)
+		}
+		if called := calls > before; called != tt.wantCalled {
+			t.Errorf("%s: handler chamado %v, esperado %v", tt.name, called, tt.wantCalled)
+		}
+		if replayed := rec.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.wantReplayed {
+			t.Errorf("%s: %s %v, esperado %v", tt.name, IdempotentReplayedHeader, replayed, tt.wantReplayed)
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: corpo %q, esperado %q", tt.name, rec.Body.String(), tt.wantBody)
+		}
+		if tt.wantReplayed && rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: Content-Type %q na repetição", tt.name, rec.Header().Get("Content-Type"))
+		}
+		if tt.wantStatus == http.StatusConflict && rec.Header().Get("Retry-After") != "1" {
+			t.Errorf("%s: Retry-After %q, esperado 1", tt.name, rec.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Arquivo: backend/middleware/ratelimit.go
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Política de limite por rota (token bucket): Burst requisições imediatas,
+// reabastecidas à taxa de Rate tokens por segundo
+type RateLimitPolicy struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+// Política de n requisições por minuto
+func PerMinute(name string, n int) RateLimitPolicy {
+	return RateLimitPolicy{Name: name, Rate: float64(n) / 60, Burst: n}
+}
+
+// Política de n requisições por hora
+func PerHour(name string, n int) RateLimitPolicy {
+	return RateLimitPolicy{Name: name, Rate: float64(n) / 3600, Burst: n}
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	ResetAfter time.Duration // até o bucket encher novamente
+	RetryAfter time.Duration // até a próxima requisição ser permitida
+}
+
+// Armazenamento dos buckets. A implementação em memória serve para uma
+// instância; várias instâncias devem compartilhar um store (ex.: Redis).
+type RateLimitStore interface {
+	Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+const rateLimitSweepInterval = 5 * time.Minute
+
+func (s *MemoryRateLimitStore) Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	burst := float64(policy.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+
+	// Reabastecer proporcionalmente ao tempo decorrido
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*policy.Rate)
+		b.last = now
+	}
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / policy.Rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = secondsToDuration((burst - b.tokens) / policy.Rate)
+	return result, nil
+}
+
+// Remover buckets cheios (equivalentes a um bucket novo) periodicamente
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > time.Hour {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
+
+// Store usado pelo middleware
+var RateLimiter RateLimitStore = NewMemoryRateLimitStore()
+
+// Middleware de rate limit. Em rotas autenticadas (dentro de AuthMiddleware)
+// o limite é por usuário; nas demais, por IP do cliente.
+func RateLimit(policy RateLimitPolicy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := policy.Name + ":ip:" + ClientIP(r)
+		if claims, ok := GetUserFromContext(r); ok {
+			key = policy.Name + ":user:" + claims.UserID
+		}
+
+		result, err := RateLimiter.Take(key, policy, time.Now())
+		if err != nil {
+			// Falha no store não deve derrubar a API
+			slog.ErrorContext(r.Context(), "erro no rate limiter", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(policy.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(int(result.ResetAfter.Seconds())))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+			sendError(w, "Muitas requisições. Tente novamente em instantes.", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// IP do cliente. X-Forwarded-For só é considerado com TRUST_PROXY_HEADERS=true
+// (quando o backend roda atrás de um proxy reverso confiável).
+func ClientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
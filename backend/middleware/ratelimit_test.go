@@ -0,0 +1,118 @@
+// Arquivo: backend/middleware/ratelimit_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	policy := RateLimitPolicy{Name: "teste", Rate: 1, Burst: 3}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		name          string
+		key           string
+		after         time.Duration // desde start
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+		wantRetry     time.Duration
+	}{
+		{"primeira", "a", 0, true, 2, 1 * time.Second, 0},
+		{"segunda", "a", 0, true, 1, 2 * time.Second, 0},
+		{"esgota o burst", "a", 0, true, 0, 3 * time.Second, 0},
+		{"bucket vazio", "a", 0, false, 0, 3 * time.Second, 1 * time.Second},
+		{"meio token", "a", 500 * time.Millisecond, false, 0, 3 * time.Second, 1 * time.Second},
+		{"outra chave não é afetada", "b", 500 * time.Millisecond, true, 2, 1 * time.Second, 0},
+		{"reabastece um token", "a", 1 * time.Second, true, 0, 3 * time.Second, 0},
+		{"reabastece até o burst", "a", time.Minute, true, 2, 1 * time.Second, 0},
+	}
+
+	store := NewMemoryRateLimitStore()
+	for _, s := range steps {
+		got, err := store.Take(s.key, policy, start.Add(s.after))
+		if err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		want := RateLimitResult{Allowed: s.wantAllowed, Remaining: s.wantRemaining, ResetAfter: s.wantReset, RetryAfter: s.wantRetry}
+		if got != want {
+			t.Errorf("%s: Take = %+v, esperado %+v", s.name, got, want)
+		}
+	}
+}
+
+func TestRateLimitPolicies(t *testing.T) {
+	tests := []struct {
+		policy    RateLimitPolicy
+		wantRate  float64
+		wantBurst int
+	}{
+		{PerMinute("login", 6), 0.1, 6},
+		{PerHour("export", 36), 0.01, 36},
+	}
+	for _, tt := range tests {
+		if tt.policy.Rate != tt.wantRate || tt.policy.Burst != tt.wantBurst {
+			t.Errorf("%s: Rate=%v Burst=%d, esperado Rate=%v Burst=%d",
+				tt.policy.Name, tt.policy.Rate, tt.policy.Burst, tt.wantRate, tt.wantBurst)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	policy := PerMinute("teste", 1)
+	store := NewMemoryRateLimitStore()
+	now := store.lastSweep
+
+	store.Take("antiga", policy, now)
+	store.Take("recente", policy, now.Add(2*time.Hour))
+	store.Take("recente", policy, now.Add(2*time.Hour+rateLimitSweepInterval))
+
+	if _, ok := store.buckets["antiga"]; ok {
+		t.Error("bucket sem uso há mais de uma hora não foi removido")
+	}
+	if _, ok := store.buckets["recente"]; !ok {
+		t.Error("bucket em uso foi removido")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	prev := RateLimiter
+	RateLimiter = NewMemoryRateLimitStore()
+	defer func() { RateLimiter = prev }()
+
+	handler := RateLimit(PerMinute("teste", 2), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		method        string
+		remoteAddr    string
+		wantStatus    int
+		wantRemaining string
+	}{
+		{http.MethodPost, "10.0.0.1:1234", http.StatusNoContent, "1"},
+		{http.MethodPost, "10.0.0.1:5678", http.StatusNoContent, "0"},
+		{http.MethodPost, "10.0.0.1:1234", http.StatusTooManyRequests, "0"},
+		{http.MethodOptions, "10.0.0.1:1234", http.StatusNoContent, ""}, // preflight não consome
+		{http.MethodPost, "10.0.0.2:1234", http.StatusNoContent, "1"},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/api/teste", nil)
+		req.RemoteAddr = tt.remoteAddr
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("#%d: status %d, esperado %d", i, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != tt.wantRemaining {
+			t.Errorf("#%d: RateLimit-Remaining %q, esperado %q", i, got, tt.wantRemaining)
+		}
+		if tt.wantStatus == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "30" {
+			t.Errorf("#%d: Retry-After %q, esperado 30", i, rec.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Arquivo: backend/middleware/recorder.go
+package middleware
+
+import "net/http"
+
+// Guarda o status e o tamanho da resposta (log de acesso e métricas)
+type StatusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// Envolver w para registrar o status. Se w já é um StatusRecorder (outro
+// middleware da cadeia o criou), ele é reaproveitado.
+func RecordStatus(w http.ResponseWriter) *StatusRecorder {
+	if rec, ok := w.(*StatusRecorder); ok {
+		return rec
+	}
+	return &StatusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status enviado (200 se o handler não chamou WriteHeader)
+func (s *StatusRecorder) Status() int {
+	return s.status
+}
+
+// Bytes escritos no corpo
+func (s *StatusRecorder) Bytes() int {
+	return s.bytes
+}
+
+func (s *StatusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *StatusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// Permite streaming (SSE) através do recorder
+func (s *StatusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
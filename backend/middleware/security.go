@@ -0,0 +1,80 @@
+// Arquivo: backend/middleware/security.go
+package middleware
+
+import (
+	"finplay/backend/config"
+	"net/http"
+	"strconv"
+)
+
+// Nome do cookie de sessão. Com cookies seguros usa o prefixo __Host-, que
+// obriga o navegador a aceitá-lo só via HTTPS, com Path=/ e sem Domain.
+func AuthCookieName() string {
+	if authConfig.CookieSecure {
+		return "__Host-auth_token"
+	}
+	return "auth_token"
+}
+
+// Requisição chegou via HTTPS? Atrás de proxy confiável usa X-Forwarded-Proto.
+func IsHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return trustProxyHeaders && r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// Enviar Strict-Transport-Security nas respostas HTTPS (ignorado pelos
+// navegadores em HTTP, então não é enviado nesse caso)
+func HSTS(cfg config.HSTSConfig, next http.Handler) http.Handler {
+	if cfg.MaxAge <= 0 {
+		return next
+	}
+
+	value := "max-age=" + strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	if cfg.IncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsHTTPS(r) {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Cabeçalhos de segurança para todas as respostas. A API só devolve JSON e
+// redirecionamentos, então a CSP pode bloquear tudo.
+func SecurityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Cross-Origin-Resource-Policy", "same-site")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Limites de corpo por tipo de rota
+const (
+	DefaultBodyLimit int64 = 1 << 20   // teto geral (1 MiB)
+	AuthBodyLimit    int64 = 16 << 10  // login, cadastro, 2FA, perfil
+	OrderBodyLimit   int64 = 64 << 10  // itens do pedido
+	ChatBodyLimit    int64 = 256 << 10 // mensagem + histórico da conversa
+)
+
+// Limitar o tamanho do corpo da requisição. Ao exceder, a leitura falha com
+// *http.MaxBytesError (respondido como 413 por handlers.DecodeJSON).
+func MaxBodySize(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			sendError(w, "Corpo da requisição muito grande", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,457 @@
+// Arquivo: backend/models/cart.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Máximo de linhas no carrinho
+const MaxCartItems = 50
+
+var (
+	ErrCartEmpty        = errors.New("carrinho vazio")
+	ErrCartFull         = errors.New("carrinho atingiu o limite de itens")
+	ErrCartItemNotFound = errors.New("item não encontrado no carrinho")
+)
+
+// Item do carrinho. Os valores são do cardápio e das regras vigentes na
+// leitura; Available = false quando o produto ou uma das opções saiu do
+// cardápio (o item fica fora do total e impede o checkout).
+type CartItem struct {
+	ID              string              `json:"id"`
+	ProductName     string              `json:"product_name"`
+	ProductCategory string              `json:"product_category"`
+	Quantity        int                 `json:"quantity"`
+	Modifiers       []OrderItemModifier `json:"modifiers"`
+	Ingredients     string              `json:"ingredients,omitempty"`
+	Available       bool                `json:"available"`
+	// Preço unitário com os acréscimos das opções
+	UnitCents        int64 `json:"unit_cents"`
+	DiscountCents    int64 `json:"discount_cents"`
+	TaxCents         int64 `json:"tax_cents"`
+	IncludedTaxCents int64 `json:"included_tax_cents"`
+}
+
+// Carrinho do usuário com o valor recalculado (mesmas contas do pedido)
+type Cart struct {
+	Items                []CartItem      `json:"items"`
+	CouponCode           string          `json:"coupon_code,omitempty"`
+	RedeemPoints         int             `json:"redeem_points"`
+	Delivery             bool            `json:"delivery"`
+	Notes                string          `json:"notes,omitempty"`
+	SubtotalCents        int64           `json:"subtotal_cents"`
+	DiscountCents        int64           `json:"discount_cents"`
+	TaxCents             int64           `json:"tax_cents"`
+	DeliveryFeeCents     int64           `json:"delivery_fee_cents"`
+	TotalCents           int64           `json:"total_cents"`
+	IncludedTaxCents     int64           `json:"included_tax_cents"`
+	Taxes                []OrderTax      `json:"taxes"`
+	Discounts            []OrderDiscount `json:"discounts"`
+	LoyaltyPoints        int             `json:"loyalty_points"`
+	LoyaltyDiscountCents int64           `json:"loyalty_discount_cents"`
+	// Por que o cupom ou os pontos pedidos ficaram de fora do valor
+	Warnings  []string   `json:"warnings"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Item a adicionar ou gravar no carrinho
+type CartItemInput struct {
+	ProductName     string `json:"product_name"`
+	ProductCategory string `json:"product_category"`
+	Quantity        int    `json:"quantity"`
+	// Opções escolhidas; gravadas na forma do cardápio, com as opções padrão
+	Modifiers   []ModifierSelection `json:"modifiers"`
+	Ingredients string              `json:"ingredients"`
+}
+
+// Conteúdo completo do carrinho (substitui o atual)
+type UpdateCartRequest struct {
+	Items        []CartItemInput `json:"items"`
+	CouponCode   string          `json:"coupon_code"`
+	RedeemPoints int             `json:"redeem_points"`
+	Delivery     bool            `json:"delivery"`
+	Notes        string          `json:"notes"`
+}
+
+// Nova quantidade de um item (0 remove)
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// Carrinho como está gravado, sem preços (vazio se o usuário não tem um)
+func loadCart(q queryer, userID string) (*Cart, error) {
+	cart := &Cart{Items: []CartItem{}}
+	var updatedAt time.Time
+	err := q.QueryRow(`
+		SELECT COALESCE(coupon_code, ''), redeem_points, delivery, COALESCE(notes, ''), updated_at
+		FROM carts
+		WHERE user_id = $1
+	`, userID).Scan(&cart.CouponCode, &cart.RedeemPoints, &cart.Delivery, &cart.Notes, &updatedAt)
+	if err == sql.ErrNoRows {
+		return cart, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cart.UpdatedAt = &updatedAt
+
+	rows, err := q.Query(`
+		SELECT id, product_name, product_category, quantity, modifiers, ingredients
+		FROM cart_items
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item CartItem
+		var modifiers []byte
+		if err := rows.Scan(&item.ID, &item.ProductName, &item.ProductCategory, &item.Quantity, &modifiers, &item.Ingredients); err != nil {
+			return nil, err
+		}
+		var selected []ModifierSelection
+		if err := json.Unmarshal(modifiers, &selected); err != nil {
+			return nil, err
+		}
+		item.Modifiers = make([]OrderItemModifier, len(selected))
+		for i, s := range selected {
+			item.Modifiers[i].ModifierSelection = s
+		}
+		cart.Items = append(cart.Items, item)
+	}
+	return cart, rows.Err()
+}
+
+// Carrinho do usuário com preços, promoções, pontos, tributos e taxa de
+// entrega calculados agora
+func GetCart(db *sql.DB, userID string) (*Cart, error) {
+	cart, err := loadCart(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := priceCart(db, userID, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// Precificar o carrinho com priceOrder numa transação somente leitura, sem
+// bloquear promoções nem pontos. Itens fora do cardápio (produto ou opção)
+// ficam de fora; cupom ou pontos que não se aplicam viram avisos e o valor é
+// calculado sem eles.
+func priceCart(db *sql.DB, userID string, cart *Cart) error {
+	cart.Taxes, cart.Discounts, cart.Warnings = []OrderTax{}, []OrderDiscount{}, []string{}
+
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	req := CreateOrderRequest{
+		CouponCode:   cart.CouponCode,
+		RedeemPoints: cart.RedeemPoints,
+		Delivery:     cart.Delivery,
+	}
+	var index []int // linha do pedido -> item do carrinho
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		product, err := GetProduct(tx, item.ProductCategory, item.ProductName)
+		if errors.Is(err, ErrUnknownProduct) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if _, _, err := resolveModifiers(product, modifierSelections(item.Modifiers)); err != nil {
+			cart.Warnings = append(cart.Warnings, err.Error())
+			continue
+		}
+		item.Available = true
+		req.Items = append(req.Items, OrderItem{
+			ProductName:     item.ProductName,
+			ProductCategory: item.ProductCategory,
+			Quantity:        item.Quantity,
+			Modifiers:       item.Modifiers,
+		})
+		index = append(index, i)
+	}
+	if len(req.Items) == 0 {
+		return nil
+	}
+
+	var q *orderQuote
+	for {
+		q, err = priceOrder(tx, userID, req, time.Now(), true)
+		if isCouponError(err) && req.CouponCode != "" {
+			cart.Warnings = append(cart.Warnings, err.Error())
+			req.CouponCode = ""
+			continue
+		}
+		if (errors.Is(err, ErrInsufficientPoints) || errors.Is(err, ErrRedeemDisabled)) && req.RedeemPoints != 0 {
+			cart.Warnings = append(cart.Warnings, err.Error())
+			req.RedeemPoints = 0
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		break
+	}
+
+	for line, i := range index {
+		item := &cart.Items[i]
+		item.Modifiers = q.modifiers[line]
+		item.UnitCents = q.lines[line].UnitCents
+		item.DiscountCents = q.priced.LineDiscounts[line]
+		item.TaxCents, item.IncludedTaxCents = q.priced.LineTaxCents(line)
+	}
+	cart.SubtotalCents = q.priced.SubtotalCents
+	cart.DiscountCents = q.priced.DiscountCents
+	cart.TaxCents = q.priced.TaxCents
+	cart.DeliveryFeeCents = q.priced.DeliveryFeeCents
+	cart.TotalCents = q.priced.TotalCents
+	cart.IncludedTaxCents = q.priced.IncludedTaxCents
+	cart.Taxes = orderTaxes(q.priced.Taxes)
+	cart.Discounts = orderDiscounts(q.priced.Discounts)
+	cart.LoyaltyPoints = q.points
+	cart.LoyaltyDiscountCents = q.pointsCents
+	return nil
+}
+
+func isCouponError(err error) bool {
+	return errors.Is(err, ErrCouponInvalid) || errors.Is(err, ErrCouponExpired) ||
+		errors.Is(err, ErrCouponUsageLimit) || errors.Is(err, ErrCouponNotApplicable)
+}
+
+// Criar o carrinho se preciso e marcar a alteração, bloqueando-o até o fim da
+// transação
+func touchCart(tx *sql.Tx, userID string) error {
+	_, err := tx.Exec(`
+		INSERT INTO carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+	`, userID)
+	return err
+}
+
+// Conferir o item contra o cardápio, deixando as opções na forma gravada
+func validateCartItem(tx *sql.Tx, item *CartItemInput) error {
+	if item.Quantity < 1 {
+		return ErrInvalidQuantity
+	}
+	product, err := GetProduct(tx, item.ProductCategory, item.ProductName)
+	if err != nil {
+		return err
+	}
+	mods, _, err := resolveModifiers(product, item.Modifiers)
+	if err != nil {
+		return err
+	}
+	item.Modifiers = modifierSelections(mods)
+	return nil
+}
+
+// Somar o item ao carrinho (mesmo produto, opções e observação somam a
+// quantidade)
+func insertCartItem(tx *sql.Tx, userID string, item CartItemInput) error {
+	modifiers, err := json.Marshal(item.Modifiers)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO cart_items (user_id, product_category, product_name, quantity, modifiers, ingredients)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, product_category, lower(product_name), ingredients, modifiers)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+	`, userID, item.ProductCategory, item.ProductName, item.Quantity, string(modifiers), item.Ingredients)
+	return err
+}
+
+func checkCartSize(tx *sql.Tx, userID string) error {
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM cart_items WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count > MaxCartItems {
+		return ErrCartFull
+	}
+	return nil
+}
+
+// Substituir todo o conteúdo do carrinho
+func ReplaceCart(db *sql.DB, userID string, req UpdateCartRequest) (*Cart, error) {
+	if req.RedeemPoints < 0 {
+		return nil, ErrInsufficientPoints
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := touchCart(tx, userID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		UPDATE carts SET coupon_code = NULLIF($1, ''), redeem_points = $2, delivery = $3, notes = NULLIF($4, '')
+		WHERE user_id = $5
+	`, req.CouponCode, req.RedeemPoints, req.Delivery, req.Notes, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM cart_items WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+	for _, item := range req.Items {
+		if err := validateCartItem(tx, &item); err != nil {
+			return nil, err
+		}
+		if err := insertCartItem(tx, userID, item); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkCartSize(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetCart(db, userID)
+}
+
+// Adicionar um item ao carrinho
+func AddCartItem(db *sql.DB, userID string, item CartItemInput) (*Cart, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := validateCartItem(tx, &item); err != nil {
+		return nil, err
+	}
+	if err := touchCart(tx, userID); err != nil {
+		return nil, err
+	}
+	if err := insertCartItem(tx, userID, item); err != nil {
+		return nil, err
+	}
+	if err := checkCartSize(tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetCart(db, userID)
+}
+
+// Alterar a quantidade de um item; 0 remove
+func UpdateCartItem(db *sql.DB, userID, itemID string, quantity int) (*Cart, error) {
+	if quantity < 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := touchCart(tx, userID); err != nil {
+		return nil, err
+	}
+	var result sql.Result
+	if quantity == 0 {
+		result, err = tx.Exec(`DELETE FROM cart_items WHERE id = $1 AND user_id = $2`, itemID, userID)
+	} else {
+		result, err = tx.Exec(`UPDATE cart_items SET quantity = $1 WHERE id = $2 AND user_id = $3`, quantity, itemID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrCartItemNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetCart(db, userID)
+}
+
+// Esvaziar o carrinho (itens, cupom, pontos e opções)
+func ClearCart(db *sql.DB, userID string) error {
+	_, err := db.Exec(`DELETE FROM carts WHERE user_id = $1`, userID)
+	return err
+}
+
+// Transformar o carrinho em pedido e esvaziá-lo, na mesma transação. Itens
+// fora do cardápio, cupom ou pontos inválidos falham como na criação do
+// pedido, sem alterar o carrinho.
+func CheckoutCart(db *sql.DB, userID string) (*Order, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Bloquear o carrinho: dois checkouts simultâneos não geram dois pedidos
+	var locked bool
+	err = tx.QueryRow(`SELECT true FROM carts WHERE user_id = $1 FOR UPDATE`, userID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, ErrCartEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := loadCart(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	req := CreateOrderRequest{
+		Notes:        cart.Notes,
+		CouponCode:   cart.CouponCode,
+		RedeemPoints: cart.RedeemPoints,
+		Delivery:     cart.Delivery,
+	}
+	for _, item := range cart.Items {
+		req.Items = append(req.Items, OrderItem{
+			ProductName:     item.ProductName,
+			ProductCategory: item.ProductCategory,
+			Quantity:        item.Quantity,
+			Modifiers:       item.Modifiers,
+			Ingredients:     item.Ingredients,
+		})
+	}
+
+	order, err := createOrder(tx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM carts WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,260 @@
+// Arquivo: backend/models/export.go
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DataExportTTL = 7 * 24 * time.Hour
+
+	// Contas com mais pedidos que isso têm a exportação gerada em segundo plano
+	DataExportSyncMaxOrders = 50
+)
+
+type Session struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"` // pending, ready, failed
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// Sessões do usuário (o token em si nunca é exportado)
+func GetUserSessions(db *sql.DB, userID string) ([]Session, error) {
+	query := `
+		SELECT id, created_at, expires_at, COALESCE(ip_address, ''), COALESCE(user_agent, '')
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.CreatedAt, &s.ExpiresAt, &s.IPAddress, &s.UserAgent); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Todos os pedidos do usuário, em qualquer status, com itens
+func GetAllUserOrders(db *sql.DB, userID string) ([]Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders o
+		WHERE o.user_id = $1
+		ORDER BY o.created_at DESC
+	`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		var order Order
+		if err := rows.Scan(order.scanDest()...); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if err := loadOrderLines(db, &orders[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
+}
+
+// Quantidade de pedidos (decide entre exportação síncrona ou assíncrona)
+func CountUserOrders(db *sql.DB, userID string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM orders WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// Montar o arquivo ZIP com os dados pessoais do usuário em JSON
+func BuildUserDataExport(db *sql.DB, userID string) ([]byte, error) {
+	user, err := GetUserByID(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := GetUserSessions(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := GetUserIdentities(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := GetAllUserOrders(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := GetUserPayments(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := GetUserRefunds(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	loyalty, err := GetLoyaltyTransactions(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := loadCart(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"sessions.json", sessions},
+		{"identities.json", identities},
+		{"orders.json", orders},
+		{"payments.json", payments},
+		{"refunds.json", refunds},
+		{"loyalty.json", loyalty},
+		{"cart.json", cart},
+		{"chat_conversations.json", map[string]interface{}{
+			"conversations": []interface{}{},
+			"note":          "As conversas do chat não são armazenadas no servidor; o histórico fica apenas no seu navegador.",
+		}},
+		{"README.json", map[string]interface{}{
+			"generated_at": time.Now().UTC(),
+			"user_id":      user.ID,
+			"description":  "Cópia dos seus dados pessoais mantidos pela FinPlay (LGPD, art. 18).",
+		}},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Criar registro de exportação pendente
+func CreateDataExport(db *sql.DB, userID string) (*DataExport, error) {
+	var e DataExport
+	query := `
+		INSERT INTO data_exports (user_id, status, expires_at)
+		VALUES ($1, 'pending', $2)
+		RETURNING id, user_id, status, created_at, expires_at
+	`
+	err := db.QueryRow(query, userID, time.Now().Add(DataExportTTL)).Scan(
+		&e.ID, &e.UserID, &e.Status, &e.CreatedAt, &e.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Gerar o arquivo de uma exportação pendente (executado em segundo plano)
+func ProcessDataExport(db *sql.DB, exportID, userID string) error {
+	archive, buildErr := BuildUserDataExport(db, userID)
+	if buildErr != nil {
+		_, err := db.Exec(
+			`UPDATE data_exports SET status = 'failed', error = $1, completed_at = $2 WHERE id = $3`,
+			buildErr.Error(), time.Now(), exportID,
+		)
+		if err != nil {
+			return err
+		}
+		return buildErr
+	}
+
+	_, err := db.Exec(
+		`UPDATE data_exports SET status = 'ready', archive = $1, completed_at = $2 WHERE id = $3`,
+		archive, time.Now(), exportID,
+	)
+	return err
+}
+
+// Buscar exportação do usuário (sem o arquivo)
+func GetDataExport(db *sql.DB, exportID, userID string) (*DataExport, error) {
+	var e DataExport
+	query := `
+		SELECT id, user_id, status, COALESCE(error, ''), created_at, completed_at, expires_at
+		FROM data_exports
+		WHERE id = $1 AND user_id = $2 AND expires_at > $3
+	`
+	err := db.QueryRow(query, exportID, userID, time.Now()).Scan(
+		&e.ID, &e.UserID, &e.Status, &e.Error, &e.CreatedAt, &e.CompletedAt, &e.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+var ErrExportNotReady = errors.New("exportação não encontrada ou ainda não concluída")
+
+// Buscar arquivo ZIP de uma exportação pronta
+func GetDataExportArchive(db *sql.DB, exportID, userID string) ([]byte, error) {
+	var archive []byte
+	query := `
+		SELECT archive
+		FROM data_exports
+		WHERE id = $1 AND user_id = $2 AND status = 'ready' AND expires_at > $3
+	`
+	err := db.QueryRow(query, exportID, userID, time.Now()).Scan(&archive)
+	if err == sql.ErrNoRows {
+		return nil, ErrExportNotReady
+	}
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
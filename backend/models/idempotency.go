@@ -0,0 +1,101 @@
+// Arquivo: backend/models/idempotency.go
+package models
+
+import (
+	"database/sql"
+	"finplay/backend/store"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Intervalo mínimo entre limpezas das chaves expiradas
+const idempotencySweepInterval = 5 * time.Minute
+
+// Store de Idempotency-Key no PostgreSQL, compartilhado entre instâncias
+type IdempotencyStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+func (s *IdempotencyStore) Reserve(userID, key, fingerprint string, now time.Time, ttl time.Duration) (*store.IdempotencyRecord, bool, error) {
+	s.sweep(now)
+
+	// Insere a chave ou reaproveita uma expirada/abandonada; se o UPDATE do
+	// ON CONFLICT não se aplicar, nada é retornado e a chave pertence a outra
+	// requisição
+	err := s.db.QueryRow(`
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL,
+			body = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $4
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at <= $6)
+		RETURNING user_id
+	`, userID, key, fingerprint, now.UTC(), now.Add(ttl).UTC(), now.Add(-store.IdempotencyLockTimeout).UTC(),
+	).Scan(new(string))
+	if err == nil {
+		return nil, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	var record store.IdempotencyRecord
+	var status sql.NullInt64
+	var contentType sql.NullString
+	var body []byte
+	err = s.db.QueryRow(`
+		SELECT fingerprint, status_code, content_type, body, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`, userID, key).Scan(&record.Fingerprint, &status, &contentType, &body, &record.CreatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+	if status.Valid {
+		record.Response = &store.IdempotentResponse{
+			Status:      int(status.Int64),
+			ContentType: contentType.String,
+			Body:        body,
+		}
+	}
+	return &record, false, nil
+}
+
+func (s *IdempotencyStore) Complete(userID, key string, resp store.IdempotentResponse) error {
+	_, err := s.db.Exec(`
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = NULLIF($2, ''), body = $3
+		WHERE user_id = $4 AND key = $5
+	`, resp.Status, resp.ContentType, resp.Body, userID, key)
+	return err
+}
+
+func (s *IdempotencyStore) Release(userID, key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	return err
+}
+
+// Remover chaves expiradas, no máximo uma vez por intervalo
+func (s *IdempotencyStore) sweep(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	// Falha aqui não impede a requisição: a chave expirada é reaproveitada no Reserve
+	if _, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= $1`, now.UTC()); err != nil {
+		slog.Warn("erro ao limpar chaves de idempotência", "error", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Arquivo: backend/models/identity.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Identidade externa (login social) retornada pelo provedor OIDC
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FullName      string
+}
+
+// Identidade vinculada à conta (exportação de dados)
+type UserIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Senha inutilizável para contas criadas via login social
+const unusablePasswordHash = "!"
+
+var (
+	ErrEmailNotVerified  = errors.New("o provedor não confirmou o email desta conta")
+	ErrIdentityNotLinked = errors.New("identidade não vinculada a nenhuma conta")
+)
+
+// Conta com senha própria (as criadas via login social começam sem)
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != unusablePasswordHash
+}
+
+// Usuário já vinculado à identidade externa, sem vincular nem criar conta
+// (usado na reautenticação)
+func GetUserIDByIdentity(db *sql.DB, provider, subject string) (string, error) {
+	var userID string
+	query := `SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2`
+	err := db.QueryRow(query, provider, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", ErrIdentityNotLinked
+	}
+	return userID, err
+}
+
+// Encontrar o usuário da identidade externa, vinculando por email verificado
+// a um usuário existente ou criando um novo. Retorna true se o usuário foi criado.
+func FindOrCreateUserByIdentity(db *sql.DB, ident ExternalIdentity) (*User, bool, error) {
+	// 1. Identidade já vinculada
+	var userID string
+	query := `SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2`
+	err := db.QueryRow(query, ident.Provider, ident.Subject).Scan(&userID)
+	if err == nil {
+		user, err := GetUserByID(db, userID)
+		return user, false, err
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	// Vincular ou criar exige email confirmado pelo provedor. Provedores podem
+	// devolver o email com outra capitalização que a do cadastro.
+	email := strings.ToLower(strings.TrimSpace(ident.Email))
+	if email == "" || !ident.EmailVerified {
+		return nil, false, ErrEmailNotVerified
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// 2. Usuário existente com o mesmo email
+	created := false
+	err = tx.QueryRow(`SELECT id FROM users WHERE lower(email) = $1 AND is_active = true`, email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		// 3. Novo usuário
+		query := `
+			INSERT INTO users (email, password_hash, full_name)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`
+		err = tx.QueryRow(query, email, unusablePasswordHash, ident.FullName).Scan(&userID)
+		created = true
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)`,
+		userID, ident.Provider, ident.Subject, email,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	user, err := GetUserByID(db, userID)
+	return user, created, err
+}
+
+// Identidades externas vinculadas ao usuário
+func GetUserIdentities(db *sql.DB, userID string) ([]UserIdentity, error) {
+	query := `
+		SELECT provider, subject, COALESCE(email, ''), created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []UserIdentity{}
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(&i.Provider, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
@@ -0,0 +1,439 @@
+// Arquivo: backend/models/loyalty.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"finplay/backend/config"
+	"time"
+)
+
+// Tipos de lançamento no extrato de pontos. earn e restore criam lotes com
+// saldo próprio (remaining) e validade; redeem, expire e reverse consomem os
+// lotes que vencem primeiro (redeem e reverse registram quanto tiraram de cada
+// lote em loyalty_lot_debits).
+const (
+	LoyaltyEarn    = "earn"    // pedido finalizado
+	LoyaltyRedeem  = "redeem"  // desconto em um novo pedido
+	LoyaltyRestore = "restore" // devolução dos pontos resgatados em pedido cancelado/estornado
+	LoyaltyExpire  = "expire"
+	LoyaltyReverse = "reverse" // estorno de pedido que já tinha gerado pontos
+)
+
+var (
+	ErrInsufficientPoints = errors.New("saldo de pontos insuficiente")
+	ErrRedeemDisabled     = errors.New("resgate de pontos indisponível")
+)
+
+var loyaltyConfig = config.Default().Loyalty
+
+// Aplicar a configuração carregada
+func ConfigureLoyalty(cfg config.LoyaltyConfig) {
+	loyaltyConfig = cfg
+}
+
+// Lançamento do extrato (points negativo para débitos)
+type LoyaltyTransaction struct {
+	ID        string     `json:"id"`
+	OrderID   string     `json:"order_id,omitempty"`
+	Kind      string     `json:"kind"`
+	Points    int        `json:"points"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Saldo e extrato do usuário
+type LoyaltyAccount struct {
+	Balance         int   `json:"balance"`
+	BalanceCents    int64 `json:"balance_cents"` // valor do saldo no resgate
+	PointValueCents int   `json:"point_value_cents"`
+	PointsPerReal   int   `json:"points_per_real"`
+	// Pontos que vencem primeiro e quando
+	ExpiringPoints int                  `json:"expiring_points,omitempty"`
+	ExpiringAt     *time.Time           `json:"expiring_at,omitempty"`
+	Transactions   []LoyaltyTransaction `json:"transactions"`
+}
+
+// Pontos ganhos por um valor pago
+func loyaltyPointsFor(cents int64) int {
+	return int(cents * int64(loyaltyConfig.PointsPerReal) / 100)
+}
+
+// Saldo (já sem os pontos vencidos) e extrato completo, do mais recente ao
+// mais antigo
+func GetLoyaltyAccount(db *sql.DB, userID string) (*LoyaltyAccount, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := expireLoyaltyPoints(tx, userID, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	account := &LoyaltyAccount{
+		PointValueCents: loyaltyConfig.PointValueCents,
+		PointsPerReal:   loyaltyConfig.PointsPerReal,
+	}
+	err = db.QueryRow(`
+		SELECT COALESCE(SUM(remaining), 0) FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0 AND expires_at > $2
+	`, userID, now).Scan(&account.Balance)
+	if err != nil {
+		return nil, err
+	}
+	account.BalanceCents = int64(account.Balance) * int64(loyaltyConfig.PointValueCents)
+
+	var expiringAt sql.NullTime
+	err = db.QueryRow(`
+		SELECT expires_at, SUM(remaining) FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0 AND expires_at > $2
+		GROUP BY expires_at
+		ORDER BY expires_at
+		LIMIT 1
+	`, userID, now).Scan(&expiringAt, &account.ExpiringPoints)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if expiringAt.Valid {
+		account.ExpiringAt = &expiringAt.Time
+	}
+
+	account.Transactions, err = GetLoyaltyTransactions(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Extrato completo do usuário (também usado na exportação de dados)
+func GetLoyaltyTransactions(db *sql.DB, userID string) ([]LoyaltyTransaction, error) {
+	rows, err := db.Query(`
+		SELECT id, COALESCE(order_id::text, ''), kind, points, expires_at, created_at
+		FROM loyalty_transactions
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []LoyaltyTransaction{}
+	for rows.Next() {
+		var t LoyaltyTransaction
+		if err := rows.Scan(&t.ID, &t.OrderID, &t.Kind, &t.Points, &t.ExpiresAt, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// Zerar os lotes vencidos, lançando um expire para cada um
+func expireLoyaltyPoints(tx *sql.Tx, userID string, now time.Time) error {
+	rows, err := tx.Query(`
+		SELECT id, remaining, expires_at FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0 AND expires_at <= $2
+		FOR UPDATE
+	`, userID, now)
+	if err != nil {
+		return err
+	}
+
+	type lot struct {
+		id        string
+		remaining int
+		expiresAt time.Time
+	}
+	var expired []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.remaining, &l.expiresAt); err != nil {
+			rows.Close()
+			return err
+		}
+		expired = append(expired, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, l := range expired {
+		if _, err := tx.Exec(`UPDATE loyalty_transactions SET remaining = 0 WHERE id = $1`, l.id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO loyalty_transactions (user_id, kind, points, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, userID, LoyaltyExpire, -l.remaining, l.expiresAt, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creditar um lote de pontos com a validade configurada
+func creditLoyaltyPoints(tx *sql.Tx, userID, orderID, kind string, points int, now time.Time) error {
+	return insertLoyaltyLot(tx, userID, orderID, kind, points, now.Add(loyaltyConfig.PointsTTL), now)
+}
+
+func insertLoyaltyLot(tx *sql.Tx, userID, orderID, kind string, points int, expiresAt, now time.Time) error {
+	if points <= 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT INTO loyalty_transactions (user_id, order_id, kind, points, remaining, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $4, $5, $6)
+	`, userID, orderID, kind, points, expiresAt, now)
+	return err
+}
+
+// Saldo disponível. Sem readOnly, os lotes vencidos são zerados e os
+// restantes ficam bloqueados até o fim da transação.
+func loyaltyBalance(tx *sql.Tx, userID string, now time.Time, readOnly bool) (int, error) {
+	query := `
+		SELECT remaining FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0 AND expires_at > $2
+	`
+	if !readOnly {
+		if err := expireLoyaltyPoints(tx, userID, now); err != nil {
+			return 0, err
+		}
+		query += ` FOR UPDATE`
+	}
+	rows, err := tx.Query(query, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var balance int
+	for rows.Next() {
+		var remaining int
+		if err := rows.Scan(&remaining); err != nil {
+			return 0, err
+		}
+		balance += remaining
+	}
+	return balance, rows.Err()
+}
+
+// Debitar até points pontos, consumindo primeiro os lotes que vencem antes.
+// Retorna quanto foi debitado (menos que points se o saldo não bastar).
+func debitLoyaltyPoints(tx *sql.Tx, userID, orderID, kind string, points int, now time.Time) (int, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+	rows, err := tx.Query(`
+		SELECT id, remaining FROM loyalty_transactions
+		WHERE user_id = $1 AND remaining > 0 AND expires_at > $2
+		ORDER BY expires_at, created_at
+		FOR UPDATE
+	`, userID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	type lot struct {
+		id        string
+		remaining int
+		take      int
+	}
+	var lots []lot
+	debited := 0
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if debited < points {
+			l.take = min(l.remaining, points-debited)
+			debited += l.take
+			lots = append(lots, l)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if debited == 0 {
+		return 0, nil
+	}
+
+	var debitID string
+	err = tx.QueryRow(`
+		INSERT INTO loyalty_transactions (user_id, order_id, kind, points, created_at)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5)
+		RETURNING id
+	`, userID, orderID, kind, -debited, now).Scan(&debitID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, l := range lots {
+		if _, err := tx.Exec(`UPDATE loyalty_transactions SET remaining = remaining - $1 WHERE id = $2`, l.take, l.id); err != nil {
+			return 0, err
+		}
+		_, err := tx.Exec(`
+			INSERT INTO loyalty_lot_debits (debit_id, lot_id, points) VALUES ($1, $2, $3)
+		`, debitID, l.id, l.take)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return debited, nil
+}
+
+// Resgate no checkout: limita os pontos à parte máxima do total e debita.
+// Retorna os pontos usados e o valor do desconto. Com readOnly o saldo é lido
+// sem bloqueio.
+func redeemLoyaltyPoints(tx *sql.Tx, userID string, points int, totalCents int64, now time.Time, readOnly bool) (int, int64, error) {
+	if points < 0 {
+		return 0, 0, ErrInsufficientPoints
+	}
+	if points == 0 {
+		return 0, 0, nil
+	}
+	if loyaltyConfig.MaxRedeemPercent == 0 {
+		return 0, 0, ErrRedeemDisabled
+	}
+
+	balance, err := loyaltyBalance(tx, userID, now, readOnly)
+	if err != nil {
+		return 0, 0, err
+	}
+	if points > balance {
+		return 0, 0, ErrInsufficientPoints
+	}
+
+	value := int64(loyaltyConfig.PointValueCents)
+	maxPoints := int(totalCents * int64(loyaltyConfig.MaxRedeemPercent) / 100 / value)
+	used := min(points, maxPoints)
+	return used, int64(used) * value, nil
+}
+
+// Pontos do pedido finalizado, sobre o valor efetivamente pago (capturado
+// menos estornado)
+func accrueLoyaltyPoints(tx *sql.Tx, orderID, userID string, now time.Time) error {
+	var paid int64
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(captured_cents - refunded_cents), 0) FROM payments
+		WHERE order_id = $1 AND status = $2
+	`, orderID, PaymentCaptured).Scan(&paid)
+	if err != nil {
+		return err
+	}
+	return creditLoyaltyPoints(tx, userID, orderID, LoyaltyEarn, loyaltyPointsFor(paid), now)
+}
+
+// Estorno de pedido já finalizado: retirar os pontos proporcionais ao valor
+// estornado, sem passar do que o pedido gerou. Pontos já gastos não são
+// cobrados de volta.
+func reverseLoyaltyPoints(tx *sql.Tx, orderID, userID string, refundCents int64, now time.Time) error {
+	var earned, reversed int
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(points) FILTER (WHERE kind = $2), 0),
+			COALESCE(-SUM(points) FILTER (WHERE kind = $3), 0)
+		FROM loyalty_transactions
+		WHERE order_id = $1
+	`, orderID, LoyaltyEarn, LoyaltyReverse).Scan(&earned, &reversed)
+	if err != nil {
+		return err
+	}
+
+	points := min(loyaltyPointsFor(refundCents), earned-reversed)
+	if err := expireLoyaltyPoints(tx, userID, now); err != nil {
+		return err
+	}
+	_, err = debitLoyaltyPoints(tx, userID, orderID, LoyaltyReverse, points, now)
+	return err
+}
+
+// Pedido cancelado ou estornado: devolver os pontos resgatados nele (uma vez),
+// com a validade dos lotes de onde saíram. Pontos de lotes já vencidos voltam
+// vencidos e saem no próximo expire.
+func restoreLoyaltyPoints(tx *sql.Tx, orderID string, now time.Time) error {
+	var userID string
+	var redeemed, restored int
+	err := tx.QueryRow(`
+		SELECT o.user_id,
+			COALESCE(-SUM(t.points) FILTER (WHERE t.kind = $2), 0),
+			COALESCE(SUM(t.points) FILTER (WHERE t.kind = $3), 0)
+		FROM orders o
+		LEFT JOIN loyalty_transactions t ON t.order_id = o.id
+		WHERE o.id = $1
+		GROUP BY o.user_id
+	`, orderID, LoyaltyRedeem, LoyaltyRestore).Scan(&userID, &redeemed, &restored)
+	if err != nil {
+		return err
+	}
+	if redeemed <= restored {
+		return nil
+	}
+
+	rows, err := tx.Query(`
+		SELECT lot.expires_at, SUM(d.points)
+		FROM loyalty_transactions t
+		JOIN loyalty_lot_debits d ON d.debit_id = t.id
+		JOIN loyalty_transactions lot ON lot.id = d.lot_id
+		WHERE t.order_id = $1 AND t.kind = $2
+		GROUP BY lot.expires_at
+		ORDER BY lot.expires_at DESC
+	`, orderID, LoyaltyRedeem)
+	if err != nil {
+		return err
+	}
+	var consumed []loyaltyLotPoints
+	for rows.Next() {
+		var l loyaltyLotPoints
+		if err := rows.Scan(&l.expiresAt, &l.points); err != nil {
+			rows.Close()
+			return err
+		}
+		consumed = append(consumed, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	lots, rest := restoredLots(redeemed-restored, consumed)
+	for _, l := range lots {
+		if err := insertLoyaltyLot(tx, userID, orderID, LoyaltyRestore, l.points, l.expiresAt, now); err != nil {
+			return err
+		}
+	}
+	// Resgates sem registro dos lotes (anteriores a loyalty_lot_debits)
+	// voltam com a validade configurada
+	return creditLoyaltyPoints(tx, userID, orderID, LoyaltyRestore, rest, now)
+}
+
+// Pontos tirados de lotes com a mesma validade
+type loyaltyLotPoints struct {
+	expiresAt time.Time
+	points    int
+}
+
+// Distribuir points entre os lotes consumidos, na ordem dada (os que vencem
+// depois primeiro). Retorna os lotes a recriar e o que não coube neles.
+func restoredLots(points int, consumed []loyaltyLotPoints) ([]loyaltyLotPoints, int) {
+	var lots []loyaltyLotPoints
+	for _, c := range consumed {
+		if points == 0 {
+			break
+		}
+		take := min(c.points, points)
+		lots = append(lots, loyaltyLotPoints{expiresAt: c.expiresAt, points: take})
+		points -= take
+	}
+	return lots, points
+}
@@ -0,0 +1,35 @@
+// Arquivo: backend/models/loyalty_test.go
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRestoredLots(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	consumed := []loyaltyLotPoints{{june, 30}, {march, 50}}
+
+	tests := []struct {
+		name     string
+		points   int
+		consumed []loyaltyLotPoints
+		want     []loyaltyLotPoints
+		wantRest int
+	}{
+		{"tudo de volta aos lotes", 80, consumed, []loyaltyLotPoints{{june, 30}, {march, 50}}, 0},
+		{"parcial começa pelo que vence depois", 40, consumed, []loyaltyLotPoints{{june, 30}, {march, 10}}, 0},
+		{"sem registro dos lotes", 25, nil, nil, 25},
+		{"registro menor que o resgate", 100, consumed, []loyaltyLotPoints{{june, 30}, {march, 50}}, 20},
+		{"nada a devolver", 0, consumed, nil, 0},
+	}
+
+	for _, tt := range tests {
+		got, rest := restoredLots(tt.points, tt.consumed)
+		if !slices.Equal(got, tt.want) || rest != tt.wantRest {
+			t.Errorf("%s: restoredLots(%d) = %v, %d, esperado %v, %d", tt.name, tt.points, got, rest, tt.want, tt.wantRest)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+// Arquivo: backend/models/mfa.go
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TOTPIssuer        = "FinPlay"
+	totpDigits        = 6
+	totpPeriod        = 30 // segundos
+	totpSkew          = 1  // janelas aceitas antes/depois da atual
+	RecoveryCodeCount = 10
+
+	// Códigos incorretos seguidos antes de bloquear a segunda etapa do login.
+	// O bloqueio dura mais que o token "mfa pending", então os tokens usados
+	// nas tentativas já terão expirado quando ele acabar.
+	MaxMFAFailures     = 5
+	MFALockoutDuration = 15 * time.Minute
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Gerar segredo TOTP aleatório (160 bits, base32)
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// Montar URI otpauth:// para apps autenticadores (Google Authenticator, Authy...)
+func TOTPURI(secret, email string) string {
+	label := url.PathEscape(TOTPIssuer + ":" + email)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOTPIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// Calcular código TOTP (RFC 6238) para um instante
+func GenerateTOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/totpPeriod))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// Janela de tempo (t/30s) em que o código confere, aceitando pequena
+// diferença de relógio
+func matchTOTPStep(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if secret == "" || len(code) != totpDigits {
+		return 0, false
+	}
+
+	for i := -totpSkew; i <= totpSkew; i++ {
+		t := now.Add(time.Duration(i*totpPeriod) * time.Second)
+		expected, err := GenerateTOTPCode(secret, t)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / totpPeriod, true
+		}
+	}
+	return 0, false
+}
+
+// Validar código TOTP do usuário e registrar a janela aceita. Um código só
+// vale uma vez: janelas iguais ou anteriores à última aceita são recusadas,
+// inclusive em requisições concorrentes.
+func VerifyUserTOTP(db *sql.DB, userID, secret, code string) (bool, error) {
+	step, ok := matchTOTPStep(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	query := `
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`
+	result, err := db.Exec(query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// Fim do bloqueio da segunda etapa do login (zero se não está bloqueada)
+func MFALockedUntil(db *sql.DB, userID string) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := db.QueryRow(`SELECT mfa_locked_until FROM users WHERE id = $1`, userID).Scan(&lockedUntil)
+	if err != nil || !lockedUntil.Valid || !time.Now().Before(lockedUntil.Time) {
+		return time.Time{}, err
+	}
+	return lockedUntil.Time, nil
+}
+
+// Registrar um código 2FA incorreto. Retorna o fim do bloqueio quando esta
+// falha atinge MaxMFAFailures (zero caso contrário).
+func RecordMFAFailure(db *sql.DB, userID string) (time.Time, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var attempts int
+	err = tx.QueryRow(`SELECT mfa_failed_attempts FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&attempts)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	attempts, lockedUntil := nextMFAFailure(attempts, time.Now())
+	if lockedUntil.IsZero() {
+		_, err = tx.Exec(`UPDATE users SET mfa_failed_attempts = $1 WHERE id = $2`, attempts, userID)
+	} else {
+		_, err = tx.Exec(
+			`UPDATE users SET mfa_failed_attempts = $1, mfa_locked_until = $2 WHERE id = $3`,
+			attempts, lockedUntil, userID,
+		)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lockedUntil, tx.Commit()
+}
+
+// Zerar as falhas após um código aceito
+func ResetMFAFailures(db *sql.DB, userID string) error {
+	_, err := db.Exec(`UPDATE users SET mfa_failed_attempts = 0 WHERE id = $1 AND mfa_failed_attempts > 0`, userID)
+	return err
+}
+
+// Contagem após mais uma falha: a falha de número MaxMFAFailures bloqueia até
+// now+MFALockoutDuration e recomeça a contagem
+func nextMFAFailure(attempts int, now time.Time) (int, time.Time) {
+	attempts++
+	if attempts >= MaxMFAFailures {
+		return 0, now.Add(MFALockoutDuration)
+	}
+	return attempts, time.Time{}
+}
+
+// Salvar segredo TOTP pendente (2FA só é ativado após verificação)
+func SetUserTOTPSecret(db *sql.DB, userID, secret string) error {
+	query := `UPDATE users SET totp_secret = $1, totp_enabled = false WHERE id = $2`
+	_, err := db.Exec(query, secret, userID)
+	return err
+}
+
+// Ativar 2FA e substituir códigos de recuperação
+func EnableUserTOTP(db *sql.DB, userID string, recoveryCodes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`UPDATE users SET totp_enabled = true WHERE id = $1`, userID); err != nil {
+		return err
+	}
+
+	if err = replaceRecoveryCodes(tx, userID, recoveryCodes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Desativar 2FA e remover códigos de recuperação
+func DisableUserTOTP(db *sql.DB, userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET totp_secret = NULL, totp_enabled = false WHERE id = $1`
+	if _, err = tx.Exec(query, userID); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Gerar códigos de recuperação legíveis (formato xxxxx-xxxxx)
+func GenerateRecoveryCodes() ([]string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	codes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		for j := range raw {
+			raw[j] = alphabet[int(raw[j])%len(alphabet)]
+		}
+		codes = append(codes, string(raw[:5])+"-"+string(raw[5:]))
+	}
+	return codes, nil
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID string, codes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+	for _, code := range codes {
+		hash, err := HashPassword(normalizeRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Consumir código de recuperação (cada código vale uma única vez)
+func UseRecoveryCode(db *sql.DB, userID, code string) (bool, error) {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return false, nil
+	}
+
+	query := `
+		SELECT id, code_hash
+		FROM user_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var matchedID string
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return false, err
+		}
+		if CheckPassword(code, hash) {
+			matchedID = id
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if matchedID == "" {
+		return false, nil
+	}
+
+	result, err := db.Exec(
+		`UPDATE user_recovery_codes SET used_at = $1 WHERE id = $2 AND used_at IS NULL`,
+		time.Now(), matchedID,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, errors.New("código de recuperação já utilizado")
+	}
+
+	return true, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
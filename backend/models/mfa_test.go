@@ -0,0 +1,95 @@
+// Arquivo: backend/models/mfa_test.go
+package models
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// Vetores do Apêndice B da RFC 6238 (SHA1, segredo "12345678901234567890").
+// A RFC usa 8 dígitos; os 6 dígitos gerados aqui são o final de cada código.
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string // código de 8 dígitos da RFC
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		got, err := GenerateTOTPCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode(%d): %v", tt.unix, err)
+		}
+		if want := tt.want[len(tt.want)-totpDigits:]; got != want {
+			t.Errorf("GenerateTOTPCode(%d) = %s, esperado %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestMatchTOTPStep(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0) // janela 37037037
+	code := func(unix int64) string {
+		c, err := GenerateTOTPCode(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"janela atual", secret, code(1111111111), 37037037, true},
+		{"janela anterior", secret, code(1111111111 - totpPeriod), 37037036, true},
+		{"janela seguinte", secret, code(1111111111 + totpPeriod), 37037038, true},
+		{"fora da tolerância", secret, code(1111111111 - 2*totpPeriod), 0, false},
+		{"espaços em volta", secret, " " + code(1111111111) + " ", 37037037, true},
+		{"tamanho errado", secret, "12345", 0, false},
+		{"sem segredo", "", code(1111111111), 0, false},
+		{"segredo inválido", "!!", code(1111111111), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchTOTPStep(tt.secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("matchTOTPStep = (%d, %v), esperado (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNextMFAFailure(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	attempts := 0
+	for i := 1; i < MaxMFAFailures; i++ {
+		var lockedUntil time.Time
+		attempts, lockedUntil = nextMFAFailure(attempts, now)
+		if attempts != i || !lockedUntil.IsZero() {
+			t.Fatalf("falha %d: contagem %d bloqueio %v, esperado %d sem bloqueio", i, attempts, lockedUntil, i)
+		}
+	}
+
+	attempts, lockedUntil := nextMFAFailure(attempts, now)
+	if attempts != 0 || !lockedUntil.Equal(now.Add(MFALockoutDuration)) {
+		t.Errorf("falha %d: contagem %d bloqueio %v, esperado 0 e %v", MaxMFAFailures, attempts, lockedUntil, now.Add(MFALockoutDuration))
+	}
+
+	// Depois do bloqueio a contagem recomeça
+	if attempts, lockedUntil = nextMFAFailure(attempts, now); attempts != 1 || !lockedUntil.IsZero() {
+		t.Errorf("após o bloqueio: contagem %d bloqueio %v, esperado 1 sem bloqueio", attempts, lockedUntil)
+	}
+}
@@ -0,0 +1,212 @@
+// Arquivo: backend/models/modifier.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Tipos de grupo de opções
+const (
+	ModifierRemove = "remove" // retirar ingrediente
+	ModifierAdd    = "add"    // adicional
+	ModifierChoice = "choice" // escolha entre variações (pão, tamanho)
+)
+
+var ErrInvalidModifier = errors.New("opção inválida")
+
+// Grupo de opções de um produto. O cliente escolhe entre MinSelect e
+// MaxSelect opções; grupo sem escolha recebe as opções padrão.
+type ModifierGroup struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Kind      string     `json:"kind"`
+	MinSelect int        `json:"min_select"`
+	MaxSelect int        `json:"max_select"`
+	Options   []Modifier `json:"options"`
+}
+
+type Modifier struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	PriceDeltaCents int64  `json:"price_delta_cents"`
+	Default         bool   `json:"default,omitempty"`
+}
+
+// Opção escolhida pelo cliente (nomes sem diferenciar maiúsculas)
+type ModifierSelection struct {
+	Group  string `json:"group"`
+	Option string `json:"option"`
+}
+
+// Opção do item do pedido. Kind e o acréscimo vêm do cardápio; valores
+// enviados pelo cliente são ignorados.
+type OrderItemModifier struct {
+	ModifierSelection
+	Kind            string `json:"kind,omitempty"`
+	PriceDeltaCents int64  `json:"price_delta_cents"`
+	modifierID      string
+}
+
+// Grupos e opções ativas dos produtos, na ordem do cardápio
+func productModifierGroups(q queryer, productIDs ...string) (map[string][]ModifierGroup, error) {
+	groups := make(map[string][]ModifierGroup)
+	if len(productIDs) == 0 {
+		return groups, nil
+	}
+	rows, err := q.Query(`
+		SELECT g.product_id, g.id, g.name, g.kind, g.min_select, g.max_select,
+			m.id, m.name, m.price_delta_cents, m.is_default
+		FROM product_modifier_groups g
+		JOIN product_modifiers m ON m.group_id = g.id AND m.active = true
+		WHERE g.product_id = ANY($1::uuid[])
+		ORDER BY g.product_id, g.position, g.name, m.position, m.name
+	`, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID string
+		var g ModifierGroup
+		var m Modifier
+		if err := rows.Scan(&productID, &g.ID, &g.Name, &g.Kind, &g.MinSelect, &g.MaxSelect,
+			&m.ID, &m.Name, &m.PriceDeltaCents, &m.Default); err != nil {
+			return nil, err
+		}
+		list := groups[productID]
+		if n := len(list); n == 0 || list[n-1].ID != g.ID {
+			list = append(list, g)
+		}
+		list[len(list)-1].Options = append(list[len(list)-1].Options, m)
+		groups[productID] = list
+	}
+	return groups, rows.Err()
+}
+
+// Conferir as opções escolhidas contra os grupos do produto: cada opção uma
+// vez e entre MinSelect e MaxSelect por grupo. Devolve as opções na ordem do
+// cardápio e a soma dos acréscimos.
+func resolveModifiers(product *Product, selected []ModifierSelection) ([]OrderItemModifier, int64, error) {
+	chosen := make(map[string]bool) // id da opção
+	for _, s := range selected {
+		g := findModifierGroup(product.ModifierGroups, s.Group)
+		if g == nil {
+			return nil, 0, fmt.Errorf("%w: %s não tem o grupo %q", ErrInvalidModifier, product.Name, s.Group)
+		}
+		m := findModifier(g.Options, s.Option)
+		if m == nil {
+			return nil, 0, fmt.Errorf("%w: %q não existe em %s (%s)", ErrInvalidModifier, s.Option, g.Name, product.Name)
+		}
+		if chosen[m.ID] {
+			return nil, 0, fmt.Errorf("%w: %s repetido em %s (%s)", ErrInvalidModifier, m.Name, g.Name, product.Name)
+		}
+		chosen[m.ID] = true
+	}
+
+	var mods []OrderItemModifier
+	var delta int64
+	for _, g := range product.ModifierGroups {
+		explicit := false
+		for _, m := range g.Options {
+			explicit = explicit || chosen[m.ID]
+		}
+
+		count := 0
+		for _, m := range g.Options {
+			if !chosen[m.ID] && (explicit || !m.Default) {
+				continue
+			}
+			count++
+			delta += m.PriceDeltaCents
+			mods = append(mods, OrderItemModifier{
+				ModifierSelection: ModifierSelection{Group: g.Name, Option: m.Name},
+				Kind:              g.Kind,
+				PriceDeltaCents:   m.PriceDeltaCents,
+				modifierID:        m.ID,
+			})
+		}
+		if count < g.MinSelect {
+			return nil, 0, fmt.Errorf("%w: escolha ao menos %d em %s (%s)", ErrInvalidModifier, g.MinSelect, g.Name, product.Name)
+		}
+		if count > g.MaxSelect {
+			return nil, 0, fmt.Errorf("%w: escolha no máximo %d em %s (%s)", ErrInvalidModifier, g.MaxSelect, g.Name, product.Name)
+		}
+	}
+	if product.PriceCents+delta < 0 {
+		return nil, 0, fmt.Errorf("%w: preço final negativo para %s", ErrInvalidModifier, product.Name)
+	}
+	return mods, delta, nil
+}
+
+func findModifierGroup(groups []ModifierGroup, name string) *ModifierGroup {
+	for i := range groups {
+		if strings.EqualFold(groups[i].Name, strings.TrimSpace(name)) {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+func findModifier(options []Modifier, name string) *Modifier {
+	for i := range options {
+		if strings.EqualFold(options[i].Name, strings.TrimSpace(name)) {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// Seleções na forma canônica (nomes do cardápio, na ordem do cardápio)
+func modifierSelections(mods []OrderItemModifier) []ModifierSelection {
+	out := make([]ModifierSelection, len(mods))
+	for i, m := range mods {
+		out[i] = m.ModifierSelection
+	}
+	return out
+}
+
+// Gravar as opções de um item do pedido
+func insertItemModifiers(tx *sql.Tx, orderID, itemID string, mods []OrderItemModifier) error {
+	for i, m := range mods {
+		_, err := tx.Exec(`
+			INSERT INTO order_item_modifiers (order_id, order_item_id, modifier_id, group_name, kind,
+				name, price_delta_cents, position)
+			VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8)
+		`, orderID, itemID, m.modifierID, m.Group, m.Kind, m.Option, m.PriceDeltaCents, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Opções dos itens de um pedido, por item
+func getOrderItemModifiers(db *sql.DB, orderID string) (map[string][]OrderItemModifier, error) {
+	rows, err := db.Query(`
+		SELECT order_item_id, group_name, kind, name, price_delta_cents
+		FROM order_item_modifiers
+		WHERE order_id = $1
+		ORDER BY order_item_id, position
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mods := make(map[string][]OrderItemModifier)
+	for rows.Next() {
+		var itemID string
+		var m OrderItemModifier
+		if err := rows.Scan(&itemID, &m.Group, &m.Kind, &m.Option, &m.PriceDeltaCents); err != nil {
+			return nil, err
+		}
+		mods[itemID] = append(mods[itemID], m)
+	}
+	return mods, rows.Err()
+}
@@ -0,0 +1,122 @@
+// Arquivo: backend/models/modifier_test.go
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestResolveModifiers(t *testing.T) {
+	burger := &Product{
+		Name:       "X-Burger",
+		PriceCents: 2500,
+		ModifierGroups: []ModifierGroup{
+			{ID: "g1", Name: "Pão", Kind: ModifierChoice, MinSelect: 1, MaxSelect: 1, Options: []Modifier{
+				{ID: "m1", Name: "Brioche", Default: true},
+				{ID: "m2", Name: "Australiano", PriceDeltaCents: 200},
+			}},
+			{ID: "g2", Name: "Retirar", Kind: ModifierRemove, MinSelect: 0, MaxSelect: 3, Options: []Modifier{
+				{ID: "m3", Name: "Cebola"},
+				{ID: "m4", Name: "Picles"},
+				{ID: "m5", Name: "Tomate"},
+			}},
+			{ID: "g3", Name: "Adicionais", Kind: ModifierAdd, MinSelect: 0, MaxSelect: 2, Options: []Modifier{
+				{ID: "m6", Name: "Bacon", PriceDeltaCents: 500},
+				{ID: "m7", Name: "Queijo", PriceDeltaCents: 300},
+				{ID: "m8", Name: "Ovo", PriceDeltaCents: 250},
+			}},
+		},
+	}
+	combo := &Product{
+		Name:       "Combo",
+		PriceCents: 3000,
+		ModifierGroups: []ModifierGroup{
+			{ID: "g4", Name: "Bebida", Kind: ModifierChoice, MinSelect: 1, MaxSelect: 1, Options: []Modifier{
+				{ID: "m9", Name: "Refrigerante"},
+				{ID: "m10", Name: "Suco", PriceDeltaCents: 300},
+			}},
+		},
+	}
+	water := func(delta int64) *Product {
+		return &Product{
+			Name:       "Água",
+			PriceCents: 300,
+			ModifierGroups: []ModifierGroup{
+				{ID: "g5", Name: "Tamanho", Kind: ModifierChoice, MinSelect: 0, MaxSelect: 1, Options: []Modifier{
+					{ID: "m11", Name: "Mini", PriceDeltaCents: delta},
+				}},
+			},
+		}
+	}
+	sel := func(pairs ...string) []ModifierSelection {
+		var out []ModifierSelection
+		for i := 0; i < len(pairs); i += 2 {
+			out = append(out, ModifierSelection{Group: pairs[i], Option: pairs[i+1]})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		product   *Product
+		selected  []ModifierSelection
+		want      []ModifierSelection
+		wantDelta int64
+		wantErr   bool
+	}{
+		{"sem escolha fica o padrão", burger, nil, sel("Pão", "Brioche"), 0, false},
+		{"escolha substitui o padrão", burger, sel("pão", " AUSTRALIANO "), sel("Pão", "Australiano"), 200, false},
+		{
+			"ordem do cardápio",
+			burger, sel("Adicionais", "Queijo", "Retirar", "Cebola", "Adicionais", "Bacon"),
+			sel("Pão", "Brioche", "Retirar", "Cebola", "Adicionais", "Bacon", "Adicionais", "Queijo"), 800, false,
+		},
+		{
+			"até o máximo do grupo",
+			burger, sel("Retirar", "Cebola", "Retirar", "Picles", "Retirar", "Tomate"),
+			sel("Pão", "Brioche", "Retirar", "Cebola", "Retirar", "Picles", "Retirar", "Tomate"), 0, false,
+		},
+		{"acima do máximo", burger, sel("Adicionais", "Bacon", "Adicionais", "Queijo", "Adicionais", "Ovo"), nil, 0, true},
+		{"duas variações no grupo de escolha única", burger, sel("Pão", "Brioche", "Pão", "Australiano"), nil, 0, true},
+		{"opção repetida", burger, sel("Adicionais", "Bacon", "adicionais", "BACON"), nil, 0, true},
+		{"grupo inexistente", burger, sel("Molhos", "Barbecue"), nil, 0, true},
+		{"opção inexistente", burger, sel("Adicionais", "Cheddar"), nil, 0, true},
+		{"abaixo do mínimo sem padrão", combo, nil, nil, 0, true},
+		{"mínimo atendido", combo, sel("Bebida", "Suco"), sel("Bebida", "Suco"), 300, false},
+		{"desconto até o preço do produto", water(-300), sel("Tamanho", "Mini"), sel("Tamanho", "Mini"), -300, false},
+		{"preço final negativo", water(-400), sel("Tamanho", "Mini"), nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		mods, delta, err := resolveModifiers(tt.product, tt.selected)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidModifier) {
+				t.Errorf("%s: erro %v, esperado ErrInvalidModifier", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: erro inesperado %v", tt.name, err)
+			continue
+		}
+		if got := modifierSelections(mods); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: opções %v, esperado %v", tt.name, got, tt.want)
+		}
+		if delta != tt.wantDelta {
+			t.Errorf("%s: acréscimo %d, esperado %d", tt.name, delta, tt.wantDelta)
+		}
+
+		// Cada opção leva o tipo e o acréscimo do cardápio
+		var sum int64
+		for _, m := range mods {
+			if m.modifierID == "" || m.Kind == "" {
+				t.Errorf("%s: opção %s sem id ou tipo do cardápio", tt.name, m.Option)
+			}
+			sum += m.PriceDeltaCents
+		}
+		if sum != delta {
+			t.Errorf("%s: opções somam %d, acréscimo %d", tt.name, sum, delta)
+		}
+	}
+}
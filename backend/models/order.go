@@ -3,119 +3,444 @@ package models
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"finplay/backend/config"
+	"finplay/backend/pricing"
+	"sort"
 	"time"
 )
 
+// Status do pedido. As transições entre authorized, paid e refunded são
+// dirigidas pelos pagamentos (ver payment.go).
+const (
+	OrderPending    = "pending"    // criado, aguardando pagamento
+	OrderAuthorized = "authorized" // pagamento autorizado, aguardando captura
+	OrderPaid       = "paid"       // pagamento capturado
+	OrderCompleted  = "completed"  // pago e finalizado
+	OrderCancelled  = "cancelled"
+	OrderRefunded   = "refunded"
+)
+
+var (
+	ErrInvalidQuantity = errors.New("quantidade inválida")
+	ErrOrderNotPaid    = errors.New("pedido ainda não foi pago")
+)
+
+var deliveryConfig = config.Default().Delivery
+
+// Aplicar a configuração carregada
+func ConfigureDelivery(cfg config.DeliveryConfig) {
+	deliveryConfig = cfg
+}
+
 type Order struct {
-	ID          string      `json:"id"`
-	UserID      string      `json:"user_id"`
-	Status      string      `json:"status"`
-	TotalItems  int         `json:"total_items"`
-	CreatedAt   time.Time   `json:"created_at"`
-	CompletedAt *time.Time  `json:"completed_at,omitempty"`
-	Notes       string      `json:"notes,omitempty"`
-	Items       []OrderItem `json:"items"`
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	Status     string `json:"status"`
+	TotalItems int    `json:"total_items"`
+	// total_cents = subtotal_cents - discount_cents + tax_cents +
+	// delivery_fee_cents (valor cobrado)
+	SubtotalCents    int64 `json:"subtotal_cents"`
+	DiscountCents    int64 `json:"discount_cents"`
+	TaxCents         int64 `json:"tax_cents"`
+	DeliveryFeeCents int64 `json:"delivery_fee_cents"`
+	TotalCents       int64 `json:"total_cents"`
+	// Tributos já embutidos nos preços (informativo, fora de tax_cents)
+	IncludedTaxCents int64           `json:"included_tax_cents"`
+	Taxes            []OrderTax      `json:"taxes"`
+	CouponCode       string          `json:"coupon_code,omitempty"`
+	Discounts        []OrderDiscount `json:"discounts"`
+	// Pontos de fidelidade resgatados e o desconto correspondente (já somado
+	// em discount_cents)
+	LoyaltyPoints        int         `json:"loyalty_points,omitempty"`
+	LoyaltyDiscountCents int64       `json:"loyalty_discount_cents,omitempty"`
+	CreatedAt            time.Time   `json:"created_at"`
+	CompletedAt          *time.Time  `json:"completed_at,omitempty"`
+	Notes                string      `json:"notes,omitempty"`
+	Items                []OrderItem `json:"items"`
 }
 
 type OrderItem struct {
-	ID              string  `json:"id"`
-	OrderID         string  `json:"order_id"`
-	ProductName     string  `json:"product_name"`
-	ProductCategory string  `json:"product_category"`
-	Quantity        int     `json:"quantity"`
-	Price           float64 `json:"price,omitempty"`
-	Ingredients     string  `json:"ingredients,omitempty"`
+	ID              string `json:"id"`
+	OrderID         string `json:"order_id"`
+	ProductName     string `json:"product_name"`
+	ProductCategory string `json:"product_category"`
+	Quantity        int    `json:"quantity"`
+	// Unidades canceladas depois do pedido (ver refund.go)
+	CancelledQuantity int `json:"cancelled_quantity,omitempty"`
+	// Desconto de promoções rateado para a linha (todas as unidades)
+	DiscountCents int64 `json:"discount_cents,omitempty"`
+	// Tributos da linha (todas as unidades): somados ao preço e embutidos nele
+	TaxCents         int64 `json:"tax_cents,omitempty"`
+	IncludedTaxCents int64 `json:"included_tax_cents,omitempty"`
+	// Preço unitário já com os acréscimos das opções
+	Price float64 `json:"price,omitempty"`
+	// Opções escolhidas (grupo e opção); na resposta, na ordem do cardápio e
+	// com o acréscimo de cada uma
+	Modifiers []OrderItemModifier `json:"modifiers,omitempty"`
+	// Observação livre do item para a cozinha (as opções do cardápio vão em
+	// modifiers)
+	Ingredients string `json:"ingredients,omitempty"`
+}
+
+// Pedido com pagamentos e histórico de estornos (tela de detalhe)
+type OrderDetail struct {
+	Order
+	RefundedCents int64     `json:"refunded_cents"`
+	Payments      []Payment `json:"payments"`
+	Refunds       []Refund  `json:"refunds"`
 }
 
 type CreateOrderRequest struct {
-	Items []OrderItem `json:"items"`
-	Notes string      `json:"notes"`
+	Items      []OrderItem `json:"items"`
+	Notes      string      `json:"notes"`
+	CouponCode string      `json:"coupon_code"`
+	// Pontos de fidelidade a resgatar (limitados à parte máxima do total)
+	RedeemPoints int `json:"redeem_points"`
+	// Entrega (cobra a taxa de entrega configurada)
+	Delivery bool `json:"delivery"`
 }
 
-// Criar novo pedido
-func CreateOrder(db *sql.DB, userID string, items []OrderItem, notes string) (*Order, error) {
+// Valor de um pedido calculado com o cardápio e as regras vigentes
+type orderQuote struct {
+	lines       []pricing.Line
+	modifiers   [][]OrderItemModifier // por linha
+	priced      pricing.Result
+	coupon      string // código do cupom como cadastrado
+	points      int
+	pointsCents int64
+}
+
+// Precificar um pedido sem gravá-lo. Os preços vêm do cardápio (products),
+// somados aos acréscimos das opções escolhidas; as promoções vigentes, o
+// cupom e os pontos resgatados são aplicados nessa ordem, seguidos dos
+// tributos da jurisdição da loja e da taxa de entrega. Com readOnly
+// (simulação do carrinho) os limites das promoções e o saldo de pontos são
+// conferidos sem bloquear linhas.
+func priceOrder(tx *sql.Tx, userID string, req CreateOrderRequest, now time.Time, readOnly bool) (*orderQuote, error) {
+	q := &orderQuote{
+		lines:     make([]pricing.Line, len(req.Items)),
+		modifiers: make([][]OrderItemModifier, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		if item.Quantity < 1 {
+			return nil, ErrInvalidQuantity
+		}
+		product, err := GetProduct(tx, item.ProductCategory, item.ProductName)
+		if err != nil {
+			return nil, err
+		}
+		mods, delta, err := resolveModifiers(product, modifierSelections(item.Modifiers))
+		if err != nil {
+			return nil, err
+		}
+		q.modifiers[i] = mods
+		q.lines[i] = pricing.Line{
+			Category:    product.Category,
+			Product:     product.Name,
+			TaxCategory: product.TaxCategory,
+			UnitCents:   product.PriceCents + delta,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	// Aplicar promoções
+	promotions, err := orderPromotions(tx, userID, req.CouponCode, now.In(promotionsLocation), readOnly)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]pricing.Promotion, len(promotions))
+	for i := range promotions {
+		rules[i] = promotions[i].Rule()
+	}
+	q.priced = pricing.Apply(q.lines, rules)
+
+	if req.CouponCode != "" {
+		q.coupon = promotions[len(promotions)-1].Code
+		applied := false
+		for _, d := range q.priced.Discounts {
+			applied = applied || d.Promotion.Code == q.coupon
+		}
+		if !applied {
+			return nil, ErrCouponNotApplicable
+		}
+	}
+
+	// Resgatar pontos sobre o total já com as promoções
+	q.points, q.pointsCents, err = redeemLoyaltyPoints(tx, userID, req.RedeemPoints, q.priced.TotalCents, now, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	q.priced.Deduct(q.pointsCents)
+
+	// Tributos sobre o valor já com os descontos
+	taxRules, err := activeTaxRules(tx)
+	if err != nil {
+		return nil, err
+	}
+	q.priced.ApplyTaxes(q.lines, taxRules, taxesConfig.Jurisdiction)
+
+	if req.Delivery && (deliveryConfig.FreeAboveCents == 0 || q.priced.SubtotalCents-q.priced.DiscountCents < int64(deliveryConfig.FreeAboveCents)) {
+		q.priced.AddDeliveryFee(int64(deliveryConfig.FeeCents))
+	}
+	return q, nil
+}
+
+// Criar novo pedido com o valor de priceOrder. O total é o valor cobrado no
+// pagamento.
+func CreateOrder(db *sql.DB, userID string, req CreateOrderRequest) (*Order, error) {
 	tx, err := db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	order, err := createOrder(tx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func createOrder(tx *sql.Tx, userID string, req CreateOrderRequest) (*Order, error) {
+	now := time.Now()
+	q, err := priceOrder(tx, userID, req, now, false)
+	if err != nil {
+		return nil, err
+	}
+
 	// Inserir pedido
 	var order Order
 	query := `
-		INSERT INTO orders (user_id, status, total_items, notes)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO orders (user_id, status, total_items, subtotal_cents, discount_cents, tax_cents,
+			included_tax_cents, delivery_fee_cents, total_cents, coupon_code, loyalty_points,
+			loyalty_discount_cents, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12, $13)
 		RETURNING id, user_id, status, total_items, created_at, notes
 	`
-	err = tx.QueryRow(query, userID, "pending", len(items), notes).Scan(
-		&order.ID, &order.UserID, &order.Status, &order.TotalItems,
-		&order.CreatedAt, &order.Notes,
+	err = tx.QueryRow(query, userID, OrderPending, len(req.Items),
+		q.priced.SubtotalCents, q.priced.DiscountCents, q.priced.TaxCents, q.priced.IncludedTaxCents,
+		q.priced.DeliveryFeeCents, q.priced.TotalCents, q.coupon, q.points, q.pointsCents, req.Notes,
+	).Scan(
+		&order.ID, &order.UserID, &order.Status, &order.TotalItems, &order.CreatedAt, &order.Notes,
 	)
 	if err != nil {
 		return nil, err
 	}
+	order.SubtotalCents = q.priced.SubtotalCents
+	order.DiscountCents = q.priced.DiscountCents
+	order.TaxCents = q.priced.TaxCents
+	order.IncludedTaxCents = q.priced.IncludedTaxCents
+	order.DeliveryFeeCents = q.priced.DeliveryFeeCents
+	order.TotalCents = q.priced.TotalCents
+	order.Taxes = orderTaxes(q.priced.Taxes)
+	order.CouponCode = q.coupon
+	order.LoyaltyPoints = q.points
+	order.LoyaltyDiscountCents = q.pointsCents
+
+	if _, err := debitLoyaltyPoints(tx, userID, order.ID, LoyaltyRedeem, q.points, now); err != nil {
+		return nil, err
+	}
+
+	order.Discounts, err = insertRedemptions(tx, order.ID, userID, q.priced.Discounts)
+	if err != nil {
+		return nil, err
+	}
 
 	// Inserir itens do pedido
 	itemQuery := `
-		INSERT INTO order_items (order_id, product_name, product_category, quantity, ingredients)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO order_items (order_id, product_name, product_category, quantity, price, discount_cents,
+			tax_cents, included_tax_cents, ingredients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
 
-	order.Items = make([]OrderItem, 0, len(items))
-	for _, item := range items {
+	order.Items = make([]OrderItem, 0, len(req.Items))
+	for i, item := range req.Items {
 		var itemID string
-		ingredientsJSON, _ := json.Marshal(item.Ingredients)
+		item.Modifiers = q.modifiers[i]
+		item.Price = float64(q.lines[i].UnitCents) / 100
+		item.DiscountCents = q.priced.LineDiscounts[i]
+		item.TaxCents, item.IncludedTaxCents = q.priced.LineTaxCents(i)
 
 		err = tx.QueryRow(itemQuery,
-			order.ID, item.ProductName, item.ProductCategory,
-			item.Quantity, string(ingredientsJSON),
+			order.ID, item.ProductName, item.ProductCategory, item.Quantity, item.Price,
+			item.DiscountCents, item.TaxCents, item.IncludedTaxCents, item.Ingredients,
 		).Scan(&itemID)
 
 		if err != nil {
 			return nil, err
 		}
+		if err := insertItemTaxes(tx, order.ID, itemID, q.priced.LineTaxes[i]); err != nil {
+			return nil, err
+		}
+		if err := insertItemModifiers(tx, order.ID, itemID, item.Modifiers); err != nil {
+			return nil, err
+		}
 
 		item.ID = itemID
 		item.OrderID = order.ID
 		order.Items = append(order.Items, item)
 	}
 
-	if err = tx.Commit(); err != nil {
+	return &order, nil
+}
+
+// Tributos calculados no formato do pedido (mesma ordem de GetOrderTaxes)
+func orderTaxes(taxes []pricing.Tax) []OrderTax {
+	out := make([]OrderTax, len(taxes))
+	for i, t := range taxes {
+		out[i] = OrderTax{
+			Name:         t.Rule.Name,
+			Jurisdiction: t.Rule.Jurisdiction,
+			RateBps:      t.Rule.RateBps,
+			Inclusive:    t.Rule.Inclusive,
+			BaseCents:    t.BaseCents,
+			AmountCents:  t.AmountCents,
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].Inclusive != out[b].Inclusive {
+			return !out[a].Inclusive
+		}
+		if out[a].Name != out[b].Name {
+			return out[a].Name < out[b].Name
+		}
+		return out[a].RateBps < out[b].RateBps
+	})
+	return out
+}
+
+const orderColumns = `
+	o.id, o.user_id, o.status, o.total_items, o.subtotal_cents, o.discount_cents, o.tax_cents,
+	o.included_tax_cents, o.delivery_fee_cents, o.total_cents,
+	COALESCE(o.coupon_code, ''), o.loyalty_points, o.loyalty_discount_cents,
+	o.created_at, o.completed_at, COALESCE(o.notes, '')
+`
+
+func (o *Order) scanDest() []any {
+	return []any{
+		&o.ID, &o.UserID, &o.Status, &o.TotalItems, &o.SubtotalCents, &o.DiscountCents, &o.TaxCents,
+		&o.IncludedTaxCents, &o.DeliveryFeeCents, &o.TotalCents,
+		&o.CouponCode, &o.LoyaltyPoints, &o.LoyaltyDiscountCents,
+		&o.CreatedAt, &o.CompletedAt, &o.Notes,
+	}
+}
+
+// Carregar itens, descontos e tributos do pedido
+func loadOrderLines(db *sql.DB, order *Order) error {
+	var err error
+	order.Items, err = GetOrderItems(db, order.ID)
+	if err != nil {
+		return err
+	}
+	order.Discounts, err = GetOrderDiscounts(db, order.ID)
+	if err != nil {
+		return err
+	}
+	order.Taxes, err = GetOrderTaxes(db, order.ID)
+	return err
+}
+
+// Buscar pedido do usuário com itens
+func GetOrderByID(db *sql.DB, orderID, userID string) (*Order, error) {
+	return getOrder(db, `o.id = $1 AND o.user_id = $2`, orderID, userID)
+}
+
+// Buscar pedido com itens de qualquer usuário (rotas da equipe da loja)
+func GetOrderForStaff(db *sql.DB, orderID string) (*Order, error) {
+	return getOrder(db, `o.id = $1`, orderID)
+}
+
+func getOrder(db *sql.DB, where string, args ...interface{}) (*Order, error) {
+	var order Order
+	err := db.QueryRow(`
+		SELECT `+orderColumns+`
+		FROM orders o
+		WHERE `+where, args...).Scan(order.scanDest()...)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := loadOrderLines(db, &order); err != nil {
+		return nil, err
+	}
 	return &order, nil
 }
 
-// Finalizar pedido
-func CompleteOrder(db *sql.DB, orderID, userID string) error {
-	query := `
-		UPDATE orders
-		SET status = 'completed', completed_at = $1
-		WHERE id = $2 AND user_id = $3 AND status = 'pending'
-	`
-	result, err := db.Exec(query, time.Now(), orderID, userID)
+// Buscar pedido do usuário com pagamentos e estornos
+func GetOrderDetail(db *sql.DB, orderID, userID string) (*OrderDetail, error) {
+	order, err := GetOrderByID(db, orderID, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	rows, err := result.RowsAffected()
+	detail := &OrderDetail{Order: *order}
+	detail.Payments, err = GetOrderPayments(db, orderID, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+	detail.Refunds, err = GetOrderRefunds(db, orderID)
+	if err != nil {
+		return nil, err
 	}
+	for _, r := range detail.Refunds {
+		detail.RefundedCents += r.AmountCents
+	}
+	return detail, nil
+}
 
-	return nil
+// Total atual do pedido (muda quando itens são cancelados)
+func GetOrderTotal(db *sql.DB, orderID string) (int64, error) {
+	var total int64
+	err := db.QueryRow(`SELECT total_cents FROM orders WHERE id = $1`, orderID).Scan(&total)
+	return total, err
+}
+
+// Finalizar pedido (apenas se já pago), creditando os pontos de fidelidade.
+// Confirmação da entrega pela equipe da loja: o pedido não é filtrado pelo
+// usuário. Retorna o dono do pedido.
+func CompleteOrder(db *sql.DB, orderID string) (string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		UPDATE orders
+		SET status = $1, completed_at = $2
+		WHERE id = $3 AND status = $4
+		RETURNING user_id
+	`
+	var userID string
+	err = tx.QueryRow(query, OrderCompleted, now, orderID, OrderPaid).Scan(&userID)
+	if err == sql.ErrNoRows {
+		// Distinguir pedido inexistente de pedido ainda não pago
+		var status string
+		if err := db.QueryRow(`SELECT status FROM orders WHERE id = $1`, orderID).Scan(&status); err != nil {
+			return "", err
+		}
+		return "", ErrOrderNotPaid
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := accrueLoyaltyPoints(tx, orderID, userID, now); err != nil {
+		return "", err
+	}
+	return userID, tx.Commit()
 }
 
 // Buscar histórico de pedidos do usuário
 func GetUserOrderHistory(db *sql.DB, userID string) ([]Order, error) {
 	query := `
-		SELECT o.id, o.user_id, o.status, o.total_items, o.created_at, o.completed_at, o.notes
+		SELECT ` + orderColumns + `
 		FROM orders o
 		WHERE o.user_id = $1 AND o.status = 'completed'
 		ORDER BY o.completed_at DESC
@@ -130,20 +455,14 @@ func GetUserOrderHistory(db *sql.DB, userID string) ([]Order, error) {
 	var orders []Order
 	for rows.Next() {
 		var order Order
-		err := rows.Scan(
-			&order.ID, &order.UserID, &order.Status, &order.TotalItems,
-			&order.CreatedAt, &order.CompletedAt, &order.Notes,
-		)
-		if err != nil {
+		if err := rows.Scan(order.scanDest()...); err != nil {
 			return nil, err
 		}
 
-		// Buscar itens do pedido
-		items, err := GetOrderItems(db, order.ID)
-		if err != nil {
+		// Buscar itens e descontos do pedido
+		if err := loadOrderLines(db, &order); err != nil {
 			return nil, err
 		}
-		order.Items = items
 
 		orders = append(orders, order)
 	}
@@ -151,14 +470,20 @@ func GetUserOrderHistory(db *sql.DB, userID string) ([]Order, error) {
 	return orders, nil
 }
 
-// Buscar itens de um pedido
+// Buscar itens de um pedido com as opções escolhidas
 func GetOrderItems(db *sql.DB, orderID string) ([]OrderItem, error) {
 	query := `
-		SELECT id, order_id, product_name, product_category, quantity, ingredients
+		SELECT id, order_id, product_name, product_category, quantity, cancelled_quantity,
+			discount_cents, tax_cents, included_tax_cents, COALESCE(price, 0), COALESCE(ingredients, '')
 		FROM order_items
 		WHERE order_id = $1
 	`
 
+	mods, err := getOrderItemModifiers(db, orderID)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := db.Query(query, orderID)
 	if err != nil {
 		return nil, err
@@ -170,25 +495,34 @@ func GetOrderItems(db *sql.DB, orderID string) ([]OrderItem, error) {
 		var item OrderItem
 		err := rows.Scan(
 			&item.ID, &item.OrderID, &item.ProductName,
-			&item.ProductCategory, &item.Quantity, &item.Ingredients,
+			&item.ProductCategory, &item.Quantity, &item.CancelledQuantity,
+			&item.DiscountCents, &item.TaxCents, &item.IncludedTaxCents, &item.Price, &item.Ingredients,
 		)
 		if err != nil {
 			return nil, err
 		}
+		item.Modifiers = mods[item.ID]
 		items = append(items, item)
 	}
 
-	return items, nil
+	return items, rows.Err()
 }
 
-// Cancelar pedido (apenas se pending)
+// Cancelar pedido (apenas se pending; autorizações são liberadas antes pelo
+// pacote payments). Cobranças PIX pendentes são encerradas junto.
 func CancelOrder(db *sql.DB, orderID, userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE orders
-		SET status = 'cancelled'
-		WHERE id = $1 AND user_id = $2 AND status = 'pending'
+		SET status = $1
+		WHERE id = $2 AND user_id = $3 AND status = $4
 	`
-	result, err := db.Exec(query, orderID, userID)
+	result, err := tx.Exec(query, OrderCancelled, orderID, userID, OrderPending)
 	if err != nil {
 		return err
 	}
@@ -201,5 +535,16 @@ func CancelOrder(db *sql.DB, orderID, userID string) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	_, err = tx.Exec(`
+		UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2 AND status = $3
+	`, PaymentVoided, orderID, PaymentPending)
+	if err != nil {
+		return err
+	}
+
+	if err := restoreLoyaltyPoints(tx, orderID, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
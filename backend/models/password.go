@@ -0,0 +1,405 @@
+// Arquivo: backend/models/password.go
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"database/sql"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"finplay/backend/config"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Política de senha (configurável via config.PasswordConfig)
+type PasswordPolicy struct {
+	MinLength     int  // mínimo de caracteres (runas, não bytes)
+	MaxLength     int  // bcrypt ignora tudo após 72 bytes
+	MinScore      int  // pontuação mínima de força (0 a 4)
+	CheckBreached bool // rejeitar senhas da lista offline de senhas vazadas
+	BcryptCost    int
+}
+
+// Resultado da estimativa de força (escala 0-4 no estilo zxcvbn)
+type PasswordStrength struct {
+	Score    int      `json:"score"`
+	Entropy  float64  `json:"entropy_bits"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     8,
+	MaxLength:     72,
+	MinScore:      2,
+	CheckBreached: true,
+	BcryptCost:    bcrypt.DefaultCost,
+}
+
+var passwordPolicy = DefaultPasswordPolicy
+
+// Aplicar a configuração carregada (validada em config.Validate)
+func ConfigurePasswordPolicy(cfg config.PasswordConfig) {
+	policy := DefaultPasswordPolicy
+	policy.MinLength = cfg.MinLength
+	policy.MinScore = cfg.MinScore
+	policy.CheckBreached = cfg.CheckBreached
+	policy.BcryptCost = cfg.BcryptCost
+	passwordPolicy = policy
+}
+
+// Política atual
+func CurrentPasswordPolicy() PasswordPolicy {
+	return passwordPolicy
+}
+
+// Validar senha contra a política atual. userInputs (email, nome...) são
+// tratados como palavras de dicionário na estimativa de força.
+func (p PasswordPolicy) Validate(password string, userInputs ...string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: deve ter no mínimo %d caracteres", ErrPasswordRejected, p.MinLength)
+	}
+	if len(password) > p.MaxLength {
+		return fmt.Errorf("%w: deve ter no máximo %d bytes", ErrPasswordRejected, p.MaxLength)
+	}
+
+	if p.CheckBreached {
+		breached, err := IsBreachedPassword(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return fmt.Errorf("%w: aparece em listas de senhas vazadas. Escolha outra", ErrPasswordRejected)
+		}
+	}
+
+	strength := EstimatePasswordStrength(password, userInputs...)
+	if strength.Score < p.MinScore {
+		if len(strength.Warnings) > 0 {
+			return fmt.Errorf("%w: muito fraca: %s", ErrPasswordRejected, strength.Warnings[0])
+		}
+		return fmt.Errorf("%w: muito fraca", ErrPasswordRejected)
+	}
+
+	return nil
+}
+
+// Precisa refazer o hash? (custo do bcrypt mudou desde o cadastro)
+func PasswordNeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return cost != CurrentPasswordPolicy().BcryptCost
+}
+
+// Atualizar hash da senha
+func UpdatePasswordHash(db *sql.DB, userID, hash string) error {
+	query := `UPDATE users SET password_hash = $1 WHERE id = $2`
+	_, err := db.Exec(query, hash, userID)
+	return err
+}
+
+// Trocar senha validando a senha atual e a política. Incrementa a versão de
+// token do usuário, revogando as sessões abertas; user.TokenVersion recebe a
+// nova versão para emitir o token da sessão atual.
+func ChangePassword(db *sql.DB, user *User, currentPassword, newPassword string) error {
+	if !CheckPassword(currentPassword, user.PasswordHash) {
+		return ErrInvalidCurrentPassword
+	}
+	if currentPassword == newPassword {
+		return ErrSamePassword
+	}
+	return SetPassword(db, user, newPassword)
+}
+
+// Definir a senha sem conferir a atual, para quem já se reautenticou por
+// outro meio (ex.: contas do login social, que não têm senha). Mesmos efeitos
+// de ChangePassword.
+func SetPassword(db *sql.DB, user *User, newPassword string) error {
+	if err := ValidatePassword(newPassword, user.Email, user.FullName); err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1
+		WHERE id = $2
+		RETURNING token_version
+	`
+	return db.QueryRow(query, hash, user.ID).Scan(&user.TokenVersion)
+}
+
+var (
+	ErrInvalidCurrentPassword = errors.New("senha atual incorreta")
+	ErrSamePassword           = errors.New("a nova senha deve ser diferente da atual")
+	// Senha recusada pela política (tamanho, força ou lista de vazadas)
+	ErrPasswordRejected = errors.New("senha não aceita")
+)
+
+// ---------------------------------------------------------------------------
+// Estimativa de força
+// ---------------------------------------------------------------------------
+
+// Sequências de teclado/alfabeto usadas na detecção de padrões
+var passwordSequences = []string{
+	"abcdefghijklmnopqrstuvwxyz",
+	"01234567890",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+	"1qaz2wsx3edc",
+}
+
+// Palavras comuns em senhas brasileiras (além das entradas do usuário)
+var passwordDictionary = []string{
+	"senha", "password", "pass", "admin", "amor", "brasil", "futebol",
+	"flamengo", "corinthians", "palmeiras", "santos", "gremio", "jesus",
+	"deus", "princesa", "mudar", "teste", "qwerty", "finplay", "login",
+	"welcome", "bemvindo", "hamburguer", "pudim", "dragon", "monkey",
+}
+
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// Estimar força da senha: entropia pelo alfabeto usado, descontando
+// repetições, sequências e palavras de dicionário
+func EstimatePasswordStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return PasswordStrength{Score: 0, Warnings: []string{"senha vazia"}}
+	}
+
+	var warnings []string
+	penalty := 0
+
+	if n := repeatPenalty(runes); n > 0 {
+		penalty += n
+		warnings = append(warnings, "evite caracteres repetidos como 'aaa'")
+	}
+	if n := sequencePenalty(strings.ToLower(password)); n > 0 {
+		penalty += n
+		warnings = append(warnings, "evite sequências como 'abc', '123' ou 'qwerty'")
+	}
+	if n := dictionaryPenalty(password, userInputs); n > 0 {
+		penalty += n
+		warnings = append(warnings, "evite palavras comuns, seu nome ou seu email")
+	}
+
+	effective := len(runes) - penalty
+	if effective < 1 {
+		effective = 1
+	}
+
+	entropy := float64(effective) * math.Log2(float64(charsetSize(runes)))
+	entropy = math.Round(entropy*10) / 10
+
+	score := 0
+	switch {
+	case entropy >= 70:
+		score = 4
+	case entropy >= 55:
+		score = 3
+	case entropy >= 40:
+		score = 2
+	case entropy >= 25:
+		score = 1
+	}
+
+	if score < 3 && charsetSize(runes) <= 26 {
+		warnings = append(warnings, "misture letras maiúsculas, números e símbolos")
+	}
+
+	return PasswordStrength{Score: score, Entropy: entropy, Warnings: warnings}
+}
+
+func charsetSize(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	size := 0
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if symbol {
+		size += 33
+	}
+	if other {
+		size += 100 // acentos e demais caracteres unicode
+	}
+	return size
+}
+
+// Trechos com 3+ caracteres iguais contam como um só
+func repeatPenalty(runes []rune) int {
+	penalty := 0
+	run := 1
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && runes[i] == runes[i-1] {
+			run++
+			continue
+		}
+		if run >= 3 {
+			penalty += run - 1
+		}
+		run = 1
+	}
+	return penalty
+}
+
+// Trechos de 3+ caracteres em sequência (direta ou inversa) contam como um só
+func sequencePenalty(lower string) int {
+	penalty := 0
+	for i := 0; i < len(lower); {
+		longest := 0
+		for _, seq := range passwordSequences {
+			for _, s := range []string{seq, reverseString(seq)} {
+				if idx := strings.IndexByte(s, lower[i]); idx >= 0 {
+					if n := commonPrefixLen(lower[i:], s[idx:]); n > longest {
+						longest = n
+					}
+				}
+			}
+		}
+		if longest >= 3 {
+			penalty += longest - 1
+			i += longest
+		} else {
+			i++
+		}
+	}
+	return penalty
+}
+
+// Palavras de dicionário (com substituições leet) contam como um caractere
+func dictionaryPenalty(password string, userInputs []string) int {
+	normalized := leetReplacer.Replace(strings.ToLower(password))
+
+	words := append([]string{}, passwordDictionary...)
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if at := strings.IndexByte(input, '@'); at >= 0 {
+			input = input[:at]
+		}
+		for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if utf8.RuneCountInString(part) >= 3 {
+				words = append(words, part)
+			}
+		}
+	}
+
+	penalty := 0
+	for _, word := range words {
+		if strings.Contains(normalized, word) {
+			penalty += utf8.RuneCountInString(word) - 1
+			normalized = strings.Replace(normalized, word, "", 1)
+		}
+	}
+	return penalty
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// ---------------------------------------------------------------------------
+// Senhas vazadas (consulta por prefixo de hash, estilo k-anonimato)
+// ---------------------------------------------------------------------------
+
+// Fonte de hashes de senhas vazadas consultada por prefixo SHA-1 de 5
+// caracteres. A implementação padrão usa a lista offline embutida; uma
+// implementação remota (ex.: API Pwned Passwords) recebe só o prefixo.
+type BreachedPasswordSource interface {
+	Range(prefix string) ([]string, error)
+}
+
+//go:embed data/breached_passwords.txt
+var breachedPasswordsFile []byte
+
+type offlineBreachedPasswords struct {
+	ranges map[string][]string
+}
+
+func (o *offlineBreachedPasswords) Range(prefix string) ([]string, error) {
+	return o.ranges[prefix], nil
+}
+
+func loadOfflineBreachedPasswords(data []byte) *offlineBreachedPasswords {
+	o := &offlineBreachedPasswords{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, suffix, ok := strings.Cut(line, ":")
+		if !ok || len(prefix) != 5 {
+			continue
+		}
+		o.ranges[prefix] = append(o.ranges[prefix], suffix)
+	}
+	return o
+}
+
+var BreachedPasswords BreachedPasswordSource = loadOfflineBreachedPasswords(breachedPasswordsFile)
+
+// Verificar se a senha está na lista de senhas vazadas
+func IsBreachedPassword(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := BreachedPasswords.Range(hash[:5])
+	if err != nil {
+		return false, err
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[5:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
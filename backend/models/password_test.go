@@ -0,0 +1,136 @@
+// Arquivo: backend/models/password_test.go
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	const (
+		repeated   = "repetidos"
+		sequence   = "sequências"
+		dictionary = "palavras comuns"
+		charset    = "misture"
+	)
+
+	tests := []struct {
+		name         string
+		password     string
+		userInputs   []string
+		wantScore    int
+		wantWarnings []string // trechos das dicas, na ordem
+	}{
+		{"vazia", "", nil, 0, []string{"senha vazia"}},
+		{"caracteres repetidos", "aaaaaaaa", nil, 0, []string{repeated, charset}},
+		{"sequência de letras", "abcdefgh", nil, 0, []string{sequence, charset}},
+		{"sequência de números", "12345678", nil, 0, []string{sequence, charset}},
+		{"teclado", "qwertyuiop", nil, 0, []string{sequence, dictionary, charset}},
+		{"palavra comum com números", "senha123", nil, 0, []string{sequence, dictionary}},
+		{"substituições leet", "Fl4m3ng0!", nil, 0, []string{dictionary}},
+		{"leet com ano", "S3nh@2024", nil, 1, []string{dictionary}},
+		{"letras minúsculas aleatórias", "kdjwqpvn", nil, 1, []string{charset}},
+		{"aleatória curta", "tk9#Vq2!mZ", nil, 3, nil},
+		{"aleatória longa", "xK9#mQ2$vL7@pR4!", nil, 4, nil},
+		{"acentos ampliam o alfabeto", "ação-café-pão", nil, 4, nil},
+		{"nome sem dados do usuário", "joaosilva2024", nil, 3, nil},
+		{"partes do email do usuário", "joaosilva2024", []string{"joao.silva@gmail.com"}, 1, []string{dictionary}},
+		{"frase sem dados do usuário", "Marina#Praia77", nil, 4, nil},
+		{"nome do usuário", "Marina#Praia77", []string{"Marina Souza"}, 3, []string{dictionary}},
+		{"entradas curtas são ignoradas", "Marina#Praia77", []string{"MS", "a@b.co"}, 4, nil},
+	}
+
+	for _, tt := range tests {
+		got := EstimatePasswordStrength(tt.password, tt.userInputs...)
+		if got.Score != tt.wantScore {
+			t.Errorf("%s: pontuação %d (%.1f bits), esperado %d", tt.name, got.Score, got.Entropy, tt.wantScore)
+		}
+		matches := len(got.Warnings) == len(tt.wantWarnings)
+		for i := 0; matches && i < len(got.Warnings); i++ {
+			matches = strings.Contains(got.Warnings[i], tt.wantWarnings[i])
+		}
+		if !matches {
+			t.Errorf("%s: dicas %q, esperado %q", tt.name, got.Warnings, tt.wantWarnings)
+		}
+	}
+
+	// Dados do usuário só diminuem a força
+	base := EstimatePasswordStrength("Marina#Praia77")
+	penalized := EstimatePasswordStrength("Marina#Praia77", "marina@example.com")
+	if penalized.Entropy >= base.Entropy {
+		t.Errorf("entropia com o email %.1f, sem %.1f", penalized.Entropy, base.Entropy)
+	}
+}
+
+// Fonte de senhas vazadas que registra os prefixos consultados
+type recordingBreachedSource struct {
+	source   BreachedPasswordSource
+	err      error
+	prefixes []string
+}
+
+func (s *recordingBreachedSource) Range(prefix string) ([]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.source.Range(prefix)
+}
+
+func TestIsBreachedPassword(t *testing.T) {
+	offline := loadOfflineBreachedPasswords(breachedPasswordsFile)
+	previous := BreachedPasswords
+	defer func() { BreachedPasswords = previous }()
+
+	tests := []struct {
+		name       string
+		password   string
+		wantPrefix string // SHA-1 de 5 caracteres enviado à fonte
+		want       bool
+	}{
+		{"senha vazada", "123456", "7C4A8", true},
+		{"senha brasileira vazada", "senha123", "3DECD", true},
+		{"variação de senha vazada", "123456!Xq", "", false},
+		{"senha não vazada", "xK9#mQ2$vL7@pR4!", "", false},
+		{"maiúsculas contam", "PASSWORD", "", false},
+	}
+
+	for _, tt := range tests {
+		source := &recordingBreachedSource{source: offline}
+		BreachedPasswords = source
+
+		got, err := IsBreachedPassword(tt.password)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: IsBreachedPassword(%q) = %v, esperado %v", tt.name, tt.password, got, tt.want)
+		}
+		// Só o prefixo sai do processo
+		if len(source.prefixes) != 1 || len(source.prefixes[0]) != 5 {
+			t.Errorf("%s: prefixos consultados %v", tt.name, source.prefixes)
+		} else if tt.wantPrefix != "" && source.prefixes[0] != tt.wantPrefix {
+			t.Errorf("%s: prefixo %s, esperado %s", tt.name, source.prefixes[0], tt.wantPrefix)
+		}
+	}
+
+	errSource := errors.New("fonte indisponível")
+	BreachedPasswords = &recordingBreachedSource{source: offline, err: errSource}
+	if _, err := IsBreachedPassword("123456"); !errors.Is(err, errSource) {
+		t.Errorf("erro %v, esperado o da fonte", err)
+	}
+}
+
+func TestLoadOfflineBreachedPasswords(t *testing.T) {
+	data := []byte("# comentário\n\n7C4A8:D09CA3762AF61E59520943DC26494F8941B\ninvalida\n123:ABC\n7C4A8:OUTRO\n")
+	got := loadOfflineBreachedPasswords(data)
+
+	if len(got.ranges) != 1 {
+		t.Errorf("faixas %v, esperado só 7C4A8", got.ranges)
+	}
+	if want := []string{"D09CA3762AF61E59520943DC26494F8941B", "OUTRO"}; !slices.Equal(got.ranges["7C4A8"], want) {
+		t.Errorf("faixa 7C4A8 = %v, esperado %v", got.ranges["7C4A8"], want)
+	}
+}
@@ -0,0 +1,253 @@
+// Arquivo: backend/models/payment.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Status do pagamento
+const (
+	PaymentPending    = "pending" // PIX aguardando transferência
+	PaymentExpired    = "expired" // cobrança PIX vencida sem pagamento
+	PaymentAuthorized = "authorized"
+	PaymentCaptured   = "captured"
+	PaymentRefunded   = "refunded"
+	PaymentVoided     = "voided"
+	PaymentDeclined   = "declined"
+	PaymentMismatch   = "mismatch" // PIX recebido com valor diferente da cobrança
+)
+
+var (
+	ErrOrderNotPayable   = errors.New("pedido não está aguardando pagamento")
+	ErrPaymentState      = errors.New("operação não permitida no status atual do pagamento")
+	ErrOrderState        = errors.New("operação não permitida no status atual do pedido")
+	ErrPaymentInProgress = errors.New("já existe um pagamento em andamento para o pedido")
+)
+
+// Tentativa de pagamento de um pedido. Valores em centavos.
+type Payment struct {
+	ID            string    `json:"id"`
+	OrderID       string    `json:"order_id"`
+	UserID        string    `json:"user_id"`
+	Gateway       string    `json:"gateway"`
+	GatewayRef    string    `json:"gateway_ref,omitempty"`
+	Status        string    `json:"status"`
+	AmountCents   int64     `json:"amount_cents"`
+	CapturedCents int64     `json:"captured_cents"`
+	RefundedCents int64     `json:"refunded_cents"`
+	Currency      string    `json:"currency"`
+	DeclineCode   string    `json:"decline_code,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+const paymentColumns = `
+	id, order_id, user_id, gateway, COALESCE(gateway_ref, ''), status,
+	amount_cents, captured_cents, refunded_cents, currency,
+	COALESCE(decline_code, ''), created_at, updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	var p Payment
+	err := row.Scan(
+		&p.ID, &p.OrderID, &p.UserID, &p.Gateway, &p.GatewayRef, &p.Status,
+		&p.AmountCents, &p.CapturedCents, &p.RefundedCents, &p.Currency,
+		&p.DeclineCode, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Registrar o resultado de uma autorização. Se aprovada, o pedido passa de
+// pending para authorized na mesma transação; ErrOrderNotPayable indica que
+// outro pagamento chegou antes (a autorização deve ser liberada no gateway).
+func CreatePayment(db *sql.DB, p *Payment) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if p.Status == PaymentAuthorized {
+		err := transitionOrder(tx, p.OrderID, OrderAuthorized, OrderPending)
+		if err == ErrOrderState {
+			return ErrOrderNotPayable
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO payments (order_id, user_id, gateway, gateway_ref, status, amount_cents, currency, decline_code)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''))
+		RETURNING id, created_at, updated_at
+	`, p.OrderID, p.UserID, p.Gateway, p.GatewayRef, p.Status, p.AmountCents, p.Currency, p.DeclineCode,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrPaymentInProgress
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Captura confirmada no gateway: pagamento captured e pedido paid
+func MarkPaymentCaptured(db *sql.DB, p *Payment, amountCents int64) error {
+	return updatePayment(db, p, PaymentCaptured, []string{PaymentAuthorized}, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`UPDATE payments SET captured_cents = $1 WHERE id = $2`, amountCents, p.ID); err != nil {
+			return err
+		}
+		p.CapturedCents = amountCents
+		return transitionOrder(tx, p.OrderID, OrderPaid, OrderAuthorized)
+	})
+}
+
+// Autorização liberada no gateway. O pedido volta para pending (permitindo
+// pagar com outro meio) ou, se cancelOrder, é cancelado.
+func MarkPaymentVoided(db *sql.DB, p *Payment, cancelOrder bool) error {
+	orderStatus := OrderPending
+	if cancelOrder {
+		orderStatus = OrderCancelled
+	}
+	return updatePayment(db, p, PaymentVoided, []string{PaymentAuthorized}, func(tx *sql.Tx) error {
+		return transitionOrder(tx, p.OrderID, orderStatus, OrderAuthorized)
+	})
+}
+
+// Trocar o status do pagamento (se ainda em um dos status esperados) e
+// aplicar o efeito no pedido na mesma transação
+func updatePayment(db *sql.DB, p *Payment, status string, from []string, effect func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE payments
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = ANY($3)
+		RETURNING updated_at
+	`, status, p.ID, pq.Array(from)).Scan(&p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrPaymentState
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := effect(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	p.Status = status
+	return nil
+}
+
+// Mudar o status do pedido se ele estiver em um dos status de origem
+func transitionOrder(tx *sql.Tx, orderID, to string, from ...string) error {
+	result, err := tx.Exec(`
+		UPDATE orders SET status = $1 WHERE id = $2 AND status = ANY($3)
+	`, to, orderID, pq.Array(from))
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOrderState
+	}
+	// Pedido encerrado sem cobrança: os pontos resgatados voltam ao saldo
+	if to == OrderCancelled || to == OrderRefunded {
+		return restoreLoyaltyPoints(tx, orderID, time.Now())
+	}
+	return nil
+}
+
+// Violação de índice único (ex.: segundo pagamento em vigor para o pedido)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Buscar pagamento do usuário
+func GetPayment(db *sql.DB, paymentID, userID string) (*Payment, error) {
+	row := db.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id = $1 AND user_id = $2`, paymentID, userID)
+	return scanPayment(row)
+}
+
+// Pagamento de qualquer usuário (operações da equipe da loja)
+func GetPaymentByID(db *sql.DB, paymentID string) (*Payment, error) {
+	row := db.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id = $1`, paymentID)
+	return scanPayment(row)
+}
+
+// Pagamento autorizado ou capturado do pedido (nil se não houver)
+func GetActiveOrderPayment(db *sql.DB, orderID string) (*Payment, error) {
+	row := db.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE order_id = $1 AND status = ANY($2)
+	`, orderID, pq.Array([]string{PaymentAuthorized, PaymentCaptured}))
+	p, err := scanPayment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// Tentativas de pagamento de um pedido do usuário, da mais recente à mais antiga
+func GetOrderPayments(db *sql.DB, orderID, userID string) ([]Payment, error) {
+	return queryPayments(db, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE order_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+	`, orderID, userID)
+}
+
+// Todos os pagamentos do usuário (exportação de dados)
+func GetUserPayments(db *sql.DB, userID string) ([]Payment, error) {
+	return queryPayments(db, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+}
+
+func queryPayments(db *sql.DB, query string, args ...any) ([]Payment, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []Payment{}
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *p)
+	}
+	return payments, rows.Err()
+}
@@ -0,0 +1,273 @@
+// Arquivo: backend/models/pix.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPixChargeNotFound = errors.New("cobrança PIX não encontrada")
+	ErrPixAmountMismatch = errors.New("valor do PIX recebido difere da cobrança")
+)
+
+// Cobrança PIX de um pedido. O status é o do pagamento associado
+// (pending, expired, captured, mismatch...).
+type PixCharge struct {
+	PaymentID     string     `json:"payment_id"`
+	OrderID       string     `json:"order_id"`
+	TxID          string     `json:"txid"`
+	Payload       string     `json:"copia_e_cola"`
+	AmountCents   int64      `json:"amount_cents"`
+	ReceivedCents int64      `json:"received_cents,omitempty"` // valor transferido
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	EndToEndID    string     `json:"end_to_end_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+const pixChargeColumns = `
+	c.payment_id, p.order_id, c.txid, c.payload, p.amount_cents, COALESCE(c.received_cents, 0),
+	p.status, c.expires_at, c.paid_at, COALESCE(c.end_to_end_id, ''), c.created_at
+`
+
+func scanPixCharge(row rowScanner) (*PixCharge, error) {
+	var c PixCharge
+	err := row.Scan(
+		&c.PaymentID, &c.OrderID, &c.TxID, &c.Payload, &c.AmountCents, &c.ReceivedCents,
+		&c.Status, &c.ExpiresAt, &c.PaidAt, &c.EndToEndID, &c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Registrar a cobrança e o pagamento pendente. O pedido continua pending até
+// o PSP notificar a transferência.
+func CreatePixCharge(db *sql.DB, p *Payment, c *PixCharge) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM orders WHERE id = $1 FOR UPDATE`, p.OrderID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != OrderPending {
+		return ErrOrderNotPayable
+	}
+
+	p.Status = PaymentPending
+	err = tx.QueryRow(`
+		INSERT INTO payments (order_id, user_id, gateway, gateway_ref, status, amount_cents, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, p.OrderID, p.UserID, p.Gateway, p.GatewayRef, p.Status, p.AmountCents, p.Currency,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrPaymentInProgress
+	}
+	if err != nil {
+		return err
+	}
+
+	c.PaymentID = p.ID
+	c.OrderID = p.OrderID
+	c.AmountCents = p.AmountCents
+	c.Status = p.Status
+	err = tx.QueryRow(`
+		INSERT INTO pix_charges (payment_id, txid, payload, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`, c.PaymentID, c.TxID, c.Payload, c.ExpiresAt).Scan(&c.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Cobrança PIX pendente e dentro da validade (nil se não houver)
+func GetActivePixCharge(db *sql.DB, orderID string) (*PixCharge, error) {
+	row := db.QueryRow(`
+		SELECT `+pixChargeColumns+`
+		FROM pix_charges c
+		JOIN payments p ON p.id = c.payment_id
+		WHERE p.order_id = $1 AND p.status = $2 AND c.expires_at > CURRENT_TIMESTAMP
+	`, orderID, PaymentPending)
+	c, err := scanPixCharge(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Cobrança PIX mais recente de um pedido do usuário
+func GetLatestPixCharge(db *sql.DB, orderID, userID string) (*PixCharge, error) {
+	row := db.QueryRow(`
+		SELECT `+pixChargeColumns+`
+		FROM pix_charges c
+		JOIN payments p ON p.id = c.payment_id
+		WHERE p.order_id = $1 AND p.user_id = $2
+		ORDER BY c.created_at DESC
+		LIMIT 1
+	`, orderID, userID)
+	return scanPixCharge(row)
+}
+
+// Cobrança PIX de um pagamento (para devolução ou remoção no PSP)
+func GetPixChargeByPayment(db *sql.DB, paymentID string) (*PixCharge, error) {
+	row := db.QueryRow(`
+		SELECT `+pixChargeColumns+`
+		FROM pix_charges c
+		JOIN payments p ON p.id = c.payment_id
+		WHERE c.payment_id = $1
+	`, paymentID)
+	c, err := scanPixCharge(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrPixChargeNotFound
+	}
+	return c, err
+}
+
+// Cobrança PIX pendente removida no PSP: o pagamento passa a voided e o
+// pedido continua pending (pode ser pago por outro meio) ou, se cancelOrder,
+// é cancelado.
+func MarkPixPaymentVoided(db *sql.DB, p *Payment, cancelOrder bool) error {
+	return updatePayment(db, p, PaymentVoided, []string{PaymentPending}, func(tx *sql.Tx) error {
+		if !cancelOrder {
+			return nil
+		}
+		return transitionOrder(tx, p.OrderID, OrderCancelled, OrderPending)
+	})
+}
+
+// Marcar como expiradas as cobranças vencidas sem pagamento
+func ExpirePixCharges(db *sql.DB) (int64, error) {
+	result, err := db.Exec(`
+		UPDATE payments p
+		SET status = $1, updated_at = CURRENT_TIMESTAMP
+		FROM pix_charges c
+		WHERE c.payment_id = p.id AND p.status = $2 AND c.expires_at <= CURRENT_TIMESTAMP
+	`, PaymentExpired, PaymentPending)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Registrar o PIX recebido: pagamento captured e pedido paid. Notificações
+// repetidas (mesma cobrança já paga) são ignoradas. Um PIX vencido é aceito se
+// o pedido ainda aguarda pagamento; se o pedido foi cancelado, alterado ou
+// pago por outro meio, a transferência é registrada (paid_at, captured_cents) sem mudar
+// o pedido e ErrOrderState sinaliza que o valor precisa ser estornado. Valor
+// diferente do cobrado também é registrado (received_cents, end_to_end_id),
+// com o pagamento em mismatch e o pedido ainda pendente, e retorna
+// ErrPixAmountMismatch.
+func ConfirmPixTransfer(db *sql.DB, txid, endToEndID string, amountCents int64, paidAt time.Time) (*PixCharge, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`
+		SELECT `+pixChargeColumns+`
+		FROM pix_charges c
+		JOIN payments p ON p.id = c.payment_id
+		WHERE c.txid = $1
+		FOR UPDATE
+	`, txid)
+	c, err := scanPixCharge(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrPixChargeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.PaidAt != nil {
+		return c, nil // notificação duplicada
+	}
+
+	_, err = tx.Exec(`
+		UPDATE pix_charges SET paid_at = $1, end_to_end_id = $2, received_cents = $3 WHERE payment_id = $4
+	`, paidAt, endToEndID, amountCents, c.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	c.PaidAt = &paidAt
+	c.EndToEndID = endToEndID
+	c.ReceivedCents = amountCents
+
+	// Valor divergente: o pagamento sai de pending (libera nova cobrança) e o
+	// valor recebido fica registrado para o estorno
+	if amountCents != c.AmountCents {
+		_, err = tx.Exec(`
+			UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		`, PaymentMismatch, c.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		c.Status = PaymentMismatch
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return c, ErrPixAmountMismatch
+	}
+
+	// O pedido só pode ser pago por este PIX se ainda estiver pendente, sem
+	// outro pagamento em vigor e com o mesmo total (itens podem ter sido
+	// cancelados depois da cobrança)
+	var orderStatus string
+	var orderTotal int64
+	var otherPayments int
+	err = tx.QueryRow(`
+		SELECT o.status, o.total_cents,
+			(SELECT COUNT(*) FROM payments
+			 WHERE order_id = o.id AND id <> $2 AND status = ANY($3))
+		FROM orders o
+		WHERE o.id = $1
+		FOR UPDATE
+	`, c.OrderID, c.PaymentID, pq.Array([]string{PaymentPending, PaymentAuthorized, PaymentCaptured}),
+	).Scan(&orderStatus, &orderTotal, &otherPayments)
+	if err != nil {
+		return nil, err
+	}
+
+	status := PaymentCaptured
+	var result error
+	if orderStatus != OrderPending || orderTotal != c.AmountCents || otherPayments > 0 {
+		status = c.Status // vencido/cancelado: mantém o status, registra o valor recebido
+		result = ErrOrderState
+	}
+
+	_, err = tx.Exec(`
+		UPDATE payments
+		SET status = $1, captured_cents = amount_cents, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, status, c.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	c.Status = status
+
+	if result == nil {
+		if err := transitionOrder(tx, c.OrderID, OrderPaid, OrderPending); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return c, result
+}
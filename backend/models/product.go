@@ -0,0 +1,96 @@
+// Arquivo: backend/models/product.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Produto do cardápio. O preço cadastrado aqui é a fonte de verdade para o
+// valor cobrado; preços enviados pelo cliente são ignorados.
+type Product struct {
+	ID         string `json:"id"`
+	Category   string `json:"category"`
+	Name       string `json:"name"`
+	PriceCents int64  `json:"price_cents"`
+	// Categoria fiscal (regras de tributo); a própria categoria quando não cadastrada
+	TaxCategory string `json:"tax_category"`
+	// Grupos de opções (retirar, adicionais, variações) com os acréscimos
+	ModifierGroups []ModifierGroup `json:"modifier_groups"`
+}
+
+var ErrUnknownProduct = errors.New("produto não encontrado no cardápio")
+
+// Executor comum a *sql.DB e *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// Buscar produto ativo por categoria e nome (sem diferenciar maiúsculas),
+// com os grupos de opções
+func GetProduct(q queryer, category, name string) (*Product, error) {
+	var p Product
+	err := q.QueryRow(`
+		SELECT id, category, name, price_cents, COALESCE(tax_category, category)
+		FROM products
+		WHERE category = $1 AND lower(name) = lower($2) AND active = true
+	`, category, name).Scan(&p.ID, &p.Category, &p.Name, &p.PriceCents, &p.TaxCategory)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrUnknownProduct, name, category)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := productModifierGroups(q, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	p.ModifierGroups = groups[p.ID]
+	if p.ModifierGroups == nil {
+		p.ModifierGroups = []ModifierGroup{}
+	}
+	return &p, nil
+}
+
+// Cardápio: produtos ativos com os grupos de opções
+func GetActiveProducts(db *sql.DB) ([]Product, error) {
+	rows, err := db.Query(`
+		SELECT id, category, name, price_cents, COALESCE(tax_category, category)
+		FROM products
+		WHERE active = true
+		ORDER BY category, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	var ids []string
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.Category, &p.Name, &p.PriceCents, &p.TaxCategory); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+		ids = append(ids, p.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	groups, err := productModifierGroups(db, ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].ModifierGroups = groups[products[i].ID]
+		if products[i].ModifierGroups == nil {
+			products[i].ModifierGroups = []ModifierGroup{}
+		}
+	}
+	return products, nil
+}
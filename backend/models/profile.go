@@ -0,0 +1,251 @@
+// Arquivo: backend/models/profile.go
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const EmailChangeTTL = 24 * time.Hour
+
+type UpdateProfileRequest struct {
+	FullName *string `json:"full_name"`
+}
+
+type EmailChangeRequest struct {
+	NewEmail    string `json:"new_email"`
+	Password    string `json:"password"`
+	ReauthToken string `json:"reauth_token,omitempty"` // no lugar da senha
+}
+
+var (
+	ErrEmailTaken              = errors.New("email já cadastrado")
+	ErrInvalidFullName         = errors.New("nome deve ter no máximo 255 caracteres")
+	ErrInvalidEmailChangeToken = errors.New("link de confirmação inválido ou expirado")
+)
+
+// Atualizar dados do perfil
+func UpdateUserProfile(db *sql.DB, userID string, req UpdateProfileRequest) (*User, error) {
+	if req.FullName != nil {
+		name := strings.TrimSpace(*req.FullName)
+		if len(name) > 255 {
+			return nil, ErrInvalidFullName
+		}
+
+		query := `UPDATE users SET full_name = $1 WHERE id = $2 AND is_active = true`
+		if _, err := db.Exec(query, name, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return GetUserByID(db, userID)
+}
+
+// Registrar troca de email pendente. Retorna o token de confirmação e o novo
+// email normalizado, destino do link.
+func CreateEmailChangeRequest(db *sql.DB, userID, newEmail string) (string, string, error) {
+	newEmail = strings.TrimSpace(newEmail)
+	if err := ValidateEmail(newEmail); err != nil {
+		return "", "", err
+	}
+
+	taken, err := emailTaken(db, newEmail, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if taken {
+		return "", "", ErrEmailTaken
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	// Apenas uma troca pendente por usuário
+	if _, err = tx.Exec(`DELETE FROM email_change_requests WHERE user_id = $1`, userID); err != nil {
+		return "", "", err
+	}
+
+	query := `
+		INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err = tx.Exec(query, userID, newEmail, hashToken(token), time.Now().Add(EmailChangeTTL)); err != nil {
+		return "", "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", "", err
+	}
+
+	return token, newEmail, nil
+}
+
+// Email em uso por outro usuário, sem diferenciar maiúsculas (o próprio
+// usuário pode trocar só a capitalização)
+func emailTaken(q queryer, email, userID string) (bool, error) {
+	var exists bool
+	err := q.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1) AND id <> $2)`,
+		email, userID,
+	).Scan(&exists)
+	return exists, err
+}
+
+// Confirmar troca de email a partir do token recebido no novo endereço.
+// Retorna também o email anterior, que é avisado da troca.
+func ConfirmEmailChange(db *sql.DB, token string) (*User, string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	var requestID, userID, newEmail string
+	query := `
+		SELECT id, user_id, new_email
+		FROM email_change_requests
+		WHERE token_hash = $1 AND expires_at > $2
+	`
+	err = tx.QueryRow(query, hashToken(token), time.Now()).Scan(&requestID, &userID, &newEmail)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrInvalidEmailChangeToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var oldEmail string
+	err = tx.QueryRow(`SELECT email FROM users WHERE id = $1 AND is_active = true FOR UPDATE`, userID).Scan(&oldEmail)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrUserNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Outro cadastro pode ter usado o email (com outra capitalização) depois do pedido
+	taken, err := emailTaken(tx, newEmail, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if taken {
+		return nil, "", ErrEmailTaken
+	}
+
+	_, err = tx.Exec(`UPDATE users SET email = $1 WHERE id = $2`, newEmail, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "users_email_key") {
+			return nil, "", ErrEmailTaken
+		}
+		return nil, "", err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM email_change_requests WHERE id = $1`, requestID); err != nil {
+		return nil, "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, "", err
+	}
+
+	user, err := GetUserByID(db, userID)
+	return user, oldEmail, err
+}
+
+// Desativar conta (soft delete reversível pelo suporte)
+func DeactivateUser(db *sql.DB, userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET is_active = false, token_version = token_version + 1 WHERE id = $1 AND is_active = true`, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err = tx.Exec(`DELETE FROM sessions WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Excluir conta conforme a LGPD: remove dados pessoais e anonimiza o usuário,
+// mantendo os pedidos (e seus itens) para fins contábeis
+func AnonymizeUser(db *sql.DB, userID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET email = 'excluido-' || id || '@anonimizado.invalid',
+		    password_hash = '!',
+		    full_name = '',
+		    last_login = NULL,
+		    totp_secret = NULL,
+		    totp_enabled = false,
+		    is_active = false,
+		    token_version = token_version + 1,
+		    deleted_at = $1
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+	result, err := tx.Exec(query, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	cleanup := []string{
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM email_change_requests WHERE user_id = $1`,
+		`DELETE FROM data_exports WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		// Observações livres podem conter dados pessoais (endereço, telefone)
+		`UPDATE orders SET notes = '' WHERE user_id = $1`,
+	}
+	for _, q := range cleanup {
+		if _, err = tx.Exec(q, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
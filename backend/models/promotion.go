@@ -0,0 +1,293 @@
+// Arquivo: backend/models/promotion.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"finplay/backend/config"
+	"finplay/backend/pricing"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCouponInvalid       = errors.New("cupom inválido")
+	ErrCouponExpired       = errors.New("cupom fora do período de validade")
+	ErrCouponUsageLimit    = errors.New("cupom atingiu o limite de uso")
+	ErrCouponNotApplicable = errors.New("cupom não se aplica a este pedido")
+)
+
+// Fuso usado para validade e dias da semana das promoções
+var promotionsLocation = time.UTC
+
+// Aplicar a configuração carregada (fuso validado em config.Validate)
+func ConfigurePromotions(cfg config.PromotionsConfig) {
+	if loc, err := time.LoadLocation(cfg.Timezone); err == nil {
+		promotionsLocation = loc
+	}
+}
+
+// Promoção cadastrada. Limites zerados significam sem limite.
+type Promotion struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description,omitempty"`
+	Code           string     `json:"-"` // cupons não são listados
+	Kind           string     `json:"kind"`
+	Percent        int        `json:"percent,omitempty"`
+	AmountCents    int64      `json:"amount_cents,omitempty"`
+	BuyQuantity    int        `json:"buy_quantity,omitempty"`
+	GetQuantity    int        `json:"get_quantity,omitempty"`
+	Category       string     `json:"category,omitempty"`
+	ProductName    string     `json:"product_name,omitempty"`
+	MinOrderCents  int64      `json:"min_order_cents,omitempty"`
+	Weekdays       []int      `json:"weekdays,omitempty"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	MaxUses        int        `json:"-"`
+	MaxUsesPerUser int        `json:"max_uses_per_user,omitempty"`
+}
+
+// Desconto aplicado a um pedido (uma linha por promoção)
+type OrderDiscount struct {
+	PromotionID string `json:"promotion_id"`
+	Name        string `json:"name"`
+	Code        string `json:"code,omitempty"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+const promotionColumns = `
+	id, name, COALESCE(description, ''), COALESCE(code, ''), kind,
+	COALESCE(percent, 0), COALESCE(amount_cents, 0), COALESCE(buy_quantity, 0), COALESCE(get_quantity, 0),
+	COALESCE(category, ''), COALESCE(product_name, ''), min_order_cents, weekdays,
+	starts_at, ends_at, COALESCE(max_uses, 0), COALESCE(max_uses_per_user, 0)
+`
+
+func scanPromotion(row rowScanner) (*Promotion, error) {
+	var p Promotion
+	var weekdays pq.Int64Array
+	err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &p.Code, &p.Kind,
+		&p.Percent, &p.AmountCents, &p.BuyQuantity, &p.GetQuantity,
+		&p.Category, &p.ProductName, &p.MinOrderCents, &weekdays,
+		&p.StartsAt, &p.EndsAt, &p.MaxUses, &p.MaxUsesPerUser,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range weekdays {
+		p.Weekdays = append(p.Weekdays, int(d))
+	}
+	return &p, nil
+}
+
+// Regra usada no cálculo
+func (p *Promotion) Rule() pricing.Promotion {
+	return pricing.Promotion{
+		ID:            p.ID,
+		Name:          p.Name,
+		Code:          p.Code,
+		Kind:          p.Kind,
+		Percent:       p.Percent,
+		AmountCents:   p.AmountCents,
+		BuyQuantity:   p.BuyQuantity,
+		GetQuantity:   p.GetQuantity,
+		Category:      p.Category,
+		Product:       p.ProductName,
+		MinOrderCents: p.MinOrderCents,
+		Weekdays:      p.Weekdays,
+		StartsAt:      p.StartsAt,
+		EndsAt:        p.EndsAt,
+	}
+}
+
+// Promoções automáticas (sem cupom) vigentes agora, para divulgação
+func GetActivePromotions(db *sql.DB) ([]Promotion, error) {
+	promotions, err := queryPromotions(db, `
+		SELECT `+promotionColumns+`
+		FROM promotions
+		WHERE active = true AND code IS NULL
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(promotionsLocation)
+	active := []Promotion{}
+	for _, p := range promotions {
+		if p.Rule().ActiveAt(now) {
+			active = append(active, p)
+		}
+	}
+	return active, nil
+}
+
+type rowsQueryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func queryPromotions(q rowsQueryer, query string, args ...any) ([]Promotion, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+	return promotions, rows.Err()
+}
+
+// Promoções aplicáveis a um novo pedido: as automáticas vigentes e dentro dos
+// limites de uso, seguidas do cupom informado (validado). As promoções com
+// limite ficam bloqueadas até o fim da transação para a contagem não correr,
+// exceto com readOnly (simulação, sem pedido gravado).
+func orderPromotions(tx *sql.Tx, userID, couponCode string, now time.Time, readOnly bool) ([]Promotion, error) {
+	automatic, err := queryPromotions(tx, `
+		SELECT `+promotionColumns+`
+		FROM promotions
+		WHERE active = true AND code IS NULL
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var promotions []Promotion
+	for _, p := range automatic {
+		if !p.Rule().ActiveAt(now) {
+			continue
+		}
+		ok, err := withinUsageLimits(tx, &p, userID, readOnly)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			promotions = append(promotions, p)
+		}
+	}
+
+	if couponCode == "" {
+		return promotions, nil
+	}
+
+	row := tx.QueryRow(`
+		SELECT `+promotionColumns+`
+		FROM promotions
+		WHERE active = true AND upper(code) = upper($1)
+	`, strings.TrimSpace(couponCode))
+	coupon, err := scanPromotion(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrCouponInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !coupon.Rule().ActiveAt(now) {
+		return nil, ErrCouponExpired
+	}
+	ok, err := withinUsageLimits(tx, coupon, userID, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCouponUsageLimit
+	}
+	return append(promotions, *coupon), nil
+}
+
+// Conferir os limites global e por usuário. Usos em pedidos cancelados ou
+// estornados não contam. Sem readOnly, a promoção fica bloqueada até o fim
+// da transação.
+func withinUsageLimits(tx *sql.Tx, p *Promotion, userID string, readOnly bool) (bool, error) {
+	if p.MaxUses == 0 && p.MaxUsesPerUser == 0 {
+		return true, nil
+	}
+
+	if !readOnly {
+		if _, err := tx.Exec(`SELECT 1 FROM promotions WHERE id = $1 FOR UPDATE`, p.ID); err != nil {
+			return false, err
+		}
+	}
+
+	var total, byUser int
+	err := tx.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE r.user_id = $2)
+		FROM promotion_redemptions r
+		JOIN orders o ON o.id = r.order_id
+		WHERE r.promotion_id = $1 AND o.status <> ALL($3)
+	`, p.ID, userID, pq.Array([]string{OrderCancelled, OrderRefunded})).Scan(&total, &byUser)
+	if err != nil {
+		return false, err
+	}
+
+	if p.MaxUses > 0 && total >= p.MaxUses {
+		return false, nil
+	}
+	if p.MaxUsesPerUser > 0 && byUser >= p.MaxUsesPerUser {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Registrar os descontos aplicados ao pedido
+func insertRedemptions(tx *sql.Tx, orderID, userID string, discounts []pricing.Discount) ([]OrderDiscount, error) {
+	for _, d := range discounts {
+		_, err := tx.Exec(`
+			INSERT INTO promotion_redemptions (promotion_id, order_id, user_id, code, amount_cents)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		`, d.Promotion.ID, orderID, userID, d.Promotion.Code, d.AmountCents)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orderDiscounts(discounts), nil
+}
+
+// Descontos calculados no formato do pedido
+func orderDiscounts(discounts []pricing.Discount) []OrderDiscount {
+	applied := []OrderDiscount{}
+	for _, d := range discounts {
+		applied = append(applied, OrderDiscount{
+			PromotionID: d.Promotion.ID,
+			Name:        d.Promotion.Name,
+			Code:        d.Promotion.Code,
+			AmountCents: d.AmountCents,
+		})
+	}
+	return applied
+}
+
+// Descontos aplicados a um pedido
+func GetOrderDiscounts(db *sql.DB, orderID string) ([]OrderDiscount, error) {
+	rows, err := db.Query(`
+		SELECT r.promotion_id, p.name, COALESCE(r.code, ''), r.amount_cents
+		FROM promotion_redemptions r
+		JOIN promotions p ON p.id = r.promotion_id
+		WHERE r.order_id = $1
+		ORDER BY r.created_at, p.name
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discounts := []OrderDiscount{}
+	for rows.Next() {
+		var d OrderDiscount
+		if err := rows.Scan(&d.PromotionID, &d.Name, &d.Code, &d.AmountCents); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, d)
+	}
+	return discounts, rows.Err()
+}
@@ -0,0 +1,113 @@
+// Arquivo: backend/models/receipt.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Status do cupom fiscal
+const (
+	ReceiptPending    = "pending"    // enviado ao autorizador, sem resposta ainda
+	ReceiptAuthorized = "authorized" // autorizado; XML e PDF guardados
+	ReceiptRejected   = "rejected"   // rejeitado; a próxima emissão reaproveita o número
+)
+
+var ErrReceiptPending = errors.New("cupom fiscal em emissão")
+
+// Cupom fiscal (NFC-e) de um pedido finalizado. O número vem de uma sequência
+// e é mantido entre tentativas, como exige a numeração da NFC-e.
+type Receipt struct {
+	OrderID      string     `json:"order_id"`
+	Number       int64      `json:"number"`
+	Series       int        `json:"series"`
+	Status       string     `json:"status"`
+	AccessKey    string     `json:"access_key,omitempty"`
+	Protocol     string     `json:"protocol,omitempty"`
+	Message      string     `json:"message,omitempty"` // motivo da rejeição
+	XML          []byte     `json:"-"`
+	PDF          []byte     `json:"-"`
+	IssuedAt     *time.Time `json:"issued_at,omitempty"`
+	AuthorizedAt *time.Time `json:"authorized_at,omitempty"`
+}
+
+const receiptColumns = `
+	order_id, number, series, status, COALESCE(access_key, ''), COALESCE(protocol, ''),
+	COALESCE(message, ''), xml, pdf, issued_at, authorized_at
+`
+
+func scanReceipt(row rowScanner) (*Receipt, error) {
+	var r Receipt
+	var xml sql.NullString
+	err := row.Scan(
+		&r.OrderID, &r.Number, &r.Series, &r.Status, &r.AccessKey, &r.Protocol,
+		&r.Message, &xml, &r.PDF, &r.IssuedAt, &r.AuthorizedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.XML = []byte(xml.String)
+	return &r, nil
+}
+
+// Cupom do pedido (sql.ErrNoRows se ainda não emitido)
+func GetReceipt(db *sql.DB, orderID string) (*Receipt, error) {
+	return scanReceipt(db.QueryRow(`SELECT `+receiptColumns+` FROM receipts WHERE order_id = $1`, orderID))
+}
+
+// Reservar a emissão do cupom do pedido. Retorna true com o registro pending
+// (número já alocado) quando esta chamada deve emitir; caso contrário retorna
+// o cupom existente: autorizado ou ErrReceiptPending se outra emissão está em
+// andamento. Rejeitados e emissões abandonadas são retomados.
+func ReserveReceipt(db *sql.DB, orderID string, series int, lockTimeout time.Duration) (*Receipt, bool, error) {
+	row := db.QueryRow(`
+		INSERT INTO receipts (order_id, series, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (order_id) DO UPDATE
+		SET status = EXCLUDED.status, message = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE receipts.status = $4
+			OR (receipts.status = $3 AND receipts.updated_at <= CURRENT_TIMESTAMP - $5 * INTERVAL '1 second')
+		RETURNING `+receiptColumns,
+		orderID, series, ReceiptPending, ReceiptRejected, int(lockTimeout.Seconds()))
+	r, err := scanReceipt(row)
+	if err == nil {
+		return r, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	r, err = GetReceipt(db, orderID)
+	if err != nil {
+		return nil, false, err
+	}
+	if r.Status != ReceiptAuthorized {
+		return nil, false, ErrReceiptPending
+	}
+	return r, false, nil
+}
+
+// Gravar o cupom autorizado
+func AuthorizeReceipt(db *sql.DB, r *Receipt) error {
+	_, err := db.Exec(`
+		UPDATE receipts
+		SET status = $1, access_key = $2, protocol = $3, xml = $4, pdf = $5,
+			issued_at = $6, authorized_at = $7, message = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $8
+	`, ReceiptAuthorized, r.AccessKey, r.Protocol, string(r.XML), r.PDF, r.IssuedAt, r.AuthorizedAt, r.OrderID)
+	if err != nil {
+		return err
+	}
+	r.Status = ReceiptAuthorized
+	return nil
+}
+
+// Registrar a rejeição (ou falha de comunicação) para a próxima tentativa
+func RejectReceipt(db *sql.DB, orderID, message string) error {
+	_, err := db.Exec(`
+		UPDATE receipts SET status = $1, message = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $3
+	`, ReceiptRejected, message, orderID)
+	return err
+}
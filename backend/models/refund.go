@@ -0,0 +1,413 @@
+// Arquivo: backend/models/refund.go
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Motivos de estorno/cancelamento
+const (
+	RefundCustomerRequest = "customer_request" // desistência do cliente
+	RefundItemUnavailable = "item_unavailable" // item em falta
+	RefundWrongItem       = "wrong_item"
+	RefundQualityIssue    = "quality_issue"
+	RefundDuplicate       = "duplicate" // cobrança em duplicidade
+	RefundOther           = "other"
+)
+
+var RefundReasons = []string{
+	RefundCustomerRequest, RefundItemUnavailable, RefundWrongItem,
+	RefundQualityIssue, RefundDuplicate, RefundOther,
+}
+
+// Tamanho máximo da observação do estorno
+const MaxRefundNoteLength = 500
+
+var (
+	ErrInvalidRefundReason = errors.New("motivo de estorno inválido")
+	ErrInvalidRefundAmount = errors.New("valor de estorno inválido")
+	ErrRefundNoteTooLong   = errors.New("observação do estorno muito longa")
+	ErrOrderItemNotFound   = errors.New("item não encontrado no pedido")
+)
+
+// Estorno (total ou parcial) de um pagamento capturado. Items lista os itens
+// cancelados junto, quando o estorno vem de um cancelamento de itens.
+type Refund struct {
+	ID          string       `json:"id"`
+	PaymentID   string       `json:"payment_id"`
+	OrderID     string       `json:"order_id"`
+	UserID      string       `json:"user_id"`
+	AmountCents int64        `json:"amount_cents"`
+	Reason      string       `json:"reason"`
+	Note        string       `json:"note,omitempty"`
+	GatewayRef  string       `json:"gateway_ref,omitempty"`
+	Items       []RefundItem `json:"items,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type RefundItem struct {
+	OrderItemID string `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// Quantidade de um item do pedido a cancelar
+type ItemCancellation struct {
+	ItemID   string `json:"item_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// Validar motivo e observação (motivo vazio vira customer_request)
+func NormalizeRefundReason(reason, note string) (string, error) {
+	if len(note) > MaxRefundNoteLength {
+		return "", ErrRefundNoteTooLong
+	}
+	if reason == "" {
+		return RefundCustomerRequest, nil
+	}
+	for _, r := range RefundReasons {
+		if r == reason {
+			return reason, nil
+		}
+	}
+	return "", ErrInvalidRefundReason
+}
+
+// Preço unitário em centavos (price é gravado em reais)
+func (i OrderItem) PriceCents() int64 {
+	return int64(math.Round(i.Price * 100))
+}
+
+// Quantidade ainda não cancelada
+func (i OrderItem) ActiveQuantity() int {
+	return i.Quantity - i.CancelledQuantity
+}
+
+// Valor líquido (com o desconto e os tributos somados da linha rateados) de
+// units unidades. Mesma conta de recalculateOrderTotal, para o estorno bater
+// com o novo total.
+func (i OrderItem) NetCents(units int) int64 {
+	if i.Quantity == 0 {
+		return 0
+	}
+	return (i.PriceCents()*int64(i.Quantity)-i.DiscountCents)*int64(units)/int64(i.Quantity) +
+		i.TaxCents*int64(units)/int64(i.Quantity)
+}
+
+// Conferir os cancelamentos contra os itens do pedido e calcular o valor de
+// cada um. Quantidades do mesmo item são somadas.
+func PriceCancellation(order *Order, cancels []ItemCancellation) ([]RefundItem, int64, error) {
+	byID := make(map[string]OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		byID[item.ID] = item
+	}
+
+	var items []RefundItem
+	index := make(map[string]int)
+	for _, c := range cancels {
+		item, ok := byID[c.ItemID]
+		if !ok {
+			return nil, 0, ErrOrderItemNotFound
+		}
+		if c.Quantity < 1 {
+			return nil, 0, ErrInvalidQuantity
+		}
+		i, seen := index[c.ItemID]
+		if !seen {
+			i = len(items)
+			index[c.ItemID] = i
+			items = append(items, RefundItem{OrderItemID: c.ItemID})
+		}
+		items[i].Quantity += c.Quantity
+		if items[i].Quantity > item.ActiveQuantity() {
+			return nil, 0, ErrInvalidQuantity
+		}
+	}
+
+	// O valor é a diferença no líquido do item, para o arredondamento do
+	// desconto não deixar centavos sobrando
+	var total int64
+	for i := range items {
+		item := byID[items[i].OrderItemID]
+		active := item.ActiveQuantity()
+		items[i].AmountCents = item.NetCents(active) - item.NetCents(active-items[i].Quantity)
+		total += items[i].AmountCents
+	}
+	return items, total, nil
+}
+
+// Cancelar itens de um pedido ainda não pago (pending ou authorized) e
+// recalcular o total. Cobranças PIX pendentes são encerradas, pois o valor
+// mudou; se nada restar, o pedido pending é cancelado.
+func CancelOrderItems(db *sql.DB, orderID string, items []RefundItem) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != OrderPending && status != OrderAuthorized {
+		return ErrOrderState
+	}
+
+	if err := cancelItems(tx, orderID, items); err != nil {
+		return err
+	}
+	remaining, err := recalculateOrderTotal(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $2 AND status = $3
+	`, PaymentVoided, orderID, PaymentPending)
+	if err != nil {
+		return err
+	}
+
+	if remaining == 0 && status == OrderPending {
+		if err := transitionOrder(tx, orderID, OrderCancelled, OrderPending); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Registrar um estorno confirmado no gateway: soma refunded_cents, cancela os
+// itens informados e recalcula o total. Estornado todo o valor capturado, o
+// pagamento e o pedido passam a refunded.
+func RecordRefund(db *sql.DB, p *Payment, r *Refund) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var captured, refunded int64
+	err = tx.QueryRow(`
+		SELECT status, captured_cents, refunded_cents FROM payments WHERE id = $1 FOR UPDATE
+	`, p.ID).Scan(&status, &captured, &refunded)
+	if err != nil {
+		return err
+	}
+	if status != PaymentCaptured {
+		return ErrPaymentState
+	}
+	if r.AmountCents <= 0 || refunded+r.AmountCents > captured {
+		return ErrInvalidRefundAmount
+	}
+
+	r.PaymentID = p.ID
+	r.OrderID = p.OrderID
+	r.UserID = p.UserID
+	err = tx.QueryRow(`
+		INSERT INTO refunds (payment_id, order_id, user_id, amount_cents, reason, note, gateway_ref)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+		RETURNING id, created_at
+	`, r.PaymentID, r.OrderID, r.UserID, r.AmountCents, r.Reason, r.Note, r.GatewayRef,
+	).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if len(r.Items) > 0 {
+		for _, item := range r.Items {
+			_, err := tx.Exec(`
+				INSERT INTO refund_items (refund_id, order_item_id, quantity, amount_cents)
+				VALUES ($1, $2, $3, $4)
+			`, r.ID, item.OrderItemID, item.Quantity, item.AmountCents)
+			if err != nil {
+				return err
+			}
+		}
+		if err := cancelItems(tx, p.OrderID, r.Items); err != nil {
+			return err
+		}
+		if _, err := recalculateOrderTotal(tx, p.OrderID); err != nil {
+			return err
+		}
+	}
+
+	// Pedido já finalizado perde os pontos do valor estornado
+	var orderStatus string
+	if err := tx.QueryRow(`SELECT status FROM orders WHERE id = $1`, p.OrderID).Scan(&orderStatus); err != nil {
+		return err
+	}
+	if orderStatus == OrderCompleted {
+		if err := reverseLoyaltyPoints(tx, p.OrderID, p.UserID, r.AmountCents, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	refunded += r.AmountCents
+	status = PaymentCaptured
+	if refunded == captured {
+		status = PaymentRefunded
+	}
+	err = tx.QueryRow(`
+		UPDATE payments
+		SET status = $1, refunded_cents = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING updated_at
+	`, status, refunded, p.ID).Scan(&p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if status == PaymentRefunded {
+		if err := transitionOrder(tx, p.OrderID, OrderRefunded, OrderPaid, OrderCompleted); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	p.Status = status
+	p.RefundedCents = refunded
+	return nil
+}
+
+// Somar as quantidades canceladas, sem ultrapassar a quantidade do item
+func cancelItems(tx *sql.Tx, orderID string, items []RefundItem) error {
+	for _, item := range items {
+		result, err := tx.Exec(`
+			UPDATE order_items
+			SET cancelled_quantity = cancelled_quantity + $1
+			WHERE id = $2 AND order_id = $3 AND quantity - cancelled_quantity >= $1
+		`, item.Quantity, item.OrderItemID, orderID)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrInvalidQuantity
+		}
+	}
+	return nil
+}
+
+// Recalcular subtotal, desconto, tributos, total e total_items a partir dos
+// itens não cancelados; desconto e tributos de cada linha caem na proporção
+// das unidades canceladas (ver OrderItem.NetCents). A taxa de entrega só sai
+// quando nada resta. Retorna o novo total.
+func recalculateOrderTotal(tx *sql.Tx, orderID string) (int64, error) {
+	var total int64
+	err := tx.QueryRow(`
+		UPDATE orders o
+		SET subtotal_cents = t.subtotal_cents, discount_cents = t.subtotal_cents - t.net_cents,
+			tax_cents = t.tax_cents, included_tax_cents = t.included_tax_cents,
+			delivery_fee_cents = CASE WHEN t.total_items > 0 THEN o.delivery_fee_cents ELSE 0 END,
+			total_cents = t.net_cents + t.tax_cents + CASE WHEN t.total_items > 0 THEN o.delivery_fee_cents ELSE 0 END,
+			total_items = t.total_items
+		FROM (
+			SELECT COALESCE(SUM(unit_cents * (quantity - cancelled_quantity)), 0) AS subtotal_cents,
+				COALESCE(SUM((unit_cents * quantity - discount_cents) * (quantity - cancelled_quantity) / NULLIF(quantity, 0)), 0) AS net_cents,
+				COALESCE(SUM(tax_cents * (quantity - cancelled_quantity) / NULLIF(quantity, 0)), 0) AS tax_cents,
+				COALESCE(SUM(included_tax_cents * (quantity - cancelled_quantity) / NULLIF(quantity, 0)), 0) AS included_tax_cents,
+				COUNT(*) FILTER (WHERE quantity > cancelled_quantity) AS total_items
+			FROM (
+				SELECT ROUND(COALESCE(price, 0) * 100)::BIGINT AS unit_cents, quantity, cancelled_quantity,
+					discount_cents, tax_cents, included_tax_cents
+				FROM order_items
+				WHERE order_id = $1
+			) i
+		) t
+		WHERE o.id = $1
+		RETURNING o.total_cents
+	`, orderID).Scan(&total)
+	return total, err
+}
+
+// Estornos de um pedido, do mais recente ao mais antigo, com os itens
+func GetOrderRefunds(db *sql.DB, orderID string) ([]Refund, error) {
+	return queryRefunds(db, `
+		SELECT id, payment_id, order_id, user_id, amount_cents, reason,
+			COALESCE(note, ''), COALESCE(gateway_ref, ''), created_at
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY created_at DESC
+	`, orderID)
+}
+
+// Todos os estornos do usuário (exportação de dados)
+func GetUserRefunds(db *sql.DB, userID string) ([]Refund, error) {
+	return queryRefunds(db, `
+		SELECT id, payment_id, order_id, user_id, amount_cents, reason,
+			COALESCE(note, ''), COALESCE(gateway_ref, ''), created_at
+		FROM refunds
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+}
+
+func queryRefunds(db *sql.DB, query string, args ...any) ([]Refund, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []Refund{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var r Refund
+		err := rows.Scan(
+			&r.ID, &r.PaymentID, &r.OrderID, &r.UserID, &r.AmountCents, &r.Reason,
+			&r.Note, &r.GatewayRef, &r.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		index[r.ID] = len(refunds)
+		refunds = append(refunds, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(refunds) == 0 {
+		return refunds, nil
+	}
+
+	ids := make([]string, 0, len(refunds))
+	for _, r := range refunds {
+		ids = append(ids, r.ID)
+	}
+	itemRows, err := db.Query(`
+		SELECT refund_id, order_item_id, quantity, amount_cents
+		FROM refund_items
+		WHERE refund_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var refundID string
+		var item RefundItem
+		if err := itemRows.Scan(&refundID, &item.OrderItemID, &item.Quantity, &item.AmountCents); err != nil {
+			return nil, err
+		}
+		r := &refunds[index[refundID]]
+		r.Items = append(r.Items, item)
+	}
+	return refunds, itemRows.Err()
+}
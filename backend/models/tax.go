@@ -0,0 +1,96 @@
+// Arquivo: backend/models/tax.go
+package models
+
+import (
+	"database/sql"
+	"finplay/backend/config"
+	"finplay/backend/pricing"
+)
+
+var taxesConfig = config.Default().Taxes
+
+// Aplicar a configuração carregada
+func ConfigureTaxes(cfg config.TaxesConfig) {
+	taxesConfig = cfg
+}
+
+// Tributo do pedido, somado por regra e proporcional às unidades não
+// canceladas. Inclusive = já embutido nos preços (não entra em tax_cents).
+type OrderTax struct {
+	Name         string `json:"name"`
+	Jurisdiction string `json:"jurisdiction,omitempty"`
+	RateBps      int    `json:"rate_bps"`
+	Inclusive    bool   `json:"inclusive"`
+	BaseCents    int64  `json:"base_cents"`
+	AmountCents  int64  `json:"amount_cents"`
+}
+
+// Regras de tributo ativas, na ordem de cadastro (desempate entre regras
+// igualmente específicas)
+func activeTaxRules(tx *sql.Tx) ([]pricing.TaxRule, error) {
+	rows, err := tx.Query(`
+		SELECT id, name, COALESCE(jurisdiction, ''), COALESCE(tax_category, ''), rate_bps, inclusive
+		FROM tax_rules
+		WHERE active = true
+		ORDER BY created_at, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []pricing.TaxRule
+	for rows.Next() {
+		var t pricing.TaxRule
+		if err := rows.Scan(&t.ID, &t.Name, &t.Jurisdiction, &t.Category, &t.RateBps, &t.Inclusive); err != nil {
+			return nil, err
+		}
+		rules = append(rules, t)
+	}
+	return rules, rows.Err()
+}
+
+// Gravar os tributos calculados de um item do pedido
+func insertItemTaxes(tx *sql.Tx, orderID, itemID string, taxes []pricing.Tax) error {
+	for _, t := range taxes {
+		_, err := tx.Exec(`
+			INSERT INTO order_item_taxes (order_id, order_item_id, tax_rule_id, name, jurisdiction,
+				rate_bps, inclusive, base_cents, amount_cents)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+		`, orderID, itemID, t.Rule.ID, t.Rule.Name, t.Rule.Jurisdiction,
+			t.Rule.RateBps, t.Rule.Inclusive, t.BaseCents, t.AmountCents)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tributos do pedido sobre os itens não cancelados (mesma proporção de
+// recalculateOrderTotal)
+func GetOrderTaxes(db *sql.DB, orderID string) ([]OrderTax, error) {
+	rows, err := db.Query(`
+		SELECT t.name, COALESCE(t.jurisdiction, ''), t.rate_bps, t.inclusive,
+			SUM(t.base_cents * (i.quantity - i.cancelled_quantity) / NULLIF(i.quantity, 0)),
+			SUM(t.amount_cents * (i.quantity - i.cancelled_quantity) / NULLIF(i.quantity, 0))
+		FROM order_item_taxes t
+		JOIN order_items i ON i.id = t.order_item_id
+		WHERE t.order_id = $1 AND i.quantity > i.cancelled_quantity
+		GROUP BY t.name, t.jurisdiction, t.rate_bps, t.inclusive
+		ORDER BY t.inclusive, t.name, t.rate_bps
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxes := []OrderTax{}
+	for rows.Next() {
+		var t OrderTax
+		if err := rows.Scan(&t.Name, &t.Jurisdiction, &t.RateBps, &t.Inclusive, &t.BaseCents, &t.AmountCents); err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, t)
+	}
+	return taxes, rows.Err()
+}
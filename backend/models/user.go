@@ -4,6 +4,8 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"finplay/backend/store"
+	"fmt"
 	"regexp"
 	"time"
 
@@ -19,6 +21,10 @@ type User struct {
 	UpdatedAt    time.Time  `json:"updated_at"`
 	LastLogin    *time.Time `json:"last_login,omitempty"`
 	IsActive     bool       `json:"is_active"`
+	TOTPSecret   string     `json:"-"` // Segredo TOTP, nunca retornar no JSON
+	TOTPEnabled  bool       `json:"totp_enabled"`
+	TokenVersion int        `json:"-"` // Incrementado para revogar sessões
+	Role         string     `json:"role"`
 }
 
 type UserRegistration struct {
@@ -32,38 +38,40 @@ type UserLogin struct {
 	Password string `json:"password"`
 }
 
+var (
+	ErrInvalidEmail = errors.New("email inválido")
+	ErrUserNotFound = errors.New("usuário não encontrado")
+)
+
 // Validar email
 func ValidateEmail(email string) error {
 	if email == "" {
-		return errors.New("email é obrigatório")
+		return fmt.Errorf("%w: informe o email", ErrInvalidEmail)
 	}
 
 	// Regex para validar email
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return errors.New("formato de email inválido")
+		return fmt.Errorf("%w: formato incorreto", ErrInvalidEmail)
 	}
 
 	// Validar provedores conhecidos
 	providerRegex := regexp.MustCompile(`@(gmail|yahoo|hotmail|outlook|live|icloud|protonmail|aol)\.(com|com\.br|net|org)$`)
 	if !providerRegex.MatchString(email) {
-		return errors.New("provedor de email não reconhecido. Use Gmail, Yahoo, Hotmail, Outlook, etc.")
+		return fmt.Errorf("%w: provedor não reconhecido. Use Gmail, Yahoo, Hotmail, Outlook, etc.", ErrInvalidEmail)
 	}
 
 	return nil
 }
 
-// Validar senha
-func ValidatePassword(password string) error {
-	if len(password) < 8 {
-		return errors.New("senha deve ter no mínimo 8 caracteres")
-	}
-	return nil
+// Validar senha contra a política atual (ver password.go)
+func ValidatePassword(password string, userInputs ...string) error {
+	return CurrentPasswordPolicy().Validate(password, userInputs...)
 }
 
-// Hash da senha
+// Hash da senha (custo do bcrypt configurável via BCRYPT_COST)
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), CurrentPasswordPolicy().BcryptCost)
 	return string(bytes), err
 }
 
@@ -79,7 +87,7 @@ func CreateUser(db *sql.DB, reg UserRegistration) (*User, error) {
 	if err := ValidateEmail(reg.Email); err != nil {
 		return nil, err
 	}
-	if err := ValidatePassword(reg.Password); err != nil {
+	if err := ValidatePassword(reg.Password, reg.Email, reg.FullName); err != nil {
 		return nil, err
 	}
 
@@ -94,11 +102,11 @@ func CreateUser(db *sql.DB, reg UserRegistration) (*User, error) {
 	query := `
 		INSERT INTO users (email, password_hash, full_name)
 		VALUES ($1, $2, $3)
-		RETURNING id, email, full_name, created_at, updated_at, is_active
+		RETURNING id, email, full_name, created_at, updated_at, is_active, token_version, role
 	`
 	err = db.QueryRow(query, reg.Email, hashedPassword, reg.FullName).Scan(
 		&user.ID, &user.Email, &user.FullName,
-		&user.CreatedAt, &user.UpdatedAt, &user.IsActive,
+		&user.CreatedAt, &user.UpdatedAt, &user.IsActive, &user.TokenVersion, &user.Role,
 	)
 
 	if err != nil {
@@ -115,17 +123,44 @@ func CreateUser(db *sql.DB, reg UserRegistration) (*User, error) {
 func GetUserByEmail(db *sql.DB, email string) (*User, error) {
 	var user User
 	query := `
-		SELECT id, email, password_hash, full_name, created_at, updated_at, last_login, is_active
+		SELECT id, email, password_hash, full_name, created_at, updated_at, last_login, is_active,
+		       COALESCE(totp_secret, ''), COALESCE(totp_enabled, false), token_version, role
 		FROM users
 		WHERE email = $1 AND is_active = true
 	`
 	err := db.QueryRow(query, email).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.FullName,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.IsActive,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TokenVersion, &user.Role,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Buscar usuário por ID
+func GetUserByID(db *sql.DB, userID string) (*User, error) {
+	var user User
+	query := `
+		SELECT id, email, password_hash, full_name, created_at, updated_at, last_login, is_active,
+		       COALESCE(totp_secret, ''), COALESCE(totp_enabled, false), token_version, role
+		FROM users
+		WHERE id = $1 AND is_active = true
+	`
+	err := db.QueryRow(query, userID).Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.FullName,
+		&user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.IsActive,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TokenVersion, &user.Role,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("usuário não encontrado")
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -140,3 +175,26 @@ func UpdateLastLogin(db *sql.DB, userID string) error {
 	_, err := db.Exec(query, time.Now(), userID)
 	return err
 }
+
+// Consulta de contas do AuthMiddleware no PostgreSQL
+type AccountStore struct {
+	db *sql.DB
+}
+
+func NewAccountStore(db *sql.DB) *AccountStore {
+	return &AccountStore{db: db}
+}
+
+// Situação da conta (nil se não existir)
+func (s *AccountStore) Account(userID string) (*store.Account, error) {
+	var account store.Account
+	query := `SELECT is_active AND deleted_at IS NULL, token_version, role FROM users WHERE id = $1`
+	err := s.db.QueryRow(query, userID).Scan(&account.Active, &account.TokenVersion, &account.Role)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
@@ -0,0 +1,362 @@
+// Arquivo: backend/oidc/oidc.go
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Configuração de um provedor OpenID Connect (Google, Apple, Keycloak...)
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Documento /.well-known/openid-configuration (campos usados)
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims relevantes do ID token
+type IDTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Parâmetros de uma tentativa de login (guardados até o callback)
+type AuthFlow struct {
+	Provider     string
+	State        string
+	Nonce        string
+	CodeVerifier string
+	UserID       string // usuário logado se reautenticando; vazio = login
+	StartedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Provedor com discovery e chaves JWKS em cache
+type Provider struct {
+	Config     ProviderConfig
+	HTTPClient *http.Client
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+const (
+	FlowTTL      = 10 * time.Minute
+	authTimeSkew = time.Minute
+	jwksMaxAge   = time.Hour
+	httpTimeout  = 10 * time.Second
+)
+
+func NewProvider(cfg ProviderConfig) *Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		Config:     cfg,
+		HTTPClient: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Buscar (e manter em cache) o documento de discovery do emissor
+func (p *Provider) Discover() (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimRight(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	var d Discovery
+	if err := p.getJSON(wellKnown, &d); err != nil {
+		return nil, fmt.Errorf("erro no discovery OIDC: %v", err)
+	}
+	if d.Issuer != p.Config.Issuer {
+		return nil, fmt.Errorf("issuer do discovery (%s) difere do configurado", d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("documento de discovery incompleto")
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// Iniciar login: gera state, nonce e PKCE e devolve a URL de autorização
+func (p *Provider) BeginAuth() (*AuthFlow, string, error) {
+	return p.begin("")
+}
+
+// Iniciar reautenticação do usuário logado: pede ao provedor um login novo
+// (prompt=login, max_age=0) em vez de reaproveitar a sessão dele
+func (p *Provider) BeginReauth(userID string) (*AuthFlow, string, error) {
+	return p.begin(userID)
+}
+
+func (p *Provider) begin(userID string) (*AuthFlow, string, error) {
+	d, err := p.Discover()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	flow := &AuthFlow{
+		Provider:     p.Config.Name,
+		State:        randomString(32),
+		Nonce:        randomString(32),
+		CodeVerifier: randomString(48),
+		UserID:       userID,
+		StartedAt:    now,
+		ExpiresAt:    now.Add(FlowTTL),
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.Config.ClientID)
+	params.Set("redirect_uri", p.Config.RedirectURL)
+	params.Set("scope", strings.Join(p.Config.Scopes, " "))
+	params.Set("state", flow.State)
+	params.Set("nonce", flow.Nonce)
+	params.Set("code_challenge", CodeChallengeS256(flow.CodeVerifier))
+	params.Set("code_challenge_method", "S256")
+	if userID != "" {
+		params.Set("prompt", "login")
+		params.Set("max_age", "0")
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return flow, d.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Trocar o código de autorização pelo ID token e validá-lo
+func (p *Provider) Exchange(code string, flow *AuthFlow) (*IDTokenClaims, error) {
+	d, err := p.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.Config.RedirectURL)
+	form.Set("client_id", p.Config.ClientID)
+	form.Set("code_verifier", flow.CodeVerifier)
+	if p.Config.ClientSecret != "" {
+		form.Set("client_secret", p.Config.ClientSecret)
+	}
+
+	resp, err := p.HTTPClient.PostForm(d.TokenEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint retornou %d: %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, err
+	}
+	if tokenResp.IDToken == "" {
+		return nil, errors.New("resposta sem id_token")
+	}
+
+	claims, err := p.VerifyIDToken(tokenResp.IDToken, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Na reautenticação o login no provedor tem que ser posterior ao início do fluxo
+	if flow.UserID != "" {
+		if claims.AuthTime == 0 || time.Unix(claims.AuthTime, 0).Before(flow.StartedAt.Add(-authTimeSkew)) {
+			return nil, errors.New("provedor não confirmou um login recente")
+		}
+	}
+	return claims, nil
+}
+
+// Validar assinatura (JWKS), issuer, audience, expiração e nonce do ID token
+func (p *Provider) VerifyIDToken(rawToken, nonce string) (*IDTokenClaims, error) {
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.Config.Issuer),
+		jwt.WithAudience(p.Config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID token inválido: %v", err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("nonce do ID token não confere")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID token sem subject")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := p.publicKey(kid, false)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		// Chave desconhecida: o provedor pode ter rotacionado as chaves
+		key, err = p.publicKey(kid, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("chave %q não encontrada no JWKS", kid)
+	}
+	return key, nil
+}
+
+func (p *Provider) publicKey(kid string, refresh bool) (*rsa.PublicKey, error) {
+	d, err := p.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if refresh || p.keys == nil || time.Since(p.keysAt) > jwksMaxAge {
+		keys, err := p.fetchJWKS(d.JWKSURI)
+		if err != nil {
+			return nil, err
+		}
+		p.keys = keys
+		p.keysAt = time.Now()
+	}
+
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, nil
+		}
+	}
+	return p.keys[kid], nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (p *Provider) fetchJWKS(jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("erro ao buscar JWKS: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (p *Provider) getJSON(u string, v interface{}) error {
+	resp, err := p.HTTPClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s retornou %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// Converter chave pública RSA para JWK (usado pelo servidor mock)
+func RSAPublicJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// code_challenge = BASE64URL(SHA256(code_verifier)) (RFC 7636)
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand não deve falhar
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,96 @@
+// Arquivo: backend/oidc/registry.go
+package oidc
+
+import (
+	"finplay/backend/config"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]*Provider{}
+)
+
+// Registrar provedor (substitui um existente com o mesmo nome)
+func Register(p *Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Config.Name] = p
+}
+
+// Buscar provedor pelo nome (ex.: "google")
+func Get(name string) (*Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Nomes dos provedores configurados
+func Names() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Registrar os provedores configurados (validados em config.Validate)
+func LoadProviders(list []config.OIDCProviderConfig) {
+	for _, p := range list {
+		Register(NewProvider(ProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+		}))
+		slog.Info("provedor OIDC configurado", "provider", p.Name, "issuer", p.Issuer)
+	}
+}
+
+// Armazenamento em memória das tentativas de login em andamento, por state
+type FlowStore struct {
+	mu    sync.Mutex
+	flows map[string]*AuthFlow
+}
+
+func NewFlowStore() *FlowStore {
+	return &FlowStore{flows: make(map[string]*AuthFlow)}
+}
+
+var Flows = NewFlowStore()
+
+func (s *FlowStore) Save(flow *AuthFlow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Limpeza oportunista de tentativas expiradas
+	now := time.Now()
+	for state, f := range s.flows {
+		if now.After(f.ExpiresAt) {
+			delete(s.flows, state)
+		}
+	}
+	s.flows[flow.State] = flow
+}
+
+// Recuperar e remover (o state só pode ser usado uma vez)
+func (s *FlowStore) Take(state string) (*AuthFlow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flow, ok := s.flows[state]
+	if !ok {
+		return nil, false
+	}
+	delete(s.flows, state)
+	if time.Now().After(flow.ExpiresAt) {
+		return nil, false
+	}
+	return flow, true
+}
@@ -0,0 +1,51 @@
+// Arquivo: backend/openapi/docs.go
+package openapi
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+)
+
+// Versão do Swagger UI carregada do CDN
+const swaggerUIVersion = "5.17.14"
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>FinPlay API - Documentação</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js"></script>
+  <script nonce="{{.Nonce}}">
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+
+// GET /api/docs - Documentação interativa (Swagger UI)
+func HandleDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+	n := base64.StdEncoding.EncodeToString(nonce)
+
+	// Substitui a CSP restritiva da API: libera o CDN e o script inline com nonce
+	w.Header().Set("Content-Security-Policy",
+		"default-src 'none'; script-src 'nonce-"+n+"' https://unpkg.com; style-src https://unpkg.com; "+
+			"img-src 'self' data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	docsTemplate.Execute(w, struct{ Version, Nonce string }{swaggerUIVersion, n})
+}
@@ -0,0 +1,93 @@
+// Arquivo: backend/openapi/openapi.go
+
+// Especificação OpenAPI 3 da API, servida em /api/openapi.json com uma página
+// de documentação em /api/docs, e validação de requisições contra o contrato.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Documento carregado. O mapa genérico vem de um round-trip JSON, então os
+// tipos são sempre os de encoding/json (map[string]any, []any, float64...).
+type Spec struct {
+	doc  map[string]any
+	json []byte
+}
+
+// Carregar a especificação embutida
+func Load() (*Spec, error) {
+	var raw any
+	if err := yaml.Unmarshal(specYAML, &raw); err != nil {
+		return nil, fmt.Errorf("openapi.yaml inválido: %w", err)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("openapi.yaml não pode ser convertido para JSON: %w", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if _, ok := doc["paths"].(map[string]any); !ok {
+		return nil, fmt.Errorf("openapi.yaml sem paths")
+	}
+
+	return &Spec{doc: doc, json: data}, nil
+}
+
+// GET /api/openapi.json - Documento OpenAPI em JSON
+func (s *Spec) HandleJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método não permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.json)
+}
+
+// Resolver "$ref" locais (#/components/...)
+func (s *Spec) resolve(node map[string]any) map[string]any {
+	for i := 0; i < 10; i++ { // limite contra ciclos
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		node = s.lookup(ref)
+		if node == nil {
+			return map[string]any{}
+		}
+	}
+	return node
+}
+
+func (s *Spec) lookup(ref string) map[string]any {
+	if len(ref) < 2 || ref[:2] != "#/" {
+		return nil
+	}
+	var cur any = s.doc
+	start := 2
+	for i := 2; i <= len(ref); i++ {
+		if i < len(ref) && ref[i] != '/' {
+			continue
+		}
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[ref[start:i]]
+		start = i + 1
+	}
+	node, _ := cur.(map[string]any)
+	return node
+}
@@ -0,0 +1,464 @@
+// Arquivo: backend/openapi/validate.go
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Divergência entre a requisição e a especificação
+type Violation struct {
+	Location string `json:"location"` // ex.: query.id, body.items[0].quantity
+	Message  string `json:"message"`
+}
+
+type validationErrorResponse struct {
+	Error      string      `json:"error"`
+	Violations []Violation `json:"violations"`
+}
+
+// Middleware que rejeita (400) requisições fora do contrato. Pensado para
+// desenvolvimento: pega divergências entre frontend e backend cedo. Rotas e
+// métodos não documentados seguem para o handler sem validação.
+func (s *Spec) Validator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := s.operation(r.URL.Path, r.Method)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			status := http.StatusBadRequest
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeJSONError(w, status, "Erro ao ler corpo da requisição")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		violations := s.validateRequest(r, op, body)
+		if len(violations) > 0 {
+			slog.WarnContext(r.Context(), "requisição fora da especificação OpenAPI",
+				"method", r.Method, "path", r.URL.Path, "violations", violations)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(validationErrorResponse{
+				Error:      "Requisição não corresponde à especificação da API",
+				Violations: violations,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Operação documentada para path+método (nil se não houver). Paths com
+// parâmetros (ex.: /api/orders/{id}/receipt) casam segmento a segmento.
+func (s *Spec) operation(path, method string) *operation {
+	paths := s.doc["paths"].(map[string]any)
+	item, ok := paths[path].(map[string]any)
+	var pathValues map[string]string
+	if !ok {
+		for template, node := range paths {
+			if values, match := matchPath(template, path); match {
+				item, _ = node.(map[string]any)
+				pathValues = values
+				break
+			}
+		}
+		if item == nil {
+			return nil
+		}
+	}
+	item = s.resolve(item)
+	op, ok := item[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	// Parâmetros do path valem para todas as operações; os da operação têm precedência
+	params := map[string]map[string]any{}
+	for _, list := range []any{item["parameters"], op["parameters"]} {
+		items, _ := list.([]any)
+		for _, p := range items {
+			pm, ok := p.(map[string]any)
+			if !ok {
+				continue
+			}
+			pm = s.resolve(pm)
+			name, _ := pm["name"].(string)
+			in, _ := pm["in"].(string)
+			params[in+"."+name] = pm
+		}
+	}
+
+	return &operation{node: op, params: params, pathValues: pathValues}
+}
+
+// Casar um path com parâmetros ({nome}) e extrair os valores
+func matchPath(template, path string) (map[string]string, bool) {
+	if !strings.Contains(template, "{") {
+		return nil, false
+	}
+	want := strings.Split(template, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	values := map[string]string{}
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if got[i] == "" {
+				return nil, false
+			}
+			values[segment[1:len(segment)-1]] = got[i]
+			continue
+		}
+		if segment != got[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+type operation struct {
+	node       map[string]any
+	params     map[string]map[string]any // chave: "in.nome"
+	pathValues map[string]string
+}
+
+func (s *Spec) validateRequest(r *http.Request, op *operation, body []byte) []Violation {
+	var v validator
+	v.spec = s
+
+	// Parâmetros de path e query (parâmetros extras são tolerados)
+	keys := make([]string, 0, len(op.params))
+	for key := range op.params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := r.URL.Query()
+	for _, key := range keys {
+		p := op.params[key]
+		if p["in"] == "path" {
+			name, _ := p["name"].(string)
+			schema, _ := p["schema"].(map[string]any)
+			v.value(s.resolve(schema), op.pathValues[name], "path."+name)
+			continue
+		}
+		if p["in"] != "query" {
+			continue
+		}
+		name, _ := p["name"].(string)
+		loc := "query." + name
+
+		if !query.Has(name) {
+			if required, _ := p["required"].(bool); required {
+				v.fail(loc, "parâmetro obrigatório ausente")
+			}
+			continue
+		}
+
+		schema, _ := p["schema"].(map[string]any)
+		v.value(s.resolve(schema), query.Get(name), loc)
+	}
+
+	// Corpo JSON
+	rb, ok := op.node["requestBody"].(map[string]any)
+	if !ok {
+		return v.violations
+	}
+	rb = s.resolve(rb)
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required, _ := rb["required"].(bool); required {
+			v.fail("body", "corpo obrigatório ausente")
+		}
+		return v.violations
+	}
+
+	content, _ := rb["content"].(map[string]any)
+	media, ok := content["application/json"].(map[string]any)
+	if !ok {
+		return v.violations
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		v.fail("header.Content-Type", "esperado application/json")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		v.fail("body", "JSON malformado")
+		return v.violations
+	}
+
+	schema, _ := media["schema"].(map[string]any)
+	v.schema(schema, data, "body")
+	return v.violations
+}
+
+type validator struct {
+	spec       *Spec
+	violations []Violation
+}
+
+func (v *validator) fail(loc, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Location: loc, Message: fmt.Sprintf(format, args...)})
+}
+
+// Converter o valor textual de um parâmetro conforme o tipo do schema
+func (v *validator) value(schema map[string]any, raw, loc string) {
+	var data any = raw
+	switch schema["type"] {
+	case "integer", "number":
+		data = json.Number(raw)
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			v.fail(loc, "esperado número")
+			return
+		}
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			v.fail(loc, "esperado booleano")
+			return
+		}
+		data = b
+	}
+	v.schema(schema, data, loc)
+}
+
+// Validar dado contra o subconjunto de JSON Schema usado em openapi.yaml:
+// type, nullable, enum, required, properties, additionalProperties, items,
+// min/maxLength, min/maxItems, minimum/maximum, pattern, format e oneOf.
+func (v *validator) schema(schema map[string]any, data any, loc string) {
+	if schema == nil {
+		return
+	}
+	schema = v.spec.resolve(schema)
+
+	if data == nil {
+		if nullable, _ := schema["nullable"].(bool); !nullable {
+			v.fail(loc, "não pode ser null")
+		}
+		return
+	}
+
+	if options, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		for _, option := range options {
+			sub := validator{spec: v.spec}
+			sub.schema(asMap(option), data, loc)
+			if len(sub.violations) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(loc, "deve corresponder a exatamente um dos formatos permitidos")
+		}
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !inEnum(enum, data) {
+		v.fail(loc, "valor fora do permitido %v", enum)
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := data.(map[string]any)
+		if !ok {
+			v.fail(loc, "esperado objeto")
+			return
+		}
+		v.object(schema, obj, loc)
+
+	case "array":
+		arr, ok := data.([]any)
+		if !ok {
+			v.fail(loc, "esperado array")
+			return
+		}
+		if min, ok := number(schema["minItems"]); ok && float64(len(arr)) < min {
+			v.fail(loc, "mínimo de %v itens", min)
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(arr)) > max {
+			v.fail(loc, "máximo de %v itens", max)
+		}
+		items := asMap(schema["items"])
+		for i, item := range arr {
+			v.schema(items, item, fmt.Sprintf("%s[%d]", loc, i))
+		}
+
+	case "string":
+		str, ok := data.(string)
+		if !ok {
+			v.fail(loc, "esperado string")
+			return
+		}
+		v.string(schema, str, loc)
+
+	case "integer", "number":
+		n, ok := data.(json.Number)
+		if !ok {
+			v.fail(loc, "esperado número")
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			v.fail(loc, "número inválido")
+			return
+		}
+		if schema["type"] == "integer" {
+			if _, err := n.Int64(); err != nil {
+				v.fail(loc, "esperado inteiro")
+				return
+			}
+		}
+		if min, ok := number(schema["minimum"]); ok && f < min {
+			v.fail(loc, "mínimo %v", min)
+		}
+		if max, ok := number(schema["maximum"]); ok && f > max {
+			v.fail(loc, "máximo %v", max)
+		}
+
+	case "boolean":
+		if _, ok := data.(bool); !ok {
+			v.fail(loc, "esperado booleano")
+		}
+	}
+}
+
+func (v *validator) object(schema, obj map[string]any, loc string) {
+	required, _ := schema["required"].([]any)
+	for _, r := range required {
+		name, _ := r.(string)
+		if _, ok := obj[name]; !ok {
+			v.fail(loc+"."+name, "campo obrigatório ausente")
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop, ok := props[name]; ok {
+			v.schema(asMap(prop), obj[name], loc+"."+name)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(loc+"."+name, "campo não documentado")
+			}
+		case map[string]any:
+			v.schema(extra, obj[name], loc+"."+name)
+		}
+	}
+}
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	patterns    sync.Map // cache de regexp por padrão
+)
+
+func (v *validator) string(schema map[string]any, s, loc string) {
+	length := float64(utf8.RuneCountInString(s))
+	if min, ok := number(schema["minLength"]); ok && length < min {
+		v.fail(loc, "mínimo de %v caracteres", min)
+	}
+	if max, ok := number(schema["maxLength"]); ok && length > max {
+		v.fail(loc, "máximo de %v caracteres", max)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := compilePattern(pattern)
+		if err == nil && !re.MatchString(s) {
+			v.fail(loc, "formato inválido")
+		}
+	}
+
+	switch schema["format"] {
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			v.fail(loc, "esperado UUID")
+		}
+	case "email":
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			v.fail(loc, "esperado email")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(loc, "esperado data/hora RFC 3339")
+		}
+	}
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func number(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func inEnum(enum []any, data any) bool {
+	if n, ok := data.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		data = f
+	}
+	for _, e := range enum {
+		if e == data {
+			return true
+		}
+	}
+	return false
+}
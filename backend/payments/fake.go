@@ -0,0 +1,183 @@
+// Arquivo: backend/payments/fake.go
+package payments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Meios de pagamento reconhecidos pelo gateway falso. Qualquer outro valor
+// não vazio é aprovado.
+const (
+	FakeApprove           = "fake_approve"
+	FakeDecline           = "fake_decline"            // card_declined
+	FakeInsufficientFunds = "fake_insufficient_funds" // insufficient_funds
+	FakeExpiredCard       = "fake_expired_card"       // expired_card
+	FakeDelay             = "fake_delay"              // aprovado após FakeOptions.SlowDelay
+	FakeUnavailable       = "fake_unavailable"        // erro temporário do gateway
+)
+
+type FakeOptions struct {
+	// Latência aplicada a todas as operações
+	Latency time.Duration
+	// Atraso extra da autorização com FakeDelay (padrão: 3s)
+	SlowDelay time.Duration
+}
+
+// Gateway em memória para desenvolvimento e testes. Simula aprovações,
+// recusas e atrasos conforme o meio de pagamento e valida as transições
+// (captura só de autorizadas, estorno até o valor capturado, etc.).
+type FakeGateway struct {
+	opts FakeOptions
+
+	mu           sync.Mutex
+	transactions map[string]*fakeTransaction
+}
+
+type fakeTransaction struct {
+	authorized int64
+	captured   int64
+	refunded   int64
+	voided     bool
+}
+
+func NewFake(opts FakeOptions) *FakeGateway {
+	if opts.SlowDelay <= 0 {
+		opts.SlowDelay = 3 * time.Second
+	}
+	return &FakeGateway{
+		opts:         opts,
+		transactions: make(map[string]*fakeTransaction),
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error) {
+	delay := g.opts.Latency
+	method := strings.TrimSpace(req.PaymentMethod)
+	if method == FakeDelay {
+		delay += g.opts.SlowDelay
+	}
+	if err := g.wait(ctx, delay); err != nil {
+		return nil, err
+	}
+
+	if req.AmountCents <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	ref := "fake_" + randomHex(12)
+	switch method {
+	case "":
+		return &Result{Reference: ref, DeclineCode: "invalid_payment_method", Message: "Meio de pagamento ausente"}, nil
+	case FakeDecline:
+		return &Result{Reference: ref, DeclineCode: "card_declined", Message: "Cartão recusado"}, nil
+	case FakeInsufficientFunds:
+		return &Result{Reference: ref, DeclineCode: "insufficient_funds", Message: "Saldo insuficiente"}, nil
+	case FakeExpiredCard:
+		return &Result{Reference: ref, DeclineCode: "expired_card", Message: "Cartão expirado"}, nil
+	case FakeUnavailable:
+		return nil, &UnavailableError{Gateway: g.Name(), Err: errors.New("serviço temporariamente indisponível")}
+	}
+
+	g.mu.Lock()
+	g.transactions[ref] = &fakeTransaction{authorized: req.AmountCents}
+	g.mu.Unlock()
+
+	return &Result{Reference: ref, Approved: true}, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amountCents int64) (*Result, error) {
+	if err := g.wait(ctx, g.opts.Latency); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tx, ok := g.transactions[reference]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if tx.voided || tx.captured > 0 {
+		return nil, ErrInvalidState
+	}
+	if amountCents <= 0 || amountCents > tx.authorized {
+		return nil, ErrInvalidAmount
+	}
+	tx.captured = amountCents
+
+	return &Result{Reference: reference, Approved: true}, nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amountCents int64) (*Result, error) {
+	if err := g.wait(ctx, g.opts.Latency); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tx, ok := g.transactions[reference]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if tx.captured == 0 {
+		return nil, ErrInvalidState
+	}
+	if amountCents <= 0 || tx.refunded+amountCents > tx.captured {
+		return nil, ErrInvalidAmount
+	}
+	tx.refunded += amountCents
+
+	return &Result{Reference: reference, Approved: true}, nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) (*Result, error) {
+	if err := g.wait(ctx, g.opts.Latency); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tx, ok := g.transactions[reference]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if tx.captured > 0 {
+		return nil, ErrInvalidState
+	}
+	tx.voided = true
+
+	return &Result{Reference: reference, Approved: true}, nil
+}
+
+// Simular latência respeitando o cancelamento da requisição
+func (g *FakeGateway) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return &UnavailableError{Gateway: g.Name(), Err: ctx.Err()}
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
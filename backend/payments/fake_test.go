@@ -0,0 +1,137 @@
+// Arquivo: backend/payments/fake_test.go
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeGatewayAuthorize(t *testing.T) {
+	tests := []struct {
+		method      string
+		amount      int64
+		wantErr     error
+		wantApprove bool
+		wantDecline string
+	}{
+		{FakeApprove, 1000, nil, true, ""},
+		{"pm_qualquer", 1000, nil, true, ""},
+		{"", 1000, nil, false, "invalid_payment_method"},
+		{FakeDecline, 1000, nil, false, "card_declined"},
+		{FakeInsufficientFunds, 1000, nil, false, "insufficient_funds"},
+		{FakeExpiredCard, 1000, nil, false, "expired_card"},
+		{FakeApprove, 0, ErrInvalidAmount, false, ""},
+	}
+
+	g := NewFake(FakeOptions{})
+	for _, tt := range tests {
+		res, err := g.Authorize(context.Background(), AuthorizeRequest{AmountCents: tt.amount, PaymentMethod: tt.method})
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%q/%d: erro %v, esperado %v", tt.method, tt.amount, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if res.Approved != tt.wantApprove || res.DeclineCode != tt.wantDecline {
+			t.Errorf("%q: Approved=%v DeclineCode=%q, esperado %v %q",
+				tt.method, res.Approved, res.DeclineCode, tt.wantApprove, tt.wantDecline)
+		}
+		if res.Reference == "" {
+			t.Errorf("%q: sem referência", tt.method)
+		}
+	}
+}
+
+func TestFakeGatewayUnavailable(t *testing.T) {
+	g := NewFake(FakeOptions{})
+	_, err := g.Authorize(context.Background(), AuthorizeRequest{AmountCents: 1000, PaymentMethod: FakeUnavailable})
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || unavailable.Gateway != "fake" {
+		t.Fatalf("erro %v, esperado UnavailableError do gateway fake", err)
+	}
+
+	// Latência maior que o prazo da requisição também é indisponibilidade
+	slow := NewFake(FakeOptions{SlowDelay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = slow.Authorize(ctx, AuthorizeRequest{AmountCents: 1000, PaymentMethod: FakeDelay})
+	if !errors.As(err, &unavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("erro %v, esperado UnavailableError com DeadlineExceeded", err)
+	}
+}
+
+// Sequências de operações sobre uma autorização de R$ 10,00
+func TestFakeGatewayTransitions(t *testing.T) {
+	type op struct {
+		kind   string // capture, refund, void
+		amount int64
+		want   error
+	}
+	tests := []struct {
+		name string
+		ops  []op
+	}{
+		{"captura total e estornos parciais", []op{
+			{"capture", 1000, nil},
+			{"refund", 400, nil},
+			{"refund", 600, nil},
+			{"refund", 1, ErrInvalidAmount},
+		}},
+		{"captura parcial limita o estorno", []op{
+			{"capture", 700, nil},
+			{"refund", 800, ErrInvalidAmount},
+			{"refund", 700, nil},
+		}},
+		{"captura acima do autorizado", []op{
+			{"capture", 1001, ErrInvalidAmount},
+			{"capture", 0, ErrInvalidAmount},
+		}},
+		{"captura só uma vez", []op{
+			{"capture", 500, nil},
+			{"capture", 500, ErrInvalidState},
+		}},
+		{"estorno exige captura", []op{
+			{"refund", 100, ErrInvalidState},
+		}},
+		{"cancelamento impede captura", []op{
+			{"void", 0, nil},
+			{"capture", 1000, ErrInvalidState},
+		}},
+		{"cancelamento após captura", []op{
+			{"capture", 1000, nil},
+			{"void", 0, ErrInvalidState},
+		}},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewFake(FakeOptions{})
+			res, err := g.Authorize(ctx, AuthorizeRequest{AmountCents: 1000, PaymentMethod: FakeApprove})
+			if err != nil || !res.Approved {
+				t.Fatalf("Authorize: %v %+v", err, res)
+			}
+			for i, o := range tt.ops {
+				switch o.kind {
+				case "capture":
+					_, err = g.Capture(ctx, res.Reference, o.amount)
+				case "refund":
+					_, err = g.Refund(ctx, res.Reference, o.amount)
+				case "void":
+					_, err = g.Void(ctx, res.Reference)
+				}
+				if !errors.Is(err, o.want) {
+					t.Fatalf("#%d %s(%d): erro %v, esperado %v", i, o.kind, o.amount, err, o.want)
+				}
+			}
+		})
+	}
+
+	g := NewFake(FakeOptions{})
+	if _, err := g.Capture(ctx, "fake_inexistente", 100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Capture de referência desconhecida: %v, esperado ErrNotFound", err)
+	}
+}
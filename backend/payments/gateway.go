@@ -0,0 +1,66 @@
+// Arquivo: backend/payments/gateway.go
+
+// Integração com gateways de pagamento. O Gateway abstrai o provedor (cartão,
+// carteira, etc.); a persistência fica em models e a orquestração com os
+// pedidos em service.go.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Erros de uso do gateway (não são recusas do emissor)
+var (
+	ErrNotFound      = errors.New("transação não encontrada no gateway")
+	ErrInvalidState  = errors.New("operação não permitida no estado atual da transação")
+	ErrInvalidAmount = errors.New("valor inválido para a operação")
+)
+
+// Gateway de pagamento. Valores sempre em centavos na moeda da autorização.
+//
+// Uma recusa (cartão recusado, saldo insuficiente...) não é erro: vem em
+// Result.Approved = false com o código em DeclineCode. Erros indicam falha de
+// comunicação ou uso inválido e podem ser tentados de novo quando temporários.
+type Gateway interface {
+	// Nome gravado em payments.gateway
+	Name() string
+	// Reservar o valor no meio de pagamento
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error)
+	// Efetivar a cobrança de uma autorização (total ou parcial)
+	Capture(ctx context.Context, reference string, amountCents int64) (*Result, error)
+	// Devolver valor já capturado (total ou parcial)
+	Refund(ctx context.Context, reference string, amountCents int64) (*Result, error)
+	// Liberar uma autorização ainda não capturada
+	Void(ctx context.Context, reference string) (*Result, error)
+}
+
+type AuthorizeRequest struct {
+	OrderID     string
+	AmountCents int64
+	Currency    string
+	// Token do meio de pagamento gerado no frontend pelo SDK do provedor
+	PaymentMethod string
+}
+
+type Result struct {
+	Reference   string // identificador da transação no gateway
+	Approved    bool
+	DeclineCode string // ex.: card_declined, insufficient_funds
+	Message     string
+}
+
+// Falha temporária do gateway (timeout, indisponibilidade)
+type UnavailableError struct {
+	Gateway string
+	Err     error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("gateway %s indisponível: %v", e.Gateway, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,198 @@
+// Arquivo: backend/payments/pix.go
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"finplay/backend/metrics"
+	"finplay/backend/models"
+	"finplay/backend/payments/pix"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Nome gravado em payments.gateway para cobranças PIX
+const PixGateway = "pix"
+
+var ErrPixDisabled = errors.New("PIX não configurado")
+
+// API PIX do PSP: devolução de PIX recebido e remoção de cobrança não paga
+// (implementada por pix.Client)
+type PixPSP interface {
+	Refund(ctx context.Context, endToEndID, id string, amountCents int64) (*pix.Devolution, error)
+	RemoveCharge(ctx context.Context, txid string) error
+}
+
+// PSP configurado (PIX_PSP_URL); nil desativa estorno e remoção no PSP
+var pixPSP PixPSP
+
+// Substituir o PSP (testes e integrações)
+func SetPixPSP(p PixPSP) {
+	pixPSP = p
+}
+
+func PixEnabled() bool {
+	return settings.PIX.Enabled()
+}
+
+// Segredo para verificar as notificações do PSP
+func PixWebhookSecret() string {
+	return settings.PIX.WebhookSecret
+}
+
+// Gerar (ou reaproveitar, se ainda válida) a cobrança PIX do total do pedido
+func CreatePixCharge(ctx context.Context, db *sql.DB, order *models.Order) (*models.PixCharge, error) {
+	if !PixEnabled() {
+		return nil, ErrPixDisabled
+	}
+	if order.Status != models.OrderPending {
+		return nil, models.ErrOrderNotPayable
+	}
+
+	if _, err := models.ExpirePixCharges(db); err != nil {
+		return nil, err
+	}
+	active, err := models.GetActivePixCharge(db, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil && active.AmountCents == order.TotalCents {
+		return active, nil
+	}
+
+	txid := pix.NewTxID()
+	payload, err := pix.Payload{
+		Key:          settings.PIX.Key,
+		MerchantName: settings.PIX.MerchantName,
+		MerchantCity: settings.PIX.MerchantCity,
+		AmountCents:  order.TotalCents,
+		TxID:         txid,
+		Description:  "Pedido FinPlay",
+	}.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	charge := &models.PixCharge{
+		TxID:      txid,
+		Payload:   payload,
+		ExpiresAt: time.Now().Add(settings.PIX.ChargeTTL),
+	}
+	err = models.CreatePixCharge(db, &models.Payment{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Gateway:     PixGateway,
+		GatewayRef:  txid,
+		AmountCents: order.TotalCents,
+		Currency:    settings.Currency,
+	}, charge)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.PaymentsTotal.Inc(PixGateway, "charge", "approved")
+	return charge, nil
+}
+
+// Processar uma transferência notificada pelo PSP. Retorna erro apenas para
+// falhas que justificam o PSP reenviar a notificação.
+func ConfirmPix(ctx context.Context, db *sql.DB, t pix.Transfer) error {
+	amount, err := t.AmountCents()
+	if err != nil {
+		slog.WarnContext(ctx, "PIX com valor inválido", "txid", t.TxID, "valor", t.Valor)
+		return nil
+	}
+	paidAt := t.Horario
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+
+	charge, err := models.ConfirmPixTransfer(db, t.TxID, t.EndToEndID, amount, paidAt)
+	switch {
+	case err == nil:
+		if charge.Status == models.PaymentCaptured {
+			metrics.PaymentsTotal.Inc(PixGateway, "capture", "approved")
+			slog.InfoContext(ctx, "PIX recebido", "txid", t.TxID, "order_id", charge.OrderID, "payment_id", charge.PaymentID)
+		}
+		return nil
+	case errors.Is(err, models.ErrPixChargeNotFound):
+		slog.WarnContext(ctx, "PIX recebido para cobrança desconhecida", "txid", t.TxID, "end_to_end_id", t.EndToEndID)
+		return nil
+	case errors.Is(err, models.ErrPixAmountMismatch):
+		metrics.PaymentsTotal.Inc(PixGateway, "capture", "declined")
+		slog.ErrorContext(ctx, "PIX recebido com valor divergente; estorno necessário",
+			"txid", t.TxID, "order_id", charge.OrderID, "payment_id", charge.PaymentID,
+			"end_to_end_id", t.EndToEndID, "expected_cents", charge.AmountCents, "received_cents", amount)
+		return nil
+	case errors.Is(err, models.ErrOrderState):
+		metrics.PaymentsTotal.Inc(PixGateway, "capture", "declined")
+		slog.ErrorContext(ctx, "PIX recebido para pedido que não aguarda pagamento; estorno necessário",
+			"txid", t.TxID, "order_id", charge.OrderID, "payment_id", charge.PaymentID)
+		return nil
+	}
+	return err
+}
+
+// Devolver um PIX recebido (total ou parcial) pelo endToEndId registrado na
+// confirmação. Retorna o id da devolução.
+func refundPix(ctx context.Context, db *sql.DB, p *models.Payment, amountCents int64) (string, error) {
+	if pixPSP == nil {
+		return "", fmt.Errorf("%w: PSP PIX não configurado", ErrUnsupported)
+	}
+	charge, err := models.GetPixChargeByPayment(db, p.ID)
+	if err != nil {
+		return "", err
+	}
+	if charge.EndToEndID == "" {
+		return "", models.ErrPaymentState
+	}
+	return devolvePix(ctx, charge.EndToEndID, p.ID, p.RefundedCents, amountCents)
+}
+
+// Pedir a devolução ao PSP. O id deriva do pagamento e do valor já estornado,
+// então repetir após uma falha não devolve duas vezes. Recusas do PSP viram
+// ErrRefundRejected; falhas de comunicação, UnavailableError.
+func devolvePix(ctx context.Context, endToEndID, paymentID string, refundedCents, amountCents int64) (string, error) {
+	d, err := pixPSP.Refund(ctx, endToEndID, pix.DevolutionID(paymentID, refundedCents), amountCents)
+	switch {
+	case pix.IsRejection(err):
+		metrics.PaymentsTotal.Inc(PixGateway, "refund", "declined")
+		return "", fmt.Errorf("%w: %v", ErrRefundRejected, err)
+	case err != nil:
+		metrics.PaymentsTotal.Inc(PixGateway, "refund", "error")
+		return "", &UnavailableError{Gateway: PixGateway, Err: err}
+	case d.Status == pix.DevolutionRejected:
+		metrics.PaymentsTotal.Inc(PixGateway, "refund", "declined")
+		return "", fmt.Errorf("%w: %s", ErrRefundRejected, d.Motivo)
+	}
+	// Mesmo id com outro valor: devolução de uma tentativa anterior diferente
+	if v, err := d.AmountCents(); err != nil || v != amountCents {
+		metrics.PaymentsTotal.Inc(PixGateway, "refund", "declined")
+		return "", fmt.Errorf("%w: devolução %s já existe com valor %s", ErrRefundRejected, d.ID, d.Valor)
+	}
+	metrics.PaymentsTotal.Inc(PixGateway, "refund", "approved")
+	return d.ID, nil
+}
+
+// Remover a cobrança ainda não paga. Sem PSP configurado, só o registro muda:
+// um PIX que chegue depois é registrado sem pagar o pedido (ver ConfirmPix).
+func voidPix(ctx context.Context, db *sql.DB, p *models.Payment, cancelOrder bool) error {
+	if p.Status != models.PaymentPending {
+		return models.ErrPaymentState
+	}
+	if pixPSP != nil {
+		err := pixPSP.RemoveCharge(ctx, p.GatewayRef)
+		switch {
+		case pix.IsRejection(err): // ex.: cobrança já paga
+			metrics.PaymentsTotal.Inc(PixGateway, "void", "declined")
+			return fmt.Errorf("%w: %v", models.ErrPaymentState, err)
+		case err != nil:
+			metrics.PaymentsTotal.Inc(PixGateway, "void", "error")
+			return &UnavailableError{Gateway: PixGateway, Err: err}
+		}
+	}
+	metrics.PaymentsTotal.Inc(PixGateway, "void", "approved")
+	return models.MarkPixPaymentVoided(db, p, cancelOrder)
+}
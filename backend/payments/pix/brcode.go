@@ -0,0 +1,255 @@
+// Arquivo: backend/payments/pix/brcode.go
+
+// PIX: geração e leitura do BR Code (payload EMV "copia e cola"), QR Code,
+// notificações (webhook) do PSP e cliente da API do PSP (devoluções).
+package pix
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Identificadores EMV usados pelo BR Code (Manual do BR Code, Banco Central)
+const (
+	idPayloadFormat     = "00"
+	idPointOfInitiation = "01"
+	idMerchantAccount   = "26"
+	idMCC               = "52"
+	idCurrency          = "53"
+	idAmount            = "54"
+	idCountry           = "58"
+	idMerchantName      = "59"
+	idMerchantCity      = "60"
+	idAdditionalData    = "62"
+	idCRC               = "63"
+
+	// Subcampos da conta (26) e dados adicionais (62)
+	idGUI         = "00"
+	idKey         = "01"
+	idDescription = "02"
+	idTxID        = "05"
+
+	gui            = "br.gov.bcb.pix"
+	currencyBRL    = "986"
+	maxNameLength  = 25
+	maxCityLength  = 15
+	maxTxIDLength  = 25
+	maxFieldLength = 99
+)
+
+var (
+	ErrInvalidPayload = errors.New("BR Code inválido")
+	ErrInvalidCRC     = errors.New("BR Code com CRC inválido")
+)
+
+// Cobrança PIX representada no BR Code
+type Payload struct {
+	Key          string // chave PIX do recebedor
+	MerchantName string
+	MerchantCity string
+	AmountCents  int64
+	TxID         string // identifica o pagamento na notificação do PSP
+	Description  string // opcional, exibida no app do pagador
+}
+
+// Gerar o payload "copia e cola" (também é o conteúdo do QR Code)
+func (p Payload) Encode() (string, error) {
+	if p.Key == "" {
+		return "", fmt.Errorf("%w: chave PIX ausente", ErrInvalidPayload)
+	}
+	if p.AmountCents <= 0 {
+		return "", fmt.Errorf("%w: valor deve ser positivo", ErrInvalidPayload)
+	}
+	if !validTxID(p.TxID) {
+		return "", fmt.Errorf("%w: txid deve ter de 1 a %d caracteres alfanuméricos", ErrInvalidPayload, maxTxIDLength)
+	}
+
+	account := tlv(idGUI, gui) + tlv(idKey, p.Key)
+	if desc := sanitize(p.Description, maxFieldLength); desc != "" {
+		// A descrição é truncada para caber no limite do campo 26
+		room := maxFieldLength - len(account) - 4
+		if room > 0 {
+			if len(desc) > room {
+				desc = desc[:room]
+			}
+			account += tlv(idDescription, desc)
+		}
+	}
+	if len(account) > maxFieldLength {
+		return "", fmt.Errorf("%w: chave PIX longa demais", ErrInvalidPayload)
+	}
+
+	var b strings.Builder
+	b.WriteString(tlv(idPayloadFormat, "01"))
+	b.WriteString(tlv(idPointOfInitiation, "12")) // uso único
+	b.WriteString(tlv(idMerchantAccount, account))
+	b.WriteString(tlv(idMCC, "0000"))
+	b.WriteString(tlv(idCurrency, currencyBRL))
+	b.WriteString(tlv(idAmount, FormatAmount(p.AmountCents)))
+	b.WriteString(tlv(idCountry, "BR"))
+	b.WriteString(tlv(idMerchantName, sanitize(p.MerchantName, maxNameLength)))
+	b.WriteString(tlv(idMerchantCity, sanitize(p.MerchantCity, maxCityLength)))
+	b.WriteString(tlv(idAdditionalData, tlv(idTxID, p.TxID)))
+	b.WriteString(idCRC + "04")
+	b.WriteString(crc16(b.String()))
+
+	return b.String(), nil
+}
+
+// Ler um payload "copia e cola", validando o CRC
+func Parse(code string) (*Payload, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < 8 || code[len(code)-8:len(code)-4] != idCRC+"04" {
+		return nil, ErrInvalidPayload
+	}
+	if !strings.EqualFold(crc16(code[:len(code)-4]), code[len(code)-4:]) {
+		return nil, ErrInvalidCRC
+	}
+
+	fields, err := parseTLV(code[:len(code)-8])
+	if err != nil {
+		return nil, err
+	}
+	if fields[idPayloadFormat] != "01" || fields[idCurrency] != currencyBRL {
+		return nil, ErrInvalidPayload
+	}
+
+	account, err := parseTLV(fields[idMerchantAccount])
+	if err != nil || !strings.EqualFold(account[idGUI], gui) {
+		return nil, ErrInvalidPayload
+	}
+	additional, err := parseTLV(fields[idAdditionalData])
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Payload{
+		Key:          account[idKey],
+		Description:  account[idDescription],
+		MerchantName: fields[idMerchantName],
+		MerchantCity: fields[idMerchantCity],
+		TxID:         additional[idTxID],
+	}
+	if amount, ok := fields[idAmount]; ok {
+		if p.AmountCents, err = ParseAmount(amount); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Identificador aleatório da cobrança (25 caracteres alfanuméricos)
+func NewTxID() string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, maxTxIDLength)
+	for i := range b {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b)
+}
+
+// Centavos no formato do BR Code e da API PIX ("12.50")
+func FormatAmount(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// "12.50" → 1250
+func ParseAmount(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > 2 {
+		return 0, fmt.Errorf("%w: valor %q", ErrInvalidPayload, s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	reais, err1 := strconv.ParseInt(whole, 10, 64)
+	cents, err2 := strconv.ParseInt(frac, 10, 64)
+	if err1 != nil || err2 != nil || reais < 0 || cents < 0 {
+		return 0, fmt.Errorf("%w: valor %q", ErrInvalidPayload, s)
+	}
+	return reais*100 + cents, nil
+}
+
+func tlv(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+func parseTLV(s string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(s) > 0 {
+		if len(s) < 4 {
+			return nil, ErrInvalidPayload
+		}
+		n, err := strconv.Atoi(s[2:4])
+		if err != nil || len(s) < 4+n {
+			return nil, ErrInvalidPayload
+		}
+		fields[s[:2]] = s[4 : 4+n]
+		s = s[4+n:]
+	}
+	return fields, nil
+}
+
+func validTxID(s string) bool {
+	if s == "" || len(s) > maxTxIDLength {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Letras acentuadas do português e seus equivalentes sem acento
+var unaccent = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "î", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "û", "u", "ù", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "Ê", "E", "È", "E", "Ë", "E",
+	"Í", "I", "Î", "I", "Ì", "I", "Ï", "I",
+	"Ó", "O", "Ô", "O", "Õ", "O", "Ò", "O", "Ö", "O",
+	"Ú", "U", "Û", "U", "Ù", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+)
+
+// Remover acentos e caracteres fora do ASCII imprimível, limitando o tamanho
+func sanitize(s string, max int) string {
+	var b strings.Builder
+	for _, r := range unaccent.Replace(strings.TrimSpace(s)) {
+		if r >= 0x20 && r < 0x7f {
+			b.WriteRune(r)
+		}
+	}
+	out := b.String()
+	if len(out) > max {
+		out = out[:max]
+	}
+	return out
+}
+
+// CRC16-CCITT (polinômio 0x1021, valor inicial 0xFFFF), em hexadecimal maiúsculo
+func crc16(s string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
@@ -0,0 +1,212 @@
+// Arquivo: backend/payments/pix/brcode_test.go
+package pix
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Exemplo de BR Code estático do Manual do BR Code (Banco Central)
+const manualExample = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-426655440000" +
+	"5204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "FFFF"},
+		{"123456789", "29B1"}, // valor de verificação do CRC-16/CCITT-FALSE
+		{"A", "B915"},
+		{manualExample[:len(manualExample)-4], "1D3D"},
+	}
+	for _, tt := range tests {
+		if got := crc16(tt.in); got != tt.want {
+			t.Errorf("crc16(%q) = %s, esperado %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseManualExample(t *testing.T) {
+	p, err := Parse(manualExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Payload{
+		Key:          "123e4567-e12b-12d1-a456-426655440000",
+		MerchantName: "Fulano de Tal",
+		MerchantCity: "BRASILIA",
+		TxID:         "***",
+	}
+	if *p != want {
+		t.Errorf("Parse = %+v, esperado %+v", *p, want)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	base := Payload{
+		Key:          "pix@finplay.com.br",
+		MerchantName: "FinPlay Lanchonete",
+		MerchantCity: "São Paulo",
+		AmountCents:  5290,
+		TxID:         "PEDIDO123",
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(p *Payload)
+		want    string // payload completo esperado (vazio = só ida e volta)
+		wantErr bool
+		check   func(t *testing.T, p *Payload)
+	}{
+		{
+			name: "cobrança básica",
+			want: "000201010212" +
+				"26400014br.gov.bcb.pix0118pix@finplay.com.br" +
+				"520400005303986540552.905802BR" +
+				"5918FinPlay Lanchonete6009Sao Paulo" +
+				"62130509PEDIDO123" +
+				"63040E7B",
+		},
+		{
+			name: "nome e cidade sem acento e truncados",
+			edit: func(p *Payload) {
+				p.MerchantName = "Lanchonete e Confeitaria Açúcar & Afeto"
+				p.MerchantCity = "São José dos Campos"
+			},
+			check: func(t *testing.T, p *Payload) {
+				if p.MerchantName != "Lanchonete e Confeitaria " || p.MerchantCity != "Sao Jose dos Ca" {
+					t.Errorf("nome/cidade = %q/%q", p.MerchantName, p.MerchantCity)
+				}
+			},
+		},
+		{
+			name: "descrição truncada no limite do campo 26",
+			edit: func(p *Payload) { p.Description = strings.Repeat("x", 120) },
+			check: func(t *testing.T, p *Payload) {
+				account := tlv(idGUI, gui) + tlv(idKey, p.Key) + tlv(idDescription, p.Description)
+				if len(account) != maxFieldLength {
+					t.Errorf("campo 26 com %d caracteres, esperado %d", len(account), maxFieldLength)
+				}
+			},
+		},
+		{name: "sem chave", edit: func(p *Payload) { p.Key = "" }, wantErr: true},
+		{name: "valor zero", edit: func(p *Payload) { p.AmountCents = 0 }, wantErr: true},
+		{name: "txid vazio", edit: func(p *Payload) { p.TxID = "" }, wantErr: true},
+		{name: "txid com símbolo", edit: func(p *Payload) { p.TxID = "PEDIDO-123" }, wantErr: true},
+		{name: "txid longo", edit: func(p *Payload) { p.TxID = strings.Repeat("A", 26) }, wantErr: true},
+		{name: "chave longa", edit: func(p *Payload) { p.Key = strings.Repeat("k", 80) }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := base
+			if tt.edit != nil {
+				tt.edit(&p)
+			}
+			code, err := p.Encode()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPayload) {
+					t.Fatalf("Encode: erro %v, esperado ErrInvalidPayload", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && code != tt.want {
+				t.Errorf("Encode =\n%s\nesperado\n%s", code, tt.want)
+			}
+
+			parsed, err := Parse(code)
+			if err != nil {
+				t.Fatalf("Parse(Encode()): %v", err)
+			}
+			if parsed.Key != p.Key || parsed.AmountCents != p.AmountCents || parsed.TxID != p.TxID {
+				t.Errorf("ida e volta: %+v", parsed)
+			}
+			if tt.check != nil {
+				tt.check(t, parsed)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid, err := Payload{Key: "chave", MerchantName: "Loja", MerchantCity: "Cidade", AmountCents: 100, TxID: "ABC"}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(valid, "54041.00", "54041.01", 1)
+
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{"vazio", "", ErrInvalidPayload},
+		{"sem CRC", valid[:len(valid)-8], ErrInvalidPayload},
+		{"valor alterado", tampered, ErrInvalidCRC},
+		{"CRC minúsculo é aceito", valid[:len(valid)-4] + strings.ToLower(valid[len(valid)-4:]), nil},
+		{"espaços em volta", "  " + valid + "\n", nil},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.code); !errors.Is(err, tt.want) {
+			t.Errorf("%s: erro %v, esperado %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestAmounts(t *testing.T) {
+	format := []struct {
+		cents int64
+		want  string
+	}{
+		{1, "0.01"},
+		{100, "1.00"},
+		{5290, "52.90"},
+		{123456, "1234.56"},
+	}
+	for _, tt := range format {
+		if got := FormatAmount(tt.cents); got != tt.want {
+			t.Errorf("FormatAmount(%d) = %q, esperado %q", tt.cents, got, tt.want)
+		}
+	}
+
+	parse := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"52.90", 5290, false},
+		{"52.9", 5290, false},
+		{"52", 5200, false},
+		{"0.01", 1, false},
+		{"", 0, true},
+		{".50", 0, true},
+		{"1.234", 0, true},
+		{"-1.00", 0, true},
+		{"1,50", 0, true},
+	}
+	for _, tt := range parse {
+		got, err := ParseAmount(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestNewTxID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewTxID()
+		if len(id) != maxTxIDLength || !validTxID(id) {
+			t.Fatalf("txid inválido: %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("txid repetido: %q", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,329 @@
+// Arquivo: backend/payments/pix/pixsim/pixsim.go
+
+// Simulador local de PSP PIX para desenvolvimento e testes: recebe o BR Code
+// "copia e cola" como um app de banco faria e envia ao backend a notificação
+// assinada de PIX recebido. Também atende as chamadas do backend ao PSP
+// (devolução e remoção de cobrança) no formato da API PIX.
+package pixsim
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"finplay/backend/payments/pix"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ISPB fictício usado nos endToEndId gerados
+const ispb = "99999999"
+
+var (
+	ErrAlreadyPaid    = errors.New("cobrança PIX já paga")
+	ErrChargeRemoved  = errors.New("cobrança PIX removida pelo recebedor")
+	ErrRefundExceeded = errors.New("valor da devolução excede o valor disponível")
+	ErrNotFound       = errors.New("PIX não encontrado")
+)
+
+type Server struct {
+	WebhookURL string
+	Secret     string
+	Client     *http.Client
+
+	mu          sync.Mutex
+	paid        map[string]pix.Transfer    // por txid
+	removed     map[string]bool            // txid removidos pelo recebedor
+	devolutions map[string]*pix.Devolution // por endToEndId + "/" + id
+}
+
+func New(webhookURL, secret string) *Server {
+	return &Server{
+		WebhookURL:  webhookURL,
+		Secret:      secret,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		paid:        make(map[string]pix.Transfer),
+		removed:     make(map[string]bool),
+		devolutions: make(map[string]*pix.Devolution),
+	}
+}
+
+// Pagar o BR Code e notificar o backend. Com amountCents > 0 paga esse valor
+// em vez do indicado no código (útil para simular divergências).
+func (s *Server) Pay(ctx context.Context, code string, amountCents int64) (*pix.Transfer, error) {
+	payload, err := pix.Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	if amountCents <= 0 {
+		amountCents = payload.AmountCents
+	}
+	if amountCents <= 0 {
+		return nil, fmt.Errorf("%w: valor ausente", pix.ErrInvalidPayload)
+	}
+
+	s.mu.Lock()
+	if _, ok := s.paid[payload.TxID]; ok {
+		s.mu.Unlock()
+		return nil, ErrAlreadyPaid
+	}
+	if s.removed[payload.TxID] {
+		s.mu.Unlock()
+		return nil, ErrChargeRemoved
+	}
+	transfer := pix.Transfer{
+		EndToEndID:  endToEndID(time.Now()),
+		TxID:        payload.TxID,
+		Valor:       pix.FormatAmount(amountCents),
+		Horario:     time.Now().UTC(),
+		InfoPagador: "Pagamento simulado",
+	}
+	s.paid[payload.TxID] = transfer
+	s.mu.Unlock()
+
+	if err := s.Notify(ctx, transfer); err != nil {
+		return &transfer, err
+	}
+	return &transfer, nil
+}
+
+// Enviar (ou reenviar) a notificação de uma transferência
+func (s *Server) Notify(ctx context.Context, transfers ...pix.Transfer) error {
+	body, err := json.Marshal(pix.Notification{Pix: transfers})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(pix.SignatureHeader, pix.Sign(s.Secret, body, time.Now()))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao enviar webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook respondeu %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// Devolver parte ou todo o valor de um PIX recebido. Repetir o id retorna a
+// devolução já feita; a soma das devoluções não passa do valor recebido.
+func (s *Server) Refund(endToEndID, id string, amountCents int64) (*pix.Devolution, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := endToEndID + "/" + id
+	if d, ok := s.devolutions[key]; ok {
+		return d, nil
+	}
+
+	var transfer *pix.Transfer
+	for _, t := range s.paid {
+		if t.EndToEndID == endToEndID {
+			transfer = &t
+			break
+		}
+	}
+	if transfer == nil {
+		return nil, ErrNotFound
+	}
+	received, err := transfer.AmountCents()
+	if err != nil {
+		return nil, err
+	}
+	var refunded int64
+	for k, d := range s.devolutions {
+		if strings.HasPrefix(k, endToEndID+"/") {
+			v, _ := d.AmountCents()
+			refunded += v
+		}
+	}
+	if amountCents <= 0 || refunded+amountCents > received {
+		return nil, ErrRefundExceeded
+	}
+
+	now := time.Now().UTC()
+	d := &pix.Devolution{
+		ID:     id,
+		RtrID:  "D" + endToEndID[1:],
+		Valor:  pix.FormatAmount(amountCents),
+		Status: pix.DevolutionDone,
+	}
+	d.Horario.Solicitacao = now
+	d.Horario.Liquidacao = &now
+	s.devolutions[key] = d
+	return d, nil
+}
+
+// Remover uma cobrança ainda não paga (pagamentos seguintes são recusados)
+func (s *Server) RemoveCharge(txid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.paid[txid]; ok {
+		return ErrAlreadyPaid
+	}
+	s.removed[txid] = true
+	return nil
+}
+
+type payRequest struct {
+	Code        string `json:"codigo"`
+	AmountCents int64  `json:"valor_centavos,omitempty"`
+}
+
+// Rotas:
+//
+//	POST /pagar    {"codigo": "<copia e cola>", "valor_centavos": 0}
+//	POST /reenviar {"codigo": "<copia e cola>"} reenvia a notificação (duplicata)
+//
+// e, da API PIX chamada pelo backend:
+//
+//	PUT   /pix/{e2eid}/devolucao/{id} {"valor": "10.00"}
+//	PATCH /cob/{txid}                 {"status": "REMOVIDA_PELO_USUARIO_RECEBEDOR"}
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pagar", func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decode(w, r)
+		if !ok {
+			return
+		}
+		transfer, err := s.Pay(r.Context(), req.Code, req.AmountCents)
+		switch {
+		case errors.Is(err, ErrAlreadyPaid):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		case transfer == nil && err != nil:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case err != nil:
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error(), "pix": transfer})
+		default:
+			writeJSON(w, http.StatusOK, transfer)
+		}
+	})
+	mux.HandleFunc("/reenviar", func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decode(w, r)
+		if !ok {
+			return
+		}
+		payload, err := pix.Parse(req.Code)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		s.mu.Lock()
+		transfer, paid := s.paid[payload.TxID]
+		s.mu.Unlock()
+		if !paid {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cobrança ainda não paga"})
+			return
+		}
+		if err := s.Notify(r.Context(), transfer); err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, transfer)
+	})
+	mux.HandleFunc("/pix/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/pix/"), "/")
+		if len(parts) != 3 || parts[1] != "devolucao" || parts[0] == "" || parts[2] == "" {
+			writeAPIError(w, http.StatusNotFound, "Recurso não encontrado", "")
+			return
+		}
+		if r.Method != http.MethodPut {
+			writeAPIError(w, http.StatusMethodNotAllowed, "Método não permitido", "")
+			return
+		}
+		var req struct {
+			Valor string `json:"valor"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "Requisição inválida", err.Error())
+			return
+		}
+		amount, err := pix.ParseAmount(req.Valor)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "Valor inválido", err.Error())
+			return
+		}
+		d, err := s.Refund(parts[0], parts[2], amount)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			writeAPIError(w, http.StatusNotFound, "PIX não encontrado", parts[0])
+		case err != nil:
+			writeAPIError(w, http.StatusBadRequest, "Devolução inválida", err.Error())
+		default:
+			writeJSON(w, http.StatusCreated, d)
+		}
+	})
+	mux.HandleFunc("/cob/", func(w http.ResponseWriter, r *http.Request) {
+		txid := strings.TrimPrefix(r.URL.Path, "/cob/")
+		if txid == "" || strings.Contains(txid, "/") {
+			writeAPIError(w, http.StatusNotFound, "Recurso não encontrado", "")
+			return
+		}
+		if r.Method != http.MethodPatch {
+			writeAPIError(w, http.StatusMethodNotAllowed, "Método não permitido", "")
+			return
+		}
+		var req struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Status != pix.ChargeRemoved {
+			writeAPIError(w, http.StatusBadRequest, "Requisição inválida", "status deve ser "+pix.ChargeRemoved)
+			return
+		}
+		if err := s.RemoveCharge(txid); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "Cobrança não pode ser removida", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"txid": txid, "status": req.Status})
+	})
+	return mux
+}
+
+func decode(w http.ResponseWriter, r *http.Request) (*payRequest, bool) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "método não permitido"})
+		return nil, false
+	}
+	var req payRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "informe o campo codigo"})
+		return nil, false
+	}
+	return &req, true
+}
+
+// Erro no formato da API PIX
+func writeAPIError(w http.ResponseWriter, status int, title, detail string) {
+	writeJSON(w, status, pix.APIError{Title: title, Status: status, Detail: detail})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// endToEndId: "E" + ISPB (8) + data/hora (yyyyMMddHHmm) + 11 caracteres
+func endToEndID(at time.Time) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 11)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return "E" + ispb + at.UTC().Format("200601021504") + string(b)
+}
@@ -0,0 +1,245 @@
+// Arquivo: backend/payments/pix/pixsim/pixsim_test.go
+package pixsim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"finplay/backend/payments/pix"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const secret = "segredo-do-webhook"
+
+// Backend de mentira que confere a assinatura e guarda as notificações
+type webhook struct {
+	mu       sync.Mutex
+	received []pix.Transfer
+	status   int
+}
+
+func (h *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := pix.VerifySignature(secret, r.Header.Get(pix.SignatureHeader), body, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	var n pix.Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	h.received = append(h.received, n.Pix...)
+	status := h.status
+	h.mu.Unlock()
+	if status != 0 {
+		http.Error(w, "falha simulada", status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newCharge(t *testing.T, txid string, cents int64) string {
+	code, err := pix.Payload{Key: "pix@finplay.com.br", MerchantName: "FinPlay", MerchantCity: "Sao Paulo", AmountCents: cents, TxID: txid}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestPay(t *testing.T) {
+	hook := &webhook{}
+	backend := httptest.NewServer(hook)
+	defer backend.Close()
+	sim := New(backend.URL, secret)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		code      string
+		amount    int64
+		wantErr   error
+		wantValor string
+	}{
+		{"valor da cobrança", newCharge(t, "TX1", 5290), 0, nil, "52.90"},
+		{"valor divergente", newCharge(t, "TX2", 5290), 5000, nil, "50.00"},
+		{"cobrança já paga", newCharge(t, "TX1", 5290), 0, ErrAlreadyPaid, ""},
+		{"código inválido", "não é um BR Code", 0, pix.ErrInvalidPayload, ""},
+	}
+	for _, tt := range tests {
+		transfer, err := sim.Pay(ctx, tt.code, tt.amount)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: erro %v, esperado %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if transfer.Valor != tt.wantValor {
+			t.Errorf("%s: valor %q, esperado %q", tt.name, transfer.Valor, tt.wantValor)
+		}
+		if !strings.HasPrefix(transfer.EndToEndID, "E"+ispb) || len(transfer.EndToEndID) != 32 {
+			t.Errorf("%s: endToEndId %q fora do formato", tt.name, transfer.EndToEndID)
+		}
+	}
+
+	if len(hook.received) != 2 || hook.received[0].TxID != "TX1" || hook.received[1].TxID != "TX2" {
+		t.Errorf("notificações recebidas: %+v", hook.received)
+	}
+}
+
+func TestPayWebhookFailure(t *testing.T) {
+	hook := &webhook{status: http.StatusInternalServerError}
+	backend := httptest.NewServer(hook)
+	defer backend.Close()
+	sim := New(backend.URL, secret)
+
+	// A transferência acontece mesmo se o backend falhar; o erro permite reenviar
+	transfer, err := sim.Pay(context.Background(), newCharge(t, "TX1", 100), 0)
+	if err == nil || transfer == nil {
+		t.Fatalf("Pay = %+v, %v; esperado transferência e erro do webhook", transfer, err)
+	}
+
+	hook.status = 0
+	if err := sim.Notify(context.Background(), *transfer); err != nil {
+		t.Fatalf("reenvio: %v", err)
+	}
+	if len(hook.received) != 2 || hook.received[1] != hook.received[0] {
+		t.Errorf("reenvio deveria repetir a mesma transferência: %+v", hook.received)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	hook := &webhook{}
+	backend := httptest.NewServer(hook)
+	defer backend.Close()
+	sim := httptest.NewServer(New(backend.URL, secret).Handler())
+	defer sim.Close()
+
+	code := newCharge(t, "TX1", 1250)
+	tests := []struct {
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"/reenviar", `{"codigo":"` + code + `"}`, http.StatusNotFound},
+		{"/pagar", `{"codigo":"` + code + `"}`, http.StatusOK},
+		{"/pagar", `{"codigo":"` + code + `"}`, http.StatusConflict},
+		{"/reenviar", `{"codigo":"` + code + `"}`, http.StatusOK},
+		{"/pagar", `{"codigo":"invalido"}`, http.StatusBadRequest},
+		{"/pagar", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(sim.URL+tt.path, "application/json", bytes.NewBufferString(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("POST %s %s: status %d, esperado %d", tt.path, tt.body, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	resp, err := http.Get(sim.URL + "/pagar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /pagar: status %d, esperado 405", resp.StatusCode)
+	}
+	if len(hook.received) != 2 {
+		t.Errorf("%d notificações, esperado 2 (pagamento e reenvio)", len(hook.received))
+	}
+}
+
+// Cliente da API PIX do backend contra as rotas do simulador
+func TestClientRefund(t *testing.T) {
+	hook := &webhook{}
+	backend := httptest.NewServer(hook)
+	defer backend.Close()
+	sim := New(backend.URL, secret)
+	psp := httptest.NewServer(sim.Handler())
+	defer psp.Close()
+	client := pix.NewClient(psp.URL + "/")
+	ctx := context.Background()
+
+	transfer, err := sim.Pay(ctx, newCharge(t, "TX1", 5000), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		endToEndID string
+		id         string
+		amount     int64
+		wantValor  string // vazio = recusa esperada
+		wantStatus int
+	}{
+		{"devolução parcial", transfer.EndToEndID, "D1", 2000, "20.00", 0},
+		{"mesmo id repete a devolução", transfer.EndToEndID, "D1", 2000, "20.00", 0},
+		{"acima do saldo", transfer.EndToEndID, "D2", 3001, "", http.StatusBadRequest},
+		{"restante", transfer.EndToEndID, "D2", 3000, "30.00", 0},
+		{"nada mais a devolver", transfer.EndToEndID, "D3", 1, "", http.StatusBadRequest},
+		{"PIX desconhecido", "E99999999202601011200naoexiste", "D1", 100, "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		d, err := client.Refund(ctx, tt.endToEndID, tt.id, tt.amount)
+		if tt.wantValor == "" {
+			var apiErr *pix.APIError
+			if !errors.As(err, &apiErr) || apiErr.Status != tt.wantStatus || !pix.IsRejection(err) {
+				t.Errorf("%s: erro %v, esperado recusa %d", tt.name, err, tt.wantStatus)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if d.ID != tt.id || d.Valor != tt.wantValor || d.Status != pix.DevolutionDone || d.RtrID == "" {
+			t.Errorf("%s: devolução %+v, esperado id %s valor %s", tt.name, d, tt.id, tt.wantValor)
+		}
+	}
+}
+
+func TestClientRemoveCharge(t *testing.T) {
+	hook := &webhook{}
+	backend := httptest.NewServer(hook)
+	defer backend.Close()
+	sim := New(backend.URL, secret)
+	psp := httptest.NewServer(sim.Handler())
+	defer psp.Close()
+	client := pix.NewClient(psp.URL)
+	ctx := context.Background()
+
+	// Cobrança removida não pode mais ser paga
+	if err := client.RemoveCharge(ctx, "TX1"); err != nil {
+		t.Fatalf("RemoveCharge: %v", err)
+	}
+	if _, err := sim.Pay(ctx, newCharge(t, "TX1", 1000), 0); !errors.Is(err, ErrChargeRemoved) {
+		t.Errorf("Pay de cobrança removida: %v, esperado ErrChargeRemoved", err)
+	}
+
+	// Cobrança paga não pode ser removida
+	if _, err := sim.Pay(ctx, newCharge(t, "TX2", 1000), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RemoveCharge(ctx, "TX2"); !pix.IsRejection(err) {
+		t.Errorf("RemoveCharge de cobrança paga: %v, esperado recusa", err)
+	}
+
+	// PSP fora do ar não é recusa
+	down := pix.NewClient("http://127.0.0.1:1")
+	if err := down.RemoveCharge(ctx, "TX3"); err == nil || pix.IsRejection(err) {
+		t.Errorf("PSP fora do ar: %v, esperado erro de comunicação", err)
+	}
+}
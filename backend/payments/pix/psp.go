@@ -0,0 +1,146 @@
+// Arquivo: backend/payments/pix/psp.go
+package pix
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status da devolução na API PIX
+const (
+	DevolutionProcessing = "EM_PROCESSAMENTO"
+	DevolutionDone       = "DEVOLVIDO"
+	DevolutionRejected   = "NAO_REALIZADO"
+)
+
+// Status da cobrança removida pelo recebedor
+const ChargeRemoved = "REMOVIDA_PELO_USUARIO_RECEBEDOR"
+
+// Tamanho máximo do id da devolução ([a-zA-Z0-9]{1,35})
+const maxDevolutionIDLength = 35
+
+// Devolução (estorno) de um PIX recebido
+type Devolution struct {
+	ID      string `json:"id"`              // definido pelo recebedor
+	RtrID   string `json:"rtrId,omitempty"` // identificador da devolução no SPI
+	Valor   string `json:"valor"`
+	Status  string `json:"status"`
+	Motivo  string `json:"motivo,omitempty"`
+	Horario struct {
+		Solicitacao time.Time  `json:"solicitacao"`
+		Liquidacao  *time.Time `json:"liquidacao,omitempty"`
+	} `json:"horario"`
+}
+
+func (d Devolution) AmountCents() (int64, error) {
+	return ParseAmount(d.Valor)
+}
+
+// Erro retornado pelo PSP (formato de erro da API PIX)
+type APIError struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("PSP respondeu %d: %s (%s)", e.Status, e.Title, e.Detail)
+	}
+	return fmt.Sprintf("PSP respondeu %d: %s", e.Status, e.Title)
+}
+
+// Erro 4xx do PSP (requisição recusada; repetir não adianta)
+func IsRejection(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status >= 400 && apiErr.Status < 500
+}
+
+// Cliente da API PIX do PSP. Autenticação (mTLS, OAuth) fica no http.Client.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Solicitar a devolução de um PIX recebido:
+// PUT /pix/{e2eid}/devolucao/{id}. Repetir o mesmo id retorna a devolução já
+// criada, então o id deve ser estável entre tentativas (ver DevolutionID).
+func (c *Client) Refund(ctx context.Context, endToEndID, id string, amountCents int64) (*Devolution, error) {
+	body, err := json.Marshal(map[string]string{"valor": FormatAmount(amountCents)})
+	if err != nil {
+		return nil, err
+	}
+	var d Devolution
+	path := "/pix/" + url.PathEscape(endToEndID) + "/devolucao/" + url.PathEscape(id)
+	if err := c.do(ctx, http.MethodPut, path, body, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Remover uma cobrança ainda não paga: PATCH /cob/{txid}
+func (c *Client) RemoveCharge(ctx context.Context, txid string) error {
+	body, err := json.Marshal(map[string]string{"status": ChargeRemoved})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPatch, "/cob/"+url.PathEscape(txid), body, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		apiErr := &APIError{}
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Title == "" {
+			apiErr.Title = strings.TrimSpace(string(data))
+		}
+		apiErr.Status = resp.StatusCode
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Id da devolução derivado do pagamento e do valor já estornado: uma nova
+// tentativa do mesmo estorno (ex.: após timeout) reaproveita a devolução no
+// PSP em vez de devolver duas vezes.
+func DevolutionID(paymentID string, refundedCents int64) string {
+	sum := sha256.Sum256([]byte(paymentID + ":" + strconv.FormatInt(refundedCents, 10)))
+	return "D" + hex.EncodeToString(sum[:])[:maxDevolutionIDLength-1]
+}
@@ -0,0 +1,34 @@
+// Arquivo: backend/payments/pix/psp_test.go
+package pix
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDevolutionID(t *testing.T) {
+	valid := regexp.MustCompile(`^[a-zA-Z0-9]{1,35}$`)
+	tests := []struct {
+		paymentID string
+		refunded  int64
+	}{
+		{"8d0f7a52-3c1e-4b7a-9f1e-2a6d5c4b3a21", 0},
+		{"8d0f7a52-3c1e-4b7a-9f1e-2a6d5c4b3a21", 2000},
+		{"0b6e1c2d-9a8f-4e7d-8c6b-5a4f3e2d1c0b", 0},
+	}
+
+	seen := make(map[string]bool)
+	for _, tt := range tests {
+		id := DevolutionID(tt.paymentID, tt.refunded)
+		if !valid.MatchString(id) {
+			t.Errorf("DevolutionID(%s, %d) = %q fora do formato da API PIX", tt.paymentID, tt.refunded, id)
+		}
+		if again := DevolutionID(tt.paymentID, tt.refunded); again != id {
+			t.Errorf("DevolutionID(%s, %d) mudou entre chamadas: %q e %q", tt.paymentID, tt.refunded, id, again)
+		}
+		if seen[id] {
+			t.Errorf("DevolutionID(%s, %d) = %q repetido", tt.paymentID, tt.refunded, id)
+		}
+		seen[id] = true
+	}
+}
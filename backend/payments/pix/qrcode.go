@@ -0,0 +1,18 @@
+// Arquivo: backend/payments/pix/qrcode.go
+package pix
+
+import (
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Lado padrão da imagem do QR Code em pixels
+const QRCodeSize = 320
+
+// Gerar PNG do QR Code para o payload "copia e cola". Correção de erro média,
+// como recomendado no Manual do BR Code.
+func QRCodePNG(payload string, size int) ([]byte, error) {
+	if size <= 0 {
+		size = QRCodeSize
+	}
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
@@ -0,0 +1,82 @@
+// Arquivo: backend/payments/pix/webhook.go
+package pix
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cabeçalho com a assinatura HMAC da notificação: "t=<unix>,v1=<hex>"
+const SignatureHeader = "X-Pix-Signature"
+
+// Janela aceita entre o envio e o recebimento (proteção contra replay)
+const SignatureTolerance = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("assinatura do webhook PIX inválida")
+
+// Notificação de PIX recebido, no formato da API PIX do Banco Central
+type Notification struct {
+	Pix []Transfer `json:"pix"`
+}
+
+type Transfer struct {
+	EndToEndID  string    `json:"endToEndId"` // identificador único da transferência
+	TxID        string    `json:"txid"`
+	Valor       string    `json:"valor"` // ex.: "52.90"
+	Horario     time.Time `json:"horario"`
+	InfoPagador string    `json:"infoPagador,omitempty"`
+}
+
+func (t Transfer) AmountCents() (int64, error) {
+	return ParseAmount(t.Valor)
+}
+
+// Assinar o corpo da notificação (usado pelo PSP/simulador)
+func Sign(secret string, body []byte, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+// Verificar a assinatura e a idade da notificação
+func VerifySignature(secret, header string, body []byte, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	if ts == "" || sig == "" {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,66 @@
+// Arquivo: backend/payments/pix/webhook_test.go
+package pix
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "segredo-do-webhook"
+	body := []byte(`{"pix":[{"endToEndId":"E1","txid":"ABC","valor":"52.90"}]}`)
+	sentAt := time.Unix(1700000000, 0)
+	valid := Sign(secret, body, sentAt)
+	ts := strconv.FormatInt(sentAt.Unix(), 10)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		want   error
+	}{
+		{"válida", secret, valid, body, sentAt, nil},
+		{"dentro da tolerância", secret, valid, body, sentAt.Add(SignatureTolerance), nil},
+		{"relógio do PSP adiantado", secret, valid, body, sentAt.Add(-SignatureTolerance), nil},
+		{"espaços entre os campos", secret, "t=" + ts + ", v1=" + signature(secret, ts, body), body, sentAt, nil},
+		{"campos em outra ordem", secret, "v1=" + signature(secret, ts, body) + ",t=" + ts, body, sentAt, nil},
+		{"antiga demais (replay)", secret, valid, body, sentAt.Add(SignatureTolerance + time.Second), ErrInvalidSignature},
+		{"no futuro", secret, valid, body, sentAt.Add(-SignatureTolerance - time.Second), ErrInvalidSignature},
+		{"corpo alterado", secret, valid, []byte(`{"pix":[{"endToEndId":"E1","txid":"ABC","valor":"0.01"}]}`), sentAt, ErrInvalidSignature},
+		{"outro segredo", "outro", valid, body, sentAt, ErrInvalidSignature},
+		{"timestamp trocado", secret, "t=" + strconv.FormatInt(sentAt.Unix()+1, 10) + ",v1=" + signature(secret, ts, body), body, sentAt, ErrInvalidSignature},
+		{"sem cabeçalho", secret, "", body, sentAt, ErrInvalidSignature},
+		{"sem v1", secret, "t=" + ts, body, sentAt, ErrInvalidSignature},
+		{"sem t", secret, "v1=" + signature(secret, ts, body), body, sentAt, ErrInvalidSignature},
+		{"t não numérico", secret, "t=abc,v1=" + signature(secret, "abc", body), body, sentAt, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		err := VerifySignature(tt.secret, tt.header, tt.body, tt.now)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: erro %v, esperado %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestTransferAmountCents(t *testing.T) {
+	tests := []struct {
+		valor   string
+		want    int64
+		wantErr bool
+	}{
+		{"52.90", 5290, false},
+		{"0.01", 1, false},
+		{"", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Transfer{Valor: tt.valor}.AmountCents()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("AmountCents(%q) = %d, %v", tt.valor, got, err)
+		}
+	}
+}
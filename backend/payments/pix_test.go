@@ -0,0 +1,171 @@
+// Arquivo: backend/payments/pix_test.go
+package payments
+
+import (
+	"context"
+	"errors"
+	"finplay/backend/models"
+	"finplay/backend/payments/pix"
+	"testing"
+)
+
+// PSP de mentira: responde sempre com a devolução ou o erro configurados
+type fakePSP struct {
+	devolution *pix.Devolution
+	err        error
+	removeErr  error
+	ids        []string
+	removed    []string
+}
+
+func (p *fakePSP) Refund(ctx context.Context, endToEndID, id string, amountCents int64) (*pix.Devolution, error) {
+	p.ids = append(p.ids, id)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.devolution, nil
+}
+
+func (p *fakePSP) RemoveCharge(ctx context.Context, txid string) error {
+	p.removed = append(p.removed, txid)
+	return p.removeErr
+}
+
+func withPSP(t *testing.T, p PixPSP) {
+	previous := pixPSP
+	SetPixPSP(p)
+	t.Cleanup(func() { SetPixPSP(previous) })
+}
+
+func TestDevolvePix(t *testing.T) {
+	var unavailable *UnavailableError
+	tests := []struct {
+		name            string
+		psp             *fakePSP
+		wantErr         error
+		wantUnavailable bool
+		wantRef         string
+	}{
+		{
+			"devolvido",
+			&fakePSP{devolution: &pix.Devolution{ID: "D1", Valor: "20.00", Status: pix.DevolutionDone}},
+			nil, false, "D1",
+		},
+		{
+			"em processamento conta como aceito",
+			&fakePSP{devolution: &pix.Devolution{ID: "D1", Valor: "20.00", Status: pix.DevolutionProcessing}},
+			nil, false, "D1",
+		},
+		{
+			"não realizado",
+			&fakePSP{devolution: &pix.Devolution{ID: "D1", Valor: "20.00", Status: pix.DevolutionRejected, Motivo: "conta encerrada"}},
+			ErrRefundRejected, false, "",
+		},
+		{
+			"mesmo id com outro valor",
+			&fakePSP{devolution: &pix.Devolution{ID: "D1", Valor: "15.00", Status: pix.DevolutionDone}},
+			ErrRefundRejected, false, "",
+		},
+		{
+			"recusa do PSP",
+			&fakePSP{err: &pix.APIError{Title: "Devolução inválida", Status: 400}},
+			ErrRefundRejected, false, "",
+		},
+		{
+			"PSP indisponível",
+			&fakePSP{err: &pix.APIError{Title: "Erro interno", Status: 503}},
+			nil, true, "",
+		},
+		{
+			"falha de rede",
+			&fakePSP{err: context.DeadlineExceeded},
+			nil, true, "",
+		},
+	}
+
+	for _, tt := range tests {
+		withPSP(t, tt.psp)
+		ref, err := devolvePix(context.Background(), "E9999999920260101120000abcdefghijk", "pagamento-1", 500, 2000)
+		switch {
+		case tt.wantUnavailable:
+			if !errors.As(err, &unavailable) || unavailable.Gateway != PixGateway {
+				t.Errorf("%s: erro %v, esperado UnavailableError do PIX", tt.name, err)
+			}
+		case !errors.Is(err, tt.wantErr):
+			t.Errorf("%s: erro %v, esperado %v", tt.name, err, tt.wantErr)
+		}
+		if ref != tt.wantRef {
+			t.Errorf("%s: referência %q, esperado %q", tt.name, ref, tt.wantRef)
+		}
+		if want := pix.DevolutionID("pagamento-1", 500); len(tt.psp.ids) != 1 || tt.psp.ids[0] != want {
+			t.Errorf("%s: ids enviados %v, esperado [%s]", tt.name, tt.psp.ids, want)
+		}
+	}
+}
+
+// Caminhos que decidem pelo gateway antes de tocar no banco
+func TestPaymentOperationsByGateway(t *testing.T) {
+	var unavailable *UnavailableError
+	ctx := context.Background()
+	captured := func(gw string) *models.Payment {
+		return &models.Payment{ID: "p1", Gateway: gw, Status: models.PaymentCaptured, CapturedCents: 1000}
+	}
+	pending := &models.Payment{ID: "p2", Gateway: PixGateway, GatewayRef: "TX1", Status: models.PaymentPending}
+
+	t.Run("captura de PIX", func(t *testing.T) {
+		p := &models.Payment{Gateway: PixGateway, Status: models.PaymentAuthorized}
+		if err := Capture(ctx, nil, p); !errors.Is(err, models.ErrPaymentState) {
+			t.Errorf("erro %v, esperado ErrPaymentState", err)
+		}
+	})
+	t.Run("gateway desconhecido", func(t *testing.T) {
+		p := captured("outro")
+		if err := Capture(ctx, nil, p); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Capture: %v, esperado ErrUnsupported", err)
+		}
+		if err := Void(ctx, nil, p, false); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Void: %v, esperado ErrUnsupported", err)
+		}
+		if _, err := Refund(ctx, nil, p, 0, models.RefundOther, ""); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Refund: %v, esperado ErrUnsupported", err)
+		}
+	})
+	t.Run("estorno de PIX sem PSP", func(t *testing.T) {
+		withPSP(t, nil)
+		if _, err := Refund(ctx, nil, captured(PixGateway), 0, models.RefundOther, ""); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("erro %v, esperado ErrUnsupported", err)
+		}
+	})
+	t.Run("estorno de PIX acima do capturado", func(t *testing.T) {
+		withPSP(t, &fakePSP{})
+		if _, err := Refund(ctx, nil, captured(PixGateway), 1001, models.RefundOther, ""); !errors.Is(err, models.ErrInvalidRefundAmount) {
+			t.Errorf("erro %v, esperado ErrInvalidRefundAmount", err)
+		}
+	})
+	t.Run("remoção de PIX já capturado", func(t *testing.T) {
+		psp := &fakePSP{}
+		withPSP(t, psp)
+		if err := Void(ctx, nil, captured(PixGateway), false); !errors.Is(err, models.ErrPaymentState) {
+			t.Errorf("erro %v, esperado ErrPaymentState", err)
+		}
+		if len(psp.removed) != 0 {
+			t.Errorf("PSP chamado para pagamento capturado: %v", psp.removed)
+		}
+	})
+	t.Run("remoção recusada pelo PSP", func(t *testing.T) {
+		psp := &fakePSP{removeErr: &pix.APIError{Title: "Cobrança já paga", Status: 400}}
+		withPSP(t, psp)
+		if err := Void(ctx, nil, pending, false); !errors.Is(err, models.ErrPaymentState) {
+			t.Errorf("erro %v, esperado ErrPaymentState", err)
+		}
+		if len(psp.removed) != 1 || psp.removed[0] != "TX1" {
+			t.Errorf("cobranças removidas %v, esperado [TX1]", psp.removed)
+		}
+	})
+	t.Run("remoção com PSP indisponível", func(t *testing.T) {
+		withPSP(t, &fakePSP{removeErr: context.DeadlineExceeded})
+		if err := Void(ctx, nil, pending, false); !errors.As(err, &unavailable) {
+			t.Errorf("erro %v, esperado UnavailableError", err)
+		}
+	})
+}
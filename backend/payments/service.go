@@ -0,0 +1,301 @@
+// Arquivo: backend/payments/service.go
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"finplay/backend/config"
+	"finplay/backend/metrics"
+	"finplay/backend/models"
+	"finplay/backend/payments/pix"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	// Pagamento recusado pelo emissor (o registro com o motivo é retornado junto)
+	ErrDeclined = errors.New("pagamento recusado")
+	// Pagamento de um gateway não configurado (ou PIX sem PSP configurado)
+	ErrUnsupported = errors.New("operação não suportada para este meio de pagamento")
+	// Estorno recusado pelo provedor (ex.: devolução PIX não realizada)
+	ErrRefundRejected = errors.New("estorno recusado pelo provedor de pagamento")
+)
+
+var (
+	settings         = config.Default().Payments
+	gateway  Gateway = NewFake(FakeOptions{})
+)
+
+// Aplicar a configuração carregada
+func Configure(cfg config.PaymentsConfig) {
+	settings = cfg
+	switch cfg.Gateway {
+	case "fake":
+		gateway = NewFake(FakeOptions{Latency: cfg.FakeLatency})
+	}
+	pixPSP = nil
+	if cfg.PIX.PSPURL != "" {
+		pixPSP = pix.NewClient(cfg.PIX.PSPURL)
+	}
+}
+
+// Substituir o gateway (testes e integrações)
+func SetGateway(g Gateway) {
+	gateway = g
+}
+
+// Pagar um pedido pendente: autorizar e, com auto_capture, capturar. Em caso
+// de recusa retorna o pagamento registrado e ErrDeclined.
+func Pay(ctx context.Context, db *sql.DB, order *models.Order, paymentMethod string) (*models.Payment, error) {
+	if order.Status != models.OrderPending {
+		return nil, models.ErrOrderNotPayable
+	}
+	// Cobrança PIX vencida não bloqueia o pagamento por outro meio
+	if _, err := models.ExpirePixCharges(db); err != nil {
+		return nil, err
+	}
+
+	res, err := gateway.Authorize(ctx, AuthorizeRequest{
+		OrderID:       order.ID,
+		AmountCents:   order.TotalCents,
+		Currency:      settings.Currency,
+		PaymentMethod: paymentMethod,
+	})
+	record("authorize", res, err)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &models.Payment{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Gateway:     gateway.Name(),
+		GatewayRef:  res.Reference,
+		Status:      models.PaymentAuthorized,
+		AmountCents: order.TotalCents,
+		Currency:    settings.Currency,
+	}
+	if !res.Approved {
+		p.Status = models.PaymentDeclined
+		p.DeclineCode = res.DeclineCode
+	}
+
+	if err := models.CreatePayment(db, p); err != nil {
+		// Outro pagamento venceu a corrida: liberar a autorização que sobrou
+		if res.Approved {
+			if _, voidErr := gateway.Void(ctx, res.Reference); voidErr != nil {
+				slog.ErrorContext(ctx, "autorização órfã no gateway", "gateway", gateway.Name(), "reference", res.Reference, "error", voidErr)
+			}
+		}
+		return nil, err
+	}
+
+	if !res.Approved {
+		return p, ErrDeclined
+	}
+
+	if settings.AutoCapture {
+		if err := Capture(ctx, db, p); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// Capturar o valor autorizado
+func Capture(ctx context.Context, db *sql.DB, p *models.Payment) error {
+	switch p.Gateway {
+	case gateway.Name():
+	case PixGateway:
+		// PIX não tem autorização: o valor é capturado quando a transferência chega
+		return fmt.Errorf("%w: PIX é capturado no recebimento", models.ErrPaymentState)
+	default:
+		return ErrUnsupported
+	}
+	if p.Status != models.PaymentAuthorized {
+		return models.ErrPaymentState
+	}
+
+	// Itens cancelados depois da autorização reduzem o valor capturado
+	amount, err := models.GetOrderTotal(db, p.OrderID)
+	if err != nil {
+		return err
+	}
+	if amount > p.AmountCents {
+		amount = p.AmountCents
+	}
+
+	res, err := gateway.Capture(ctx, p.GatewayRef, amount)
+	record("capture", res, err)
+	if err != nil {
+		return err
+	}
+
+	if err := models.MarkPaymentCaptured(db, p, amount); err != nil {
+		slog.ErrorContext(ctx, "captura feita no gateway mas não registrada", "payment_id", p.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Liberar uma autorização ainda não capturada. Para PIX, remover a cobrança
+// ainda não paga.
+func Void(ctx context.Context, db *sql.DB, p *models.Payment, cancelOrder bool) error {
+	switch p.Gateway {
+	case gateway.Name():
+	case PixGateway:
+		return voidPix(ctx, db, p, cancelOrder)
+	default:
+		return ErrUnsupported
+	}
+	if p.Status != models.PaymentAuthorized {
+		return models.ErrPaymentState
+	}
+
+	res, err := gateway.Void(ctx, p.GatewayRef)
+	record("void", res, err)
+	if err != nil {
+		return err
+	}
+
+	return models.MarkPaymentVoided(db, p, cancelOrder)
+}
+
+// Estornar um pagamento capturado. Com amountCents 0 estorna todo o saldo
+// ainda não estornado. Estornos parciais mantêm o pagamento captured.
+func Refund(ctx context.Context, db *sql.DB, p *models.Payment, amountCents int64, reason, note string) (*models.Refund, error) {
+	if amountCents == 0 {
+		amountCents = p.CapturedCents - p.RefundedCents
+	}
+	return refund(ctx, db, p, &models.Refund{AmountCents: amountCents, Reason: reason, Note: note})
+}
+
+// Estorno pelo gateway do pagamento: cartão pelo Gateway, PIX por devolução
+// no PSP
+func refund(ctx context.Context, db *sql.DB, p *models.Payment, r *models.Refund) (*models.Refund, error) {
+	if p.Gateway != gateway.Name() && p.Gateway != PixGateway {
+		return nil, ErrUnsupported
+	}
+	if p.Status != models.PaymentCaptured {
+		return nil, models.ErrPaymentState
+	}
+	if r.AmountCents <= 0 || p.RefundedCents+r.AmountCents > p.CapturedCents {
+		return nil, models.ErrInvalidRefundAmount
+	}
+
+	if p.Gateway == PixGateway {
+		ref, err := refundPix(ctx, db, p, r.AmountCents)
+		if err != nil {
+			return nil, err
+		}
+		r.GatewayRef = ref
+	} else {
+		res, err := gateway.Refund(ctx, p.GatewayRef, r.AmountCents)
+		record("refund", res, err)
+		if err != nil {
+			return nil, err
+		}
+		r.GatewayRef = res.Reference
+	}
+
+	if err := models.RecordRefund(db, p, r); err != nil {
+		slog.ErrorContext(ctx, "estorno feito no gateway mas não registrado", "payment_id", p.ID, "amount_cents", r.AmountCents, "error", err)
+		return nil, err
+	}
+	return r, nil
+}
+
+// Cancelar itens de um pedido. Antes do pagamento o total é apenas
+// recalculado (com pagamento autorizado, a captura usa o novo total); depois
+// da captura o valor dos itens é estornado. Cancelar tudo o que resta equivale
+// a cancelar o pedido. Pedidos finalizados (já entregues) só são estornados
+// pela equipe (models.ErrOrderState). Retorna o estorno, quando houver.
+func CancelItems(ctx context.Context, db *sql.DB, order *models.Order, cancels []models.ItemCancellation, reason, note string) (*models.Refund, error) {
+	items, amount, err := models.PriceCancellation(order, cancels)
+	if err != nil {
+		return nil, err
+	}
+	all := true
+	for _, item := range order.Items {
+		cancelled := 0
+		for _, c := range items {
+			if c.OrderItemID == item.ID {
+				cancelled = c.Quantity
+			}
+		}
+		if item.ActiveQuantity() > cancelled {
+			all = false
+		}
+	}
+
+	switch order.Status {
+	case models.OrderPending:
+		return nil, models.CancelOrderItems(db, order.ID, items)
+	case models.OrderAuthorized:
+		if all {
+			return nil, CancelOrder(ctx, db, order.ID, order.UserID)
+		}
+		return nil, models.CancelOrderItems(db, order.ID, items)
+	case models.OrderPaid:
+		p, err := models.GetActiveOrderPayment(db, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil || p.Status != models.PaymentCaptured {
+			return nil, models.ErrPaymentState
+		}
+		// Estornos parciais anteriores limitam o valor que ainda pode voltar;
+		// sem itens restantes, todo o saldo é devolvido
+		if remaining := p.CapturedCents - p.RefundedCents; all || amount > remaining {
+			amount = remaining
+		}
+		return refund(ctx, db, p, &models.Refund{AmountCents: amount, Reason: reason, Note: note, Items: items})
+	}
+	return nil, models.ErrOrderState
+}
+
+// Cancelar pedido do usuário: pendente é cancelado, autorização é liberada e
+// pedido pago (ainda não finalizado) é estornado com todos os itens. Pedidos
+// finalizados só são estornados pela equipe (models.ErrOrderState).
+func CancelOrder(ctx context.Context, db *sql.DB, orderID, userID string) error {
+	order, err := models.GetOrderByID(db, orderID, userID)
+	if err != nil {
+		return err
+	}
+
+	switch order.Status {
+	case models.OrderPending:
+		return models.CancelOrder(db, orderID, userID)
+	case models.OrderAuthorized:
+		p, err := models.GetActiveOrderPayment(db, orderID)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return models.ErrPaymentState
+		}
+		return Void(ctx, db, p, true)
+	case models.OrderPaid:
+		var cancels []models.ItemCancellation
+		for _, item := range order.Items {
+			if item.ActiveQuantity() > 0 {
+				cancels = append(cancels, models.ItemCancellation{ItemID: item.ID, Quantity: item.ActiveQuantity()})
+			}
+		}
+		_, err := CancelItems(ctx, db, order, cancels, models.RefundCustomerRequest, "")
+		return err
+	}
+	return models.ErrOrderState
+}
+
+func record(operation string, res *Result, err error) {
+	outcome := "approved"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case !res.Approved:
+		outcome = "declined"
+	}
+	metrics.PaymentsTotal.Inc(gateway.Name(), operation, outcome)
+}
@@ -0,0 +1,269 @@
+// Arquivo: backend/pricing/pricing.go
+
+// Cálculo do valor de um pedido: descontos de promoções, tributos e taxa de
+// entrega, nessa ordem (Apply, Deduct, ApplyTaxes, AddDeliveryFee). O pacote
+// é puro (sem banco): models carrega as regras e grava o resultado.
+package pricing
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tipos de promoção
+const (
+	KindPercentage = "percentage"  // Percent% de desconto nos itens do escopo
+	KindFixed      = "fixed"       // AmountCents de desconto, rateado entre os itens do escopo
+	KindBuyXGetY   = "buy_x_get_y" // a cada BuyQuantity+GetQuantity unidades, GetQuantity (as mais baratas) saem grátis
+	KindFreeItem   = "free_item"   // GetQuantity unidades do escopo grátis, uma vez por pedido
+)
+
+var Kinds = []string{KindPercentage, KindFixed, KindBuyXGetY, KindFreeItem}
+
+// Linha do pedido já precificada pelo cardápio
+type Line struct {
+	Category    string
+	Product     string
+	TaxCategory string // vazio = Category
+	UnitCents   int64
+	Quantity    int
+}
+
+func (l Line) taxCategory() string {
+	if l.TaxCategory != "" {
+		return l.TaxCategory
+	}
+	return l.Category
+}
+
+func (l Line) Subtotal() int64 {
+	return l.UnitCents * int64(l.Quantity)
+}
+
+// Regra de promoção. Category/Product vazios não restringem o escopo.
+type Promotion struct {
+	ID            string
+	Name          string
+	Code          string // cupom; vazio = promoção automática
+	Kind          string
+	Percent       int
+	AmountCents   int64
+	BuyQuantity   int
+	GetQuantity   int
+	Category      string
+	Product       string
+	MinOrderCents int64 // subtotal mínimo do pedido, sem as unidades que a própria promoção dá grátis
+	Weekdays      []int // 0 = domingo; vazio = todos os dias
+	StartsAt      *time.Time
+	EndsAt        *time.Time
+}
+
+// Promoção vigente no instante informado (já no fuso da loja)
+func (p Promotion) ActiveAt(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	if len(p.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range p.Weekdays {
+		if time.Weekday(d) == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Promotion) inScope(l Line) bool {
+	if p.Category != "" && !strings.EqualFold(p.Category, l.Category) {
+		return false
+	}
+	if p.Product != "" && !strings.EqualFold(p.Product, l.Product) {
+		return false
+	}
+	return true
+}
+
+// Desconto aplicado por uma promoção, com o valor por linha
+type Discount struct {
+	Promotion   Promotion
+	AmountCents int64
+	Lines       []int64
+}
+
+// TotalCents = SubtotalCents - DiscountCents + TaxCents + DeliveryFeeCents
+type Result struct {
+	SubtotalCents    int64
+	DiscountCents    int64
+	TaxCents         int64 // tributos somados ao total
+	IncludedTaxCents int64 // tributos embutidos nos preços (informativo)
+	DeliveryFeeCents int64
+	TotalCents       int64
+	LineDiscounts    []int64 // desconto total de cada linha (mesmo índice de lines)
+	LineTotals       []int64 // valor de cada linha depois dos descontos (sem tributos)
+	LineTaxes        [][]Tax // tributos de cada linha
+	Discounts        []Discount
+	Taxes            []Tax // tributos do pedido, um por regra aplicada
+}
+
+// Aplicar as promoções na ordem recebida (automáticas antes do cupom). Cada
+// uma incide sobre o valor que sobrou das anteriores, então nenhum item fica
+// negativo. Promoções fora do escopo ou abaixo do mínimo não entram no resultado.
+func Apply(lines []Line, promotions []Promotion) Result {
+	res := Result{LineDiscounts: make([]int64, len(lines))}
+	for _, l := range lines {
+		res.SubtotalCents += l.Subtotal()
+	}
+
+	remaining := make([]int64, len(lines))
+	for i, l := range lines {
+		remaining[i] = l.Subtotal()
+	}
+
+	for _, p := range promotions {
+		perLine := p.discount(lines, remaining)
+		var amount int64
+		for _, d := range perLine {
+			amount += d
+		}
+		// "Leve 3 pague 2" e item grátis não podem atingir o mínimo com o
+		// próprio item que dão de graça
+		subtotal := res.SubtotalCents
+		if p.Kind == KindBuyXGetY || p.Kind == KindFreeItem {
+			subtotal -= amount
+		}
+		if subtotal < p.MinOrderCents {
+			continue
+		}
+
+		for i, d := range perLine {
+			remaining[i] -= d
+			res.LineDiscounts[i] += d
+		}
+		if amount > 0 {
+			res.Discounts = append(res.Discounts, Discount{Promotion: p, AmountCents: amount, Lines: perLine})
+			res.DiscountCents += amount
+		}
+	}
+
+	res.TotalCents = res.SubtotalCents - res.DiscountCents
+	res.LineTotals = remaining
+	return res
+}
+
+// Abater um valor avulso do total (ex.: resgate de pontos), rateado entre as
+// linhas pelo valor restante. Deve vir antes de ApplyTaxes. Retorna o valor
+// efetivamente abatido.
+func (r *Result) Deduct(amountCents int64) int64 {
+	perLine := Allocate(amountCents, r.LineTotals)
+	var amount int64
+	for i, d := range perLine {
+		r.LineTotals[i] -= d
+		r.LineDiscounts[i] += d
+		amount += d
+	}
+	r.DiscountCents += amount
+	r.TotalCents -= amount
+	return amount
+}
+
+// Desconto por linha, limitado ao valor restante de cada uma
+func (p Promotion) discount(lines []Line, remaining []int64) []int64 {
+	perLine := make([]int64, len(lines))
+	scope := make([]int64, len(lines)) // valor restante das linhas no escopo
+	for i, l := range lines {
+		if p.inScope(l) {
+			scope[i] = remaining[i]
+		}
+	}
+
+	switch p.Kind {
+	case KindPercentage:
+		var total int64
+		for _, v := range scope {
+			total += v
+		}
+		return Allocate(total*int64(p.Percent)/100, scope)
+	case KindFixed:
+		return Allocate(p.AmountCents, scope)
+	case KindBuyXGetY:
+		group := p.BuyQuantity + p.GetQuantity
+		if group <= 0 || p.GetQuantity <= 0 {
+			return perLine
+		}
+		units := 0
+		for i, l := range lines {
+			if scope[i] > 0 {
+				units += l.Quantity
+			}
+		}
+		return freeUnits(lines, scope, units/group*p.GetQuantity)
+	case KindFreeItem:
+		return freeUnits(lines, scope, p.GetQuantity)
+	}
+	return perLine
+}
+
+// Zerar as n unidades mais baratas do escopo
+func freeUnits(lines []Line, scope []int64, n int) []int64 {
+	perLine := make([]int64, len(lines))
+	order := make([]int, 0, len(lines))
+	for i := range lines {
+		if scope[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return lines[order[a]].UnitCents < lines[order[b]].UnitCents
+	})
+
+	for _, i := range order {
+		if n <= 0 {
+			break
+		}
+		units := lines[i].Quantity
+		if units > n {
+			units = n
+		}
+		n -= units
+		perLine[i] = min(lines[i].UnitCents*int64(units), scope[i])
+	}
+	return perLine
+}
+
+// Ratear amount proporcionalmente aos pesos, sem ultrapassar nenhum deles.
+// Os centavos que sobram do arredondamento vão para as primeiras linhas.
+func Allocate(amount int64, weights []int64) []int64 {
+	out := make([]int64, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if amount <= 0 || total <= 0 {
+		return out
+	}
+	if amount >= total {
+		copy(out, weights)
+		return out
+	}
+
+	var given int64
+	for i, w := range weights {
+		out[i] = amount * w / total
+		given += out[i]
+	}
+	for i := range out {
+		if given == amount {
+			break
+		}
+		if out[i] < weights[i] {
+			out[i]++
+			given++
+		}
+	}
+	return out
+}
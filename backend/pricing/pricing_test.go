@@ -0,0 +1,224 @@
+// Arquivo: backend/pricing/pricing_test.go
+package pricing
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{"sem valor", 0, []int64{100, 200}, []int64{0, 0}},
+		{"valor negativo", -5, []int64{10}, []int64{0}},
+		{"pesos zerados", 10, []int64{0, 0}, []int64{0, 0}},
+		{"sem pesos", 10, nil, []int64{}},
+		{"proporcional exato", 300, []int64{100, 200}, []int64{100, 200}},
+		{"proporcional", 90, []int64{100, 200}, []int64{30, 60}},
+		{"sobra vai para a primeira linha", 100, []int64{100, 200, 300}, []int64{17, 33, 50}},
+		{"sobra em várias linhas", 2, []int64{1, 1, 1}, []int64{1, 1, 0}},
+		{"linha de peso zero não recebe", 1, []int64{0, 1, 1}, []int64{0, 1, 0}},
+		{"limitado aos pesos", 500, []int64{100, 200}, []int64{100, 200}},
+	}
+
+	for _, tt := range tests {
+		got := Allocate(tt.amount, tt.weights)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Allocate(%d, %v) = %v, esperado %v", tt.name, tt.amount, tt.weights, got, tt.want)
+			continue
+		}
+		var sum int64
+		for i, v := range got {
+			if v > tt.weights[i] {
+				t.Errorf("%s: linha %d recebeu %d, acima do peso %d", tt.name, i, v, tt.weights[i])
+			}
+			sum += v
+		}
+		if tt.amount > 0 && sum != min(tt.amount, sumOf(tt.weights)) {
+			t.Errorf("%s: rateou %d de %d", tt.name, sum, tt.amount)
+		}
+	}
+}
+
+func TestFreeUnits(t *testing.T) {
+	lines := []Line{
+		{Product: "X-Burger", UnitCents: 500, Quantity: 2},
+		{Product: "Refrigerante", UnitCents: 300, Quantity: 1},
+		{Product: "X-Salada", UnitCents: 800, Quantity: 1},
+	}
+	full := []int64{1000, 300, 800}
+
+	tests := []struct {
+		name  string
+		scope []int64
+		n     int
+		want  []int64
+	}{
+		{"nenhuma unidade", full, 0, []int64{0, 0, 0}},
+		{"mais barata primeiro", full, 1, []int64{0, 300, 0}},
+		{"segue para a próxima mais barata", full, 2, []int64{500, 300, 0}},
+		{"todas as unidades da linha", full, 3, []int64{1000, 300, 0}},
+		{"mais unidades que o pedido", full, 10, []int64{1000, 300, 800}},
+		{"linha fora do escopo", []int64{1000, 0, 800}, 2, []int64{1000, 0, 0}},
+		{"limitado ao valor restante", []int64{400, 0, 0}, 1, []int64{400, 0, 0}},
+		{"escopo vazio", []int64{0, 0, 0}, 2, []int64{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		got := freeUnits(lines, tt.scope, tt.n)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: freeUnits(%v, %d) = %v, esperado %v", tt.name, tt.scope, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	lines := []Line{
+		{Category: "lanches", Product: "X-Burger", UnitCents: 2500, Quantity: 2},
+		{Category: "bebidas", Product: "Refrigerante", UnitCents: 600, Quantity: 3},
+	}
+
+	tests := []struct {
+		name          string
+		promotions    []Promotion
+		wantDiscount  int64
+		wantLines     []int64 // desconto por linha
+		wantDiscounts int     // promoções que entraram no resultado
+	}{
+		{"sem promoções", nil, 0, []int64{0, 0}, 0},
+		{
+			"percentual no pedido todo",
+			[]Promotion{{Kind: KindPercentage, Percent: 10}},
+			680, []int64{500, 180}, 1,
+		},
+		{
+			"percentual por categoria",
+			[]Promotion{{Kind: KindPercentage, Percent: 10, Category: "Lanches"}},
+			500, []int64{500, 0}, 1,
+		},
+		{
+			"valor fixo por produto",
+			[]Promotion{{Kind: KindFixed, AmountCents: 1000, Product: "refrigerante"}},
+			1000, []int64{0, 1000}, 1,
+		},
+		{
+			"valor fixo maior que o pedido",
+			[]Promotion{{Kind: KindFixed, AmountCents: 10000}},
+			6800, []int64{5000, 1800}, 1,
+		},
+		{
+			"abaixo do pedido mínimo",
+			[]Promotion{{Kind: KindPercentage, Percent: 10, MinOrderCents: 7000}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			"pedido mínimo atingido",
+			[]Promotion{{Kind: KindPercentage, Percent: 10, MinOrderCents: 6800}},
+			680, []int64{500, 180}, 1,
+		},
+		{
+			"leve 3 pague 2",
+			[]Promotion{{Kind: KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Category: "bebidas"}},
+			600, []int64{0, 600}, 1,
+		},
+		{
+			"leve 3 pague 2 sem unidades suficientes",
+			[]Promotion{{Kind: KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Category: "lanches"}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			"leve e ganhe sem unidades grátis",
+			[]Promotion{{Kind: KindBuyXGetY, BuyQuantity: 2}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			"item grátis",
+			[]Promotion{{Kind: KindFreeItem, GetQuantity: 1}},
+			600, []int64{0, 600}, 1,
+		},
+		{
+			// 6800 - 600 do refrigerante grátis
+			"item grátis não conta para o mínimo",
+			[]Promotion{{Kind: KindFreeItem, GetQuantity: 1, MinOrderCents: 6800}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			"mínimo atingido sem o item grátis",
+			[]Promotion{{Kind: KindFreeItem, GetQuantity: 1, MinOrderCents: 6200}},
+			600, []int64{0, 600}, 1,
+		},
+		{
+			"leve 3 pague 2 com a unidade grátis abaixo do mínimo",
+			[]Promotion{{Kind: KindBuyXGetY, BuyQuantity: 2, GetQuantity: 1, Category: "bebidas", MinOrderCents: 6500}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			"escopo sem itens",
+			[]Promotion{{Kind: KindPercentage, Percent: 10, Category: "sobremesas"}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			"tipo desconhecido",
+			[]Promotion{{Kind: "mystery", Percent: 10}},
+			0, []int64{0, 0}, 0,
+		},
+		{
+			// O cupom incide sobre o que sobrou da automática e não deixa linha negativa
+			"promoções acumuladas",
+			[]Promotion{
+				{Kind: KindPercentage, Percent: 50},
+				{Kind: KindFixed, AmountCents: 5000, Code: "CUPOM"},
+			},
+			6800, []int64{5000, 1800}, 2,
+		},
+		{
+			"segunda promoção sobre o restante",
+			[]Promotion{
+				{Kind: KindFreeItem, GetQuantity: 1},
+				{Kind: KindPercentage, Percent: 10, Category: "bebidas"},
+			},
+			720, []int64{0, 720}, 2,
+		},
+	}
+
+	for _, tt := range tests {
+		res := Apply(lines, tt.promotions)
+		if res.SubtotalCents != 6800 {
+			t.Errorf("%s: subtotal %d, esperado 6800", tt.name, res.SubtotalCents)
+		}
+		if res.DiscountCents != tt.wantDiscount || res.TotalCents != 6800-tt.wantDiscount {
+			t.Errorf("%s: desconto %d total %d, esperado %d e %d",
+				tt.name, res.DiscountCents, res.TotalCents, tt.wantDiscount, 6800-tt.wantDiscount)
+		}
+		if !slices.Equal(res.LineDiscounts, tt.wantLines) {
+			t.Errorf("%s: desconto por linha %v, esperado %v", tt.name, res.LineDiscounts, tt.wantLines)
+		}
+		for i, l := range lines {
+			if res.LineTotals[i] != l.Subtotal()-res.LineDiscounts[i] || res.LineTotals[i] < 0 {
+				t.Errorf("%s: linha %d vale %d depois do desconto de %d", tt.name, i, res.LineTotals[i], res.LineDiscounts[i])
+			}
+		}
+		if len(res.Discounts) != tt.wantDiscounts {
+			t.Errorf("%s: %d promoções aplicadas, esperado %d", tt.name, len(res.Discounts), tt.wantDiscounts)
+		}
+		var sum int64
+		for _, d := range res.Discounts {
+			sum += d.AmountCents
+		}
+		if sum != res.DiscountCents {
+			t.Errorf("%s: promoções somam %d, desconto %d", tt.name, sum, res.DiscountCents)
+		}
+	}
+}
+
+func sumOf(values []int64) int64 {
+	var total int64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,103 @@
+// Arquivo: backend/pricing/tax.go
+package pricing
+
+import "strings"
+
+// Regra de tributo. Jurisdiction/Category vazios valem para todas.
+type TaxRule struct {
+	ID           string
+	Name         string // tributo (ex.: ICMS); regras de mesmo nome competem entre si
+	Jurisdiction string // UF
+	Category     string // categoria fiscal do produto (ver Line.TaxCategory)
+	RateBps      int    // alíquota em pontos-base (1800 = 18%)
+	Inclusive    bool   // já embutido no preço: informado, mas não somado ao total
+}
+
+// Especificidade da regra para a linha; -1 quando não se aplica. Categoria
+// pesa mais que jurisdição.
+func (t TaxRule) match(l Line, jurisdiction string) int {
+	score := 0
+	if t.Category != "" {
+		if !strings.EqualFold(t.Category, l.taxCategory()) {
+			return -1
+		}
+		score += 2
+	}
+	if t.Jurisdiction != "" {
+		if !strings.EqualFold(t.Jurisdiction, jurisdiction) {
+			return -1
+		}
+		score++
+	}
+	return score
+}
+
+// Tributo calculado (de uma linha ou somado no pedido)
+type Tax struct {
+	Rule        TaxRule
+	BaseCents   int64
+	AmountCents int64
+}
+
+// Calcular os tributos de cada linha sobre o valor depois dos descontos, na
+// jurisdição informada. Para cada tributo vale só a regra mais específica
+// (empate: a primeira recebida). Tributos não inclusos somam ao total.
+func (r *Result) ApplyTaxes(lines []Line, rules []TaxRule, jurisdiction string) {
+	r.LineTaxes = make([][]Tax, len(lines))
+	byRule := make(map[int]int) // índice da regra -> posição em r.Taxes
+
+	for i, l := range lines {
+		best := make(map[string]int) // tributo -> índice da regra escolhida
+		for j, rule := range rules {
+			score := rule.match(l, jurisdiction)
+			if score < 0 {
+				continue
+			}
+			name := strings.ToUpper(rule.Name)
+			if k, ok := best[name]; !ok || score > rules[k].match(l, jurisdiction) {
+				best[name] = j
+			}
+		}
+
+		base := r.LineTotals[i]
+		for j, rule := range rules {
+			if best[strings.ToUpper(rule.Name)] != j || rule.match(l, jurisdiction) < 0 {
+				continue
+			}
+			tax := Tax{Rule: rule, BaseCents: base, AmountCents: (base*int64(rule.RateBps) + 5000) / 10000}
+			r.LineTaxes[i] = append(r.LineTaxes[i], tax)
+
+			if k, ok := byRule[j]; ok {
+				r.Taxes[k].BaseCents += tax.BaseCents
+				r.Taxes[k].AmountCents += tax.AmountCents
+			} else {
+				byRule[j] = len(r.Taxes)
+				r.Taxes = append(r.Taxes, tax)
+			}
+			if rule.Inclusive {
+				r.IncludedTaxCents += tax.AmountCents
+			} else {
+				r.TaxCents += tax.AmountCents
+				r.TotalCents += tax.AmountCents
+			}
+		}
+	}
+}
+
+// Tributos de uma linha: somados ao preço e embutidos nele
+func (r *Result) LineTaxCents(i int) (added, included int64) {
+	for _, t := range r.LineTaxes[i] {
+		if t.Rule.Inclusive {
+			included += t.AmountCents
+		} else {
+			added += t.AmountCents
+		}
+	}
+	return added, included
+}
+
+// Somar a taxa de entrega ao total (não tributada)
+func (r *Result) AddDeliveryFee(cents int64) {
+	r.DeliveryFeeCents += cents
+	r.TotalCents += cents
+}
@@ -0,0 +1,148 @@
+// Arquivo: backend/pricing/tax_test.go
+package pricing
+
+import "testing"
+
+func TestApplyTaxes(t *testing.T) {
+	lines := []Line{
+		{Category: "lanches", Product: "X-Burger", UnitCents: 1000, Quantity: 1},
+		{Category: "bebidas", Product: "Cerveja", TaxCategory: "alcoolicas", UnitCents: 500, Quantity: 2},
+	}
+	icms := TaxRule{Name: "ICMS", RateBps: 1800}
+
+	tests := []struct {
+		name         string
+		promotions   []Promotion
+		rules        []TaxRule
+		jurisdiction string
+		wantTax      int64      // somado ao total
+		wantIncluded int64      // embutido nos preços
+		wantLines    [][2]int64 // por linha: somado, embutido
+		wantTaxes    int        // regras aplicadas no pedido
+	}{
+		{"sem regras", nil, nil, "SP", 0, 0, [][2]int64{{0, 0}, {0, 0}}, 0},
+		{
+			"regra geral",
+			nil, []TaxRule{icms}, "SP",
+			360, 0, [][2]int64{{180, 0}, {180, 0}}, 1,
+		},
+		{
+			"base depois dos descontos",
+			[]Promotion{{Kind: KindPercentage, Percent: 10}}, []TaxRule{icms}, "SP",
+			324, 0, [][2]int64{{162, 0}, {162, 0}}, 1,
+		},
+		{
+			"outra jurisdição não se aplica",
+			nil, []TaxRule{{Name: "ICMS", Jurisdiction: "RJ", RateBps: 2000}}, "SP",
+			0, 0, [][2]int64{{0, 0}, {0, 0}}, 0,
+		},
+		{
+			"jurisdição sem diferenciar maiúsculas",
+			nil, []TaxRule{{Name: "ICMS", Jurisdiction: "sp", RateBps: 1200}}, "SP",
+			240, 0, [][2]int64{{120, 0}, {120, 0}}, 1,
+		},
+		{
+			// Categoria pesa mais que jurisdição, que pesa mais que a regra geral
+			"regra mais específica vence",
+			nil, []TaxRule{
+				icms,
+				{Name: "ICMS", Jurisdiction: "SP", RateBps: 1200},
+				{Name: "icms", Category: "lanches", RateBps: 700},
+			}, "SP",
+			190, 0, [][2]int64{{70, 0}, {120, 0}}, 2,
+		},
+		{
+			"empate fica com a primeira regra",
+			nil, []TaxRule{icms, {Name: "ICMS", RateBps: 1200}}, "SP",
+			360, 0, [][2]int64{{180, 0}, {180, 0}}, 1,
+		},
+		{
+			"categoria fiscal no lugar da categoria",
+			nil, []TaxRule{
+				{Name: "IPI", Category: "bebidas", RateBps: 1000},
+				{Name: "IPI", Category: "ALCOOLICAS", RateBps: 1500},
+			}, "SP",
+			150, 0, [][2]int64{{0, 0}, {150, 0}}, 1,
+		},
+		{
+			"tributos diferentes somam",
+			nil, []TaxRule{icms, {Name: "PIS", RateBps: 165}}, "SP",
+			394, 0, [][2]int64{{197, 0}, {197, 0}}, 2,
+		},
+		{
+			"embutido não soma ao total",
+			nil, []TaxRule{{Name: "ISS", RateBps: 500, Inclusive: true}}, "SP",
+			0, 100, [][2]int64{{0, 50}, {0, 50}}, 1,
+		},
+		{
+			"somado e embutido",
+			nil, []TaxRule{icms, {Name: "ISS", RateBps: 500, Inclusive: true}}, "SP",
+			360, 100, [][2]int64{{180, 50}, {180, 50}}, 2,
+		},
+		{
+			// 1,25% de 10,00 = 12,5 centavos, arredondado por linha
+			"arredondamento por linha",
+			nil, []TaxRule{{Name: "COFINS", RateBps: 125}}, "SP",
+			26, 0, [][2]int64{{13, 0}, {13, 0}}, 1,
+		},
+	}
+
+	for _, tt := range tests {
+		res := Apply(lines, tt.promotions)
+		before := res.TotalCents
+		res.ApplyTaxes(lines, tt.rules, tt.jurisdiction)
+
+		if res.TaxCents != tt.wantTax || res.IncludedTaxCents != tt.wantIncluded {
+			t.Errorf("%s: tributos %d (embutidos %d), esperado %d (%d)",
+				tt.name, res.TaxCents, res.IncludedTaxCents, tt.wantTax, tt.wantIncluded)
+		}
+		if res.TotalCents != before+tt.wantTax {
+			t.Errorf("%s: total %d, esperado %d", tt.name, res.TotalCents, before+tt.wantTax)
+		}
+		for i, want := range tt.wantLines {
+			added, included := res.LineTaxCents(i)
+			if added != want[0] || included != want[1] {
+				t.Errorf("%s: linha %d com tributos %d/%d, esperado %d/%d", tt.name, i, added, included, want[0], want[1])
+			}
+		}
+		if len(res.Taxes) != tt.wantTaxes {
+			t.Errorf("%s: %d regras aplicadas, esperado %d", tt.name, len(res.Taxes), tt.wantTaxes)
+		}
+
+		// O resumo do pedido soma as linhas
+		var added, included, base int64
+		for _, tax := range res.Taxes {
+			if tax.Rule.Inclusive {
+				included += tax.AmountCents
+			} else {
+				added += tax.AmountCents
+			}
+			base += tax.BaseCents
+		}
+		if added != res.TaxCents || included != res.IncludedTaxCents {
+			t.Errorf("%s: resumo soma %d/%d, esperado %d/%d", tt.name, added, included, res.TaxCents, res.IncludedTaxCents)
+		}
+		var lineBase int64
+		for i := range lines {
+			for _, tax := range res.LineTaxes[i] {
+				lineBase += tax.BaseCents
+			}
+		}
+		if base != lineBase {
+			t.Errorf("%s: base do resumo %d, linhas somam %d", tt.name, base, lineBase)
+		}
+	}
+}
+
+func TestAddDeliveryFee(t *testing.T) {
+	lines := []Line{{Category: "lanches", UnitCents: 1000, Quantity: 1}}
+	res := Apply(lines, nil)
+	res.ApplyTaxes(lines, []TaxRule{{Name: "ICMS", RateBps: 1800}}, "SP")
+	res.AddDeliveryFee(700)
+
+	// A taxa de entrega não é tributada
+	if res.DeliveryFeeCents != 700 || res.TaxCents != 180 || res.TotalCents != 1880 {
+		t.Errorf("entrega %d tributos %d total %d, esperado 700, 180 e 1880",
+			res.DeliveryFeeCents, res.TaxCents, res.TotalCents)
+	}
+}
@@ -0,0 +1,46 @@
+// Arquivo: backend/receipts/authority.go
+
+// Cupom fiscal eletrônico (NFC-e, modelo 65) dos pedidos finalizados: XML no
+// leiaute da NFC-e, autorização pelo fisco e DANFE NFC-e em PDF. O Authority
+// abstrai a SEFAZ; a persistência fica em models e a orquestração em
+// service.go.
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Autorizador da NFC-e (SEFAZ do estado ou SVC). Uma rejeição não é erro: vem
+// em Authorization.Authorized = false com o código em Status. Erros indicam
+// falha de comunicação e podem ser tentados de novo.
+type Authority interface {
+	// Nome para logs e métricas
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error)
+}
+
+type AuthorizeRequest struct {
+	AccessKey   string // chave de acesso (44 dígitos)
+	Environment int    // tpAmb: 1 produção, 2 homologação
+	XML         []byte // NFe assinada
+}
+
+type Authorization struct {
+	Authorized bool
+	Status     int    // cStat (100 = autorizado o uso)
+	Message    string // xMotivo
+	Protocol   string // nProt
+	ReceivedAt time.Time
+}
+
+// NFC-e rejeitada pelo autorizador
+type RejectedError struct {
+	Status  int
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("NFC-e rejeitada (%d): %s", e.Status, e.Message)
+}
@@ -0,0 +1,109 @@
+// Arquivo: backend/receipts/kitchen.go
+package receipts
+
+import (
+	"errors"
+	"finplay/backend/models"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrTicketUnavailable = errors.New("comanda disponível apenas para pedidos pagos")
+
+// Comanda da cozinha em texto puro na largura da bobina: um bloco por item
+// com a quantidade ainda não cancelada, as opções por grupo (SEM para
+// retiradas, + para adicionais, GRUPO: opção para variações) e as
+// observações.
+func KitchenTicket(order *models.Order) ([]byte, error) {
+	switch order.Status {
+	case models.OrderAuthorized, models.OrderPaid, models.OrderCompleted:
+	default:
+		return nil, ErrTicketUnavailable
+	}
+
+	var b strings.Builder
+	line := func(text string) {
+		b.WriteString(text)
+		b.WriteByte('\n')
+	}
+	center := func(text string) {
+		n := utf8.RuneCountInString(text)
+		if n < columns {
+			text = strings.Repeat(" ", (columns-n)/2) + text
+		}
+		line(text)
+	}
+	// Texto quebrado na largura, com recuo nas linhas seguintes
+	indented := func(prefix, text string) {
+		pad := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+		for i, l := range wrap(text, columns-len(pad)) {
+			if i == 0 {
+				line(prefix + l)
+			} else {
+				line(pad + l)
+			}
+		}
+	}
+
+	line(strings.Repeat("=", columns))
+	center("COMANDA")
+	center("Pedido " + strings.ToUpper(shortID(order.ID)))
+	center(order.CreatedAt.In(location).Format("02/01/2006 15:04"))
+	if order.DeliveryFeeCents > 0 {
+		center("*** ENTREGA ***")
+	}
+
+	for _, it := range order.Items {
+		qty := it.Quantity - it.CancelledQuantity
+		if qty <= 0 {
+			continue
+		}
+		line(strings.Repeat("-", columns))
+		indented(fmt.Sprintf("%dx ", qty), strings.ToUpper(it.ProductName))
+
+		var removed, added []string
+		var choices []string // grupos de escolha, na ordem do cardápio
+		chosen := make(map[string][]string)
+		for _, m := range it.Modifiers {
+			switch m.Kind {
+			case models.ModifierRemove:
+				removed = append(removed, m.Option)
+			case models.ModifierAdd:
+				added = append(added, m.Option)
+			default:
+				if _, ok := chosen[m.Group]; !ok {
+					choices = append(choices, m.Group)
+				}
+				chosen[m.Group] = append(chosen[m.Group], m.Option)
+			}
+		}
+		for _, g := range choices {
+			indented("   "+strings.ToUpper(g)+": ", strings.ToUpper(strings.Join(chosen[g], ", ")))
+		}
+		for _, o := range removed {
+			indented("   SEM ", strings.ToUpper(o))
+		}
+		for _, o := range added {
+			indented("   + ", strings.ToUpper(o))
+		}
+		if note := strings.TrimSpace(it.Ingredients); note != "" {
+			indented("   OBS: ", note)
+		}
+	}
+
+	line(strings.Repeat("=", columns))
+	if note := strings.TrimSpace(order.Notes); note != "" {
+		indented("OBS. DO PEDIDO: ", note)
+		line(strings.Repeat("=", columns))
+	}
+	return []byte(b.String()), nil
+}
+
+// Identificador curto do pedido para chamada no balcão
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
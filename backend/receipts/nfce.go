@@ -0,0 +1,508 @@
+// Arquivo: backend/receipts/nfce.go
+package receipts
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"finplay/backend/models"
+	"finplay/backend/pricing"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modelo da NFC-e e versão do leiaute
+const (
+	nfceModel     = 65
+	layoutVersion = "4.00"
+	nfeNamespace  = "http://www.portalfiscal.inf.br/nfe"
+)
+
+// Meios de pagamento (tPag)
+const (
+	payCreditCard = "03"
+	payPix        = "17"
+	payNone       = "90"
+)
+
+// NCM por categoria do cardápio
+var categoryNCM = map[string]string{
+	"hamburguer": "21069090",
+	"bebidas":    "22029900",
+	"sobremesas": "21069090",
+}
+
+const defaultNCM = "21069090"
+
+// Cupom montado a partir do pedido; base do XML e do DANFE
+type Document struct {
+	OrderID     string
+	AccessKey   string
+	Number      int64
+	Series      int
+	Environment int // tpAmb
+	IssuedAt    time.Time
+	Items       []Item
+	// Totais em centavos: produtos - descontos + outros = total. Outros são os
+	// tributos cobrados à parte e a taxa de entrega (NFC-e não tem frete).
+	ProductsCents    int64
+	DiscountCents    int64
+	AddedTaxCents    int64
+	DeliveryFeeCents int64
+	OtherCents       int64
+	TotalCents       int64
+	TaxCents         int64 // tributos incidentes (Lei 12.741/2012)
+	Payments         []Payment
+	QRCodeURL        string
+}
+
+type Item struct {
+	Code          string
+	Description   string
+	NCM           string
+	Quantity      int
+	UnitCents     int64
+	TotalCents    int64 // quantidade × unitário
+	DiscountCents int64
+	OtherCents    int64 // tributos cobrados à parte e parte da taxa de entrega
+	TaxCents      int64
+}
+
+type Payment struct {
+	Method      string // tPag
+	AmountCents int64
+}
+
+func (p Payment) Label() string {
+	switch p.Method {
+	case payPix:
+		return "PIX"
+	case payCreditCard:
+		return "Cartão de Crédito"
+	}
+	return "Sem pagamento"
+}
+
+// Montar o cupom com os itens não cancelados e os pagamentos capturados. Os
+// tributos vêm do pedido; pedidos anteriores ao cálculo de tributos usam a
+// carga aproximada configurada.
+func buildDocument(order *models.Order, payments []models.Payment, number int64, issuedAt time.Time) *Document {
+	doc := &Document{
+		OrderID:          order.ID,
+		Number:           number,
+		Series:           settings.Series,
+		Environment:      environmentCode(),
+		IssuedAt:         issuedAt,
+		DeliveryFeeCents: order.DeliveryFeeCents,
+	}
+	taxed := len(order.Taxes) > 0
+
+	var weights []int64
+	for _, it := range order.Items {
+		active := it.ActiveQuantity()
+		if active <= 0 {
+			continue
+		}
+		addedTax := it.TaxCents * int64(active) / int64(it.Quantity)
+		net := it.NetCents(active) - addedTax
+		item := Item{
+			Code:        productCode(it.ProductCategory, it.ProductName),
+			Description: it.ProductName,
+			NCM:         ncmFor(it.ProductCategory),
+			Quantity:    active,
+			UnitCents:   it.PriceCents(),
+			TotalCents:  it.PriceCents() * int64(active),
+			OtherCents:  addedTax,
+		}
+		item.DiscountCents = item.TotalCents - net
+		if taxed {
+			item.TaxCents = addedTax + it.IncludedTaxCents*int64(active)/int64(it.Quantity)
+		} else {
+			item.TaxCents = int64(math.Round(float64(net) * settings.ApproxTaxPercent / 100))
+		}
+		doc.Items = append(doc.Items, item)
+		weights = append(weights, net)
+
+		doc.ProductsCents += item.TotalCents
+		doc.DiscountCents += item.DiscountCents
+		doc.AddedTaxCents += addedTax
+		doc.TaxCents += item.TaxCents
+	}
+
+	// Taxa de entrega rateada entre os itens (vOutro); sem valor para ratear
+	// (itens zerados por descontos), fica no primeiro
+	rest := doc.DeliveryFeeCents
+	for i, fee := range pricing.Allocate(doc.DeliveryFeeCents, weights) {
+		doc.Items[i].OtherCents += fee
+		rest -= fee
+	}
+	if rest > 0 && len(doc.Items) > 0 {
+		doc.Items[0].OtherCents += rest
+	}
+	for _, item := range doc.Items {
+		doc.OtherCents += item.OtherCents
+	}
+	doc.TotalCents = doc.ProductsCents - doc.DiscountCents + doc.OtherCents
+
+	for _, p := range payments {
+		paid := p.CapturedCents - p.RefundedCents
+		if p.Status != models.PaymentCaptured || paid <= 0 {
+			continue
+		}
+		method := payCreditCard
+		if p.Gateway == "pix" {
+			method = payPix
+		}
+		doc.Payments = append(doc.Payments, Payment{Method: method, AmountCents: paid})
+	}
+	if len(doc.Payments) == 0 {
+		doc.Payments = []Payment{{Method: payNone}}
+	}
+
+	doc.AccessKey = accessKey(settings.StateCode, issuedAt, settings.CNPJ, doc.Series, number, randomCode(order.ID, number))
+	doc.QRCodeURL = qrCodeURL(doc.AccessKey, doc.Environment)
+	return doc
+}
+
+func environmentCode() int {
+	if settings.Environment == "production" {
+		return 1
+	}
+	return 2
+}
+
+func ncmFor(category string) string {
+	if ncm, ok := categoryNCM[strings.ToLower(category)]; ok {
+		return ncm
+	}
+	return defaultNCM
+}
+
+// Código do produto: categoria e nome, sem espaços e em maiúsculas
+func productCode(category, name string) string {
+	code := strings.ToUpper(category + "-" + strings.Join(strings.Fields(name), "-"))
+	if len(code) > 60 {
+		code = code[:60]
+	}
+	return code
+}
+
+// Código numérico (cNF) de 8 dígitos derivado do pedido, diferente do número
+func randomCode(orderID string, number int64) int {
+	sum := sha256.Sum256([]byte(orderID))
+	code := int(binary.BigEndian.Uint32(sum[:4]) % 1e8)
+	if int64(code) == number {
+		code = (code + 1) % 1e8
+	}
+	return code
+}
+
+// Chave de acesso: cUF, AAMM, CNPJ, modelo, série, número, tpEmis, cNF e DV
+func accessKey(stateCode int, issuedAt time.Time, cnpj string, series int, number int64, code int) string {
+	key := fmt.Sprintf("%02d%s%s%02d%03d%09d%d%08d",
+		stateCode, issuedAt.Format("0601"), cnpj, nfceModel, series, number, 1, code)
+	return key + strconv.Itoa(checkDigit(key))
+}
+
+// Dígito verificador módulo 11 (pesos 2 a 9 da direita para a esquerda)
+func checkDigit(digits string) int {
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	dv := 11 - sum%11
+	if dv >= 10 {
+		return 0
+	}
+	return dv
+}
+
+// URL do QR Code (versão 2, emissão online): chave|versão|ambiente|idCSC|hash,
+// com hash = SHA-1 dos mesmos campos concatenados ao CSC
+func qrCodeURL(key string, environment int) string {
+	idCSC := strings.TrimLeft(settings.CSCID, "0")
+	params := fmt.Sprintf("%s|2|%d|%s", key, environment, idCSC)
+	sum := sha1.Sum([]byte(params + settings.CSC))
+	return settings.ConsultURL + "?p=" + params + "|" + strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// Valor monetário no formato do leiaute (ponto decimal, 2 casas)
+func decimal(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// XML da NFC-e (leiaute 4.00, Simples Nacional, consumidor não identificado)
+
+type nfeXML struct {
+	XMLName xml.Name   `xml:"NFe"`
+	Xmlns   string     `xml:"xmlns,attr,omitempty"`
+	InfNFe  infNFe     `xml:"infNFe"`
+	Supl    infNFeSupl `xml:"infNFeSupl"`
+}
+
+type infNFe struct {
+	ID      string  `xml:"Id,attr"`
+	Versao  string  `xml:"versao,attr"`
+	Ide     ide     `xml:"ide"`
+	Emit    emit    `xml:"emit"`
+	Det     []det   `xml:"det"`
+	Total   total   `xml:"total"`
+	Transp  transp  `xml:"transp"`
+	Pag     pag     `xml:"pag"`
+	InfAdic infAdic `xml:"infAdic"`
+}
+
+type ide struct {
+	CUF      int    `xml:"cUF"`
+	CNF      string `xml:"cNF"`
+	NatOp    string `xml:"natOp"`
+	Mod      int    `xml:"mod"`
+	Serie    int    `xml:"serie"`
+	NNF      int64  `xml:"nNF"`
+	DhEmi    string `xml:"dhEmi"`
+	TpNF     int    `xml:"tpNF"`
+	IdDest   int    `xml:"idDest"`
+	CMunFG   int    `xml:"cMunFG"`
+	TpImp    int    `xml:"tpImp"`
+	TpEmis   int    `xml:"tpEmis"`
+	CDV      string `xml:"cDV"`
+	TpAmb    int    `xml:"tpAmb"`
+	FinNFe   int    `xml:"finNFe"`
+	IndFinal int    `xml:"indFinal"`
+	IndPres  int    `xml:"indPres"`
+	ProcEmi  int    `xml:"procEmi"`
+	VerProc  string `xml:"verProc"`
+}
+
+type emit struct {
+	CNPJ      string    `xml:"CNPJ"`
+	XNome     string    `xml:"xNome"`
+	EnderEmit enderEmit `xml:"enderEmit"`
+	IE        string    `xml:"IE"`
+	CRT       int       `xml:"CRT"`
+}
+
+type enderEmit struct {
+	XLgr string `xml:"xLgr"`
+	Nro  string `xml:"nro"`
+	CMun int    `xml:"cMun"`
+	XMun string `xml:"xMun"`
+	UF   string `xml:"UF"`
+}
+
+type det struct {
+	NItem   int     `xml:"nItem,attr"`
+	Prod    prod    `xml:"prod"`
+	Imposto imposto `xml:"imposto"`
+}
+
+type prod struct {
+	CProd    string `xml:"cProd"`
+	CEAN     string `xml:"cEAN"`
+	XProd    string `xml:"xProd"`
+	NCM      string `xml:"NCM"`
+	CFOP     string `xml:"CFOP"`
+	UCom     string `xml:"uCom"`
+	QCom     string `xml:"qCom"`
+	VUnCom   string `xml:"vUnCom"`
+	VProd    string `xml:"vProd"`
+	CEANTrib string `xml:"cEANTrib"`
+	UTrib    string `xml:"uTrib"`
+	QTrib    string `xml:"qTrib"`
+	VUnTrib  string `xml:"vUnTrib"`
+	VDesc    string `xml:"vDesc,omitempty"`
+	VOutro   string `xml:"vOutro,omitempty"`
+	IndTot   int    `xml:"indTot"`
+}
+
+type imposto struct {
+	VTotTrib string `xml:"vTotTrib"`
+	ICMS     struct {
+		ICMSSN102 struct {
+			Orig  int    `xml:"orig"`
+			CSOSN string `xml:"CSOSN"`
+		} `xml:"ICMSSN102"`
+	} `xml:"ICMS"`
+}
+
+type total struct {
+	ICMSTot struct {
+		VBC      string `xml:"vBC"`
+		VICMS    string `xml:"vICMS"`
+		VProd    string `xml:"vProd"`
+		VDesc    string `xml:"vDesc"`
+		VOutro   string `xml:"vOutro"`
+		VNF      string `xml:"vNF"`
+		VTotTrib string `xml:"vTotTrib"`
+	} `xml:"ICMSTot"`
+}
+
+type transp struct {
+	ModFrete int `xml:"modFrete"`
+}
+
+type pag struct {
+	DetPag []detPag `xml:"detPag"`
+}
+
+type detPag struct {
+	TPag string `xml:"tPag"`
+	VPag string `xml:"vPag"`
+}
+
+type infAdic struct {
+	InfCpl string `xml:"infCpl"`
+}
+
+type infNFeSupl struct {
+	QRCode   string `xml:"qrCode"`
+	URLChave string `xml:"urlChave"`
+}
+
+// NFC-e autorizada: a nota mais o protocolo de autorização
+type nfeProcXML struct {
+	XMLName xml.Name `xml:"nfeProc"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Versao  string   `xml:"versao,attr"`
+	NFe     nfeXML   `xml:"NFe"`
+	ProtNFe struct {
+		Versao  string `xml:"versao,attr"`
+		InfProt struct {
+			TpAmb    int    `xml:"tpAmb"`
+			ChNFe    string `xml:"chNFe"`
+			DhRecbto string `xml:"dhRecbto"`
+			NProt    string `xml:"nProt"`
+			CStat    int    `xml:"cStat"`
+			XMotivo  string `xml:"xMotivo"`
+		} `xml:"infProt"`
+	} `xml:"protNFe"`
+}
+
+const dateTimeLayout = "2006-01-02T15:04:05-07:00"
+
+func (d *Document) nfe() nfeXML {
+	n := nfeXML{Xmlns: nfeNamespace}
+	inf := &n.InfNFe
+	inf.ID = "NFe" + d.AccessKey
+	inf.Versao = layoutVersion
+
+	inf.Ide = ide{
+		CUF:      settings.StateCode,
+		CNF:      d.AccessKey[35:43],
+		NatOp:    "VENDA",
+		Mod:      nfceModel,
+		Serie:    d.Series,
+		NNF:      d.Number,
+		DhEmi:    d.IssuedAt.Format(dateTimeLayout),
+		TpNF:     1, // saída
+		IdDest:   1, // operação interna
+		CMunFG:   settings.CityCode,
+		TpImp:    4, // DANFE NFC-e
+		TpEmis:   1, // emissão normal
+		CDV:      d.AccessKey[43:],
+		TpAmb:    d.Environment,
+		FinNFe:   1, // normal
+		IndFinal: 1, // consumidor final
+		IndPres:  1, // operação presencial
+		ProcEmi:  0, // aplicativo do contribuinte
+		VerProc:  "FinPlay",
+	}
+	inf.Emit = emit{
+		CNPJ:  settings.CNPJ,
+		XNome: settings.CompanyName,
+		EnderEmit: enderEmit{
+			XLgr: settings.Address,
+			Nro:  "S/N",
+			CMun: settings.CityCode,
+			XMun: settings.City,
+			UF:   settings.State,
+		},
+		IE:  settings.StateRegistration,
+		CRT: 1, // Simples Nacional
+	}
+
+	for i, it := range d.Items {
+		qty := fmt.Sprintf("%d.0000", it.Quantity)
+		line := det{NItem: i + 1}
+		line.Prod = prod{
+			CProd:    it.Code,
+			CEAN:     "SEM GTIN",
+			XProd:    it.Description,
+			NCM:      it.NCM,
+			CFOP:     "5102",
+			UCom:     "UN",
+			QCom:     qty,
+			VUnCom:   decimal(it.UnitCents),
+			VProd:    decimal(it.TotalCents),
+			CEANTrib: "SEM GTIN",
+			UTrib:    "UN",
+			QTrib:    qty,
+			VUnTrib:  decimal(it.UnitCents),
+			IndTot:   1,
+		}
+		if it.DiscountCents > 0 {
+			line.Prod.VDesc = decimal(it.DiscountCents)
+		}
+		if it.OtherCents > 0 {
+			line.Prod.VOutro = decimal(it.OtherCents)
+		}
+		line.Imposto.VTotTrib = decimal(it.TaxCents)
+		line.Imposto.ICMS.ICMSSN102.CSOSN = "102"
+		inf.Det = append(inf.Det, line)
+	}
+
+	t := &inf.Total.ICMSTot
+	t.VBC, t.VICMS = decimal(0), decimal(0)
+	t.VProd = decimal(d.ProductsCents)
+	t.VDesc = decimal(d.DiscountCents)
+	t.VOutro = decimal(d.OtherCents)
+	t.VNF = decimal(d.TotalCents)
+	t.VTotTrib = decimal(d.TaxCents)
+
+	inf.Transp.ModFrete = 9 // sem frete
+	for _, p := range d.Payments {
+		inf.Pag.DetPag = append(inf.Pag.DetPag, detPag{TPag: p.Method, VPag: decimal(p.AmountCents)})
+	}
+	inf.InfAdic.InfCpl = "Pedido " + d.OrderID
+
+	n.Supl = infNFeSupl{QRCode: d.QRCodeURL, URLChave: settings.ConsultURL}
+	return n
+}
+
+// XML da nota enviado ao autorizador
+func (d *Document) XML() ([]byte, error) {
+	out, err := xml.Marshal(d.nfe())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// XML de distribuição (nfeProc) com o protocolo de autorização
+func (d *Document) ProcXML(auth *Authorization) ([]byte, error) {
+	proc := nfeProcXML{Xmlns: nfeNamespace, Versao: layoutVersion, NFe: d.nfe()}
+	proc.NFe.Xmlns = ""
+	proc.ProtNFe.Versao = layoutVersion
+	p := &proc.ProtNFe.InfProt
+	p.TpAmb = d.Environment
+	p.ChNFe = d.AccessKey
+	p.DhRecbto = auth.ReceivedAt.In(d.IssuedAt.Location()).Format(dateTimeLayout)
+	p.NProt = auth.Protocol
+	p.CStat = auth.Status
+	p.XMotivo = auth.Message
+
+	out, err := xml.MarshalIndent(proc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,252 @@
+// Arquivo: backend/receipts/pdf.go
+package receipts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// DANFE NFC-e em bobina de 80 mm: uma página da altura do conteúdo, texto em
+// Courier (largura fixa) e QR Code desenhado em vetores.
+const (
+	pageWidth  = 226.0 // 80 mm em pontos
+	margin     = 10.0
+	fontSize   = 7.0
+	lineHeight = 9.0
+	columns    = 49 // caracteres por linha (Courier: 0,6 × tamanho da fonte)
+	qrModule   = 2.0
+)
+
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+// Gerar o PDF do cupom autorizado
+func RenderPDF(d *Document, auth *Authorization) ([]byte, error) {
+	qr, err := qrcode.New(d.QRCodeURL, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	qr.DisableBorder = true
+	bitmap := qr.Bitmap()
+
+	top, bottom := danfeLines(d, auth)
+	qrSize := float64(len(bitmap)) * qrModule
+	height := 2*margin + float64(len(top)+len(bottom))*lineHeight + qrSize + 2*lineHeight
+
+	var content bytes.Buffer
+	y := height - margin - fontSize
+	writeLines := func(lines []pdfLine) {
+		for _, l := range lines {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, fontSize, margin, y, pdfString(l.text))
+			y -= lineHeight
+		}
+	}
+
+	writeLines(top)
+
+	// QR Code centralizado
+	y -= lineHeight / 2
+	x0 := (pageWidth - qrSize) / 2
+	content.WriteString("0 g\n")
+	for row, modules := range bitmap {
+		for col, dark := range modules {
+			if dark {
+				fmt.Fprintf(&content, "%.2f %.2f %.2f %.2f re\n",
+					x0+float64(col)*qrModule, y-float64(row+1)*qrModule+fontSize, qrModule, qrModule)
+			}
+		}
+	}
+	content.WriteString("f\n")
+	y -= qrSize + lineHeight
+
+	writeLines(bottom)
+
+	return buildPDF(pageWidth, height, content.Bytes()), nil
+}
+
+// Texto do DANFE: bloco antes e depois do QR Code
+func danfeLines(d *Document, auth *Authorization) (top, bottom []pdfLine) {
+	add := func(dst *[]pdfLine, bold bool, text string) {
+		*dst = append(*dst, pdfLine{text: text, bold: bold})
+	}
+	center := func(text string) string {
+		n := utf8.RuneCountInString(text)
+		if n >= columns {
+			return text
+		}
+		return strings.Repeat(" ", (columns-n)/2) + text
+	}
+	pair := func(left, right string) string {
+		n := columns - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+		if n < 1 {
+			n = 1
+		}
+		return left + strings.Repeat(" ", n) + right
+	}
+	rule := strings.Repeat("-", columns)
+
+	for _, l := range wrap(strings.ToUpper(settings.CompanyName), columns) {
+		add(&top, true, center(l))
+	}
+	add(&top, false, center(fmt.Sprintf("CNPJ %s  IE %s", formatCNPJ(settings.CNPJ), settings.StateRegistration)))
+	for _, l := range wrap(fmt.Sprintf("%s - %s/%s", settings.Address, settings.City, settings.State), columns) {
+		add(&top, false, center(l))
+	}
+	add(&top, false, rule)
+	add(&top, true, center("DANFE NFC-e - Documento Auxiliar"))
+	add(&top, true, center("da Nota Fiscal de Consumidor Eletrônica"))
+	add(&top, false, rule)
+
+	add(&top, true, "#  DESCRIÇÃO          QTD UN  VL UNIT  VL TOTAL")
+	for i, it := range d.Items {
+		desc := []rune(it.Description)
+		if len(desc) > 18 {
+			desc = desc[:18]
+		}
+		add(&top, false, fmt.Sprintf("%-2d %-18s %3d UN %8s %9s", i+1, string(desc), it.Quantity, brl(it.UnitCents), brl(it.TotalCents)))
+	}
+	add(&top, false, rule)
+
+	add(&top, false, pair("Qtde. total de itens", fmt.Sprint(len(d.Items))))
+	add(&top, false, pair("Valor total R$", brl(d.ProductsCents)))
+	if d.DiscountCents > 0 {
+		add(&top, false, pair("Descontos R$", "-"+brl(d.DiscountCents)))
+	}
+	if d.AddedTaxCents > 0 {
+		add(&top, false, pair("Tributos R$", brl(d.AddedTaxCents)))
+	}
+	if d.DeliveryFeeCents > 0 {
+		add(&top, false, pair("Taxa de entrega R$", brl(d.DeliveryFeeCents)))
+	}
+	add(&top, true, pair("Valor a pagar R$", brl(d.TotalCents)))
+	add(&top, false, pair("FORMA DE PAGAMENTO", "VALOR PAGO R$"))
+	for _, p := range d.Payments {
+		add(&top, false, pair(p.Label(), brl(p.AmountCents)))
+	}
+	add(&top, false, rule)
+
+	add(&top, true, center("Consulte pela Chave de Acesso em"))
+	for _, l := range wrap(settings.ConsultURL, columns) {
+		add(&top, false, center(l))
+	}
+	add(&top, false, center(groupDigits(d.AccessKey)))
+	add(&top, false, rule)
+	add(&top, true, center("CONSUMIDOR NÃO IDENTIFICADO"))
+	add(&top, false, rule)
+	add(&top, true, center(fmt.Sprintf("NFC-e nº %09d Série %03d %s", d.Number, d.Series, d.IssuedAt.Format("02/01/2006 15:04:05"))))
+	add(&top, false, center("Protocolo de autorização: "+auth.Protocol))
+	add(&top, false, center("Data de autorização: "+auth.ReceivedAt.In(d.IssuedAt.Location()).Format(time.DateTime)))
+
+	add(&bottom, false, rule)
+	add(&bottom, false, center("Tributos Totais Incidentes"))
+	add(&bottom, false, center(fmt.Sprintf("(Lei Federal 12.741/2012) R$ %s", brl(d.TaxCents))))
+	add(&bottom, false, center("Pedido "+d.OrderID))
+	if d.Environment != 1 {
+		add(&bottom, true, center("EMITIDA EM AMBIENTE DE HOMOLOGAÇÃO"))
+		add(&bottom, true, center("SEM VALOR FISCAL"))
+	}
+	return top, bottom
+}
+
+// Valor em reais com vírgula decimal e ponto de milhar
+func brl(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	reais := fmt.Sprint(cents / 100)
+	for i := len(reais) - 3; i > 0; i -= 3 {
+		reais = reais[:i] + "." + reais[i:]
+	}
+	return fmt.Sprintf("%s%s,%02d", sign, reais, cents%100)
+}
+
+func formatCNPJ(cnpj string) string {
+	if len(cnpj) != 14 {
+		return cnpj
+	}
+	return cnpj[:2] + "." + cnpj[2:5] + "." + cnpj[5:8] + "/" + cnpj[8:12] + "-" + cnpj[12:]
+}
+
+// Chave de acesso em grupos de 4 dígitos
+func groupDigits(key string) string {
+	var groups []string
+	for i := 0; i < len(key); i += 4 {
+		groups = append(groups, key[i:min(i+4, len(key))])
+	}
+	return strings.Join(groups, " ")
+}
+
+// Quebrar o texto em linhas de até width caracteres
+func wrap(text string, width int) []string {
+	var lines []string
+	runes := []rune(text)
+	for len(runes) > width {
+		cut := width
+		if i := strings.LastIndex(string(runes[:width]), " "); i > 0 {
+			cut = utf8.RuneCountInString(string(runes[:width])[:i])
+		}
+		lines = append(lines, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	return append(lines, string(runes))
+}
+
+// Texto em WinAnsiEncoding (Latin-1 cobre o português) com escapes do PDF
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x80:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Montar o arquivo PDF: catálogo, página, fontes padrão e o conteúdo
+func buildPDF(width, height float64, content []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", width, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
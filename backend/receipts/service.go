@@ -0,0 +1,108 @@
+// Arquivo: backend/receipts/service.go
+package receipts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"finplay/backend/config"
+	"finplay/backend/models"
+	"log/slog"
+	"time"
+)
+
+var ErrOrderNotCompleted = errors.New("cupom fiscal disponível apenas para pedidos finalizados")
+
+// Emissão sem resposta após esse tempo é considerada abandonada
+const issueLockTimeout = time.Minute
+
+var (
+	settings            = config.Default().Receipts
+	location            = time.UTC
+	authority Authority = NewStub()
+)
+
+// Aplicar a configuração carregada. loc é o fuso da loja (data de emissão).
+func Configure(cfg config.ReceiptsConfig, loc *time.Location) {
+	settings = cfg
+	if loc != nil {
+		location = loc
+	}
+	switch cfg.Authority {
+	case "stub":
+		authority = NewStub()
+	}
+}
+
+// Substituir o autorizador (testes e integrações)
+func SetAuthority(a Authority) {
+	authority = a
+}
+
+// Cupom do pedido finalizado, emitindo-o na primeira chamada: reserva o
+// número, monta o XML, autoriza e gera o PDF. Rejeições ficam registradas e a
+// próxima chamada tenta de novo com o mesmo número.
+func Issue(ctx context.Context, db *sql.DB, order *models.Order) (*models.Receipt, error) {
+	if order.Status != models.OrderCompleted {
+		return nil, ErrOrderNotCompleted
+	}
+
+	r, reserved, err := models.ReserveReceipt(db, order.ID, settings.Series, issueLockTimeout)
+	if err != nil || !reserved {
+		return r, err
+	}
+
+	if err := issue(ctx, db, order, r); err != nil {
+		if rejectErr := models.RejectReceipt(db, order.ID, err.Error()); rejectErr != nil {
+			slog.ErrorContext(ctx, "erro ao registrar rejeição do cupom", "order_id", order.ID, "error", rejectErr)
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+func issue(ctx context.Context, db *sql.DB, order *models.Order, r *models.Receipt) error {
+	payments, err := models.GetOrderPayments(db, order.ID, order.UserID)
+	if err != nil {
+		return err
+	}
+
+	issuedAt := time.Now().In(location).Truncate(time.Second)
+	doc := buildDocument(order, payments, r.Number, issuedAt)
+	nfe, err := doc.XML()
+	if err != nil {
+		return err
+	}
+
+	auth, err := authority.Authorize(ctx, AuthorizeRequest{
+		AccessKey:   doc.AccessKey,
+		Environment: doc.Environment,
+		XML:         nfe,
+	})
+	if err != nil {
+		return err
+	}
+	if !auth.Authorized {
+		return &RejectedError{Status: auth.Status, Message: auth.Message}
+	}
+
+	r.XML, err = doc.ProcXML(auth)
+	if err != nil {
+		return err
+	}
+	r.PDF, err = RenderPDF(doc, auth)
+	if err != nil {
+		return err
+	}
+	r.AccessKey = doc.AccessKey
+	r.Protocol = auth.Protocol
+	r.IssuedAt = &issuedAt
+	r.AuthorizedAt = &auth.ReceivedAt
+	if err := models.AuthorizeReceipt(db, r); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "NFC-e autorizada", "order_id", order.ID, "number", r.Number,
+		"access_key", r.AccessKey, "authority", authority.Name())
+	return nil
+}
@@ -0,0 +1,63 @@
+// Arquivo: backend/receipts/stub.go
+package receipts
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Autorizador local para desenvolvimento e testes: confere a chave de acesso
+// e autoriza, com protocolo no formato da SEFAZ. Não transmite nada.
+type StubAuthority struct {
+	mu   sync.Mutex
+	seen map[string]string // chave de acesso -> protocolo
+}
+
+func NewStub() *StubAuthority {
+	return &StubAuthority{seen: make(map[string]string)}
+}
+
+func (s *StubAuthority) Name() string {
+	return "stub"
+}
+
+func (s *StubAuthority) Authorize(ctx context.Context, req AuthorizeRequest) (*Authorization, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(req.AccessKey) != 44 || checkDigit(req.AccessKey[:43]) != int(req.AccessKey[43]-'0') {
+		return &Authorization{Status: 236, Message: "Rejeição: Chave de Acesso com dígito verificador inválido"}, nil
+	}
+	if !bytes.Contains(req.XML, []byte(`Id="NFe`+req.AccessKey+`"`)) {
+		return &Authorization{Status: 502, Message: "Rejeição: Erro na Chave de Acesso - Campo Id não corresponde à concatenação dos campos correspondentes"}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Reenvio da mesma nota devolve o protocolo já emitido
+	protocol, ok := s.seen[req.AccessKey]
+	if !ok {
+		n, err := rand.Int(rand.Reader, big.NewInt(1e10))
+		if err != nil {
+			return nil, err
+		}
+		// cUF + tipo de autorizador + ano + sequencial
+		protocol = fmt.Sprintf("%s1%s%010d", req.AccessKey[:2], time.Now().Format("06"), n.Int64())
+		s.seen[req.AccessKey] = protocol
+	}
+
+	return &Authorization{
+		Authorized: true,
+		Status:     100,
+		Message:    "Autorizado o uso da NF-e",
+		Protocol:   protocol,
+		ReceivedAt: time.Now(),
+	}, nil
+}
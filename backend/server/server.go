@@ -0,0 +1,110 @@
+// Arquivo: backend/server/server.go
+
+// Servidor HTTP com timeouts configuráveis e desligamento gracioso:
+// em SIGINT/SIGTERM para de aceitar conexões, aguarda as requisições em
+// andamento e as tarefas em segundo plano e só então retorna.
+package server
+
+import (
+	"context"
+	"errors"
+	"finplay/backend/config"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	draining     = make(chan struct{})
+	drainingOnce sync.Once
+	background   sync.WaitGroup
+)
+
+// Canal fechado quando o desligamento começa. Respostas de longa duração
+// (ex.: streams SSE) devem observá-lo e encerrar o envio, já que
+// http.Server.Shutdown não interrompe conexões ativas.
+func Draining() <-chan struct{} {
+	return draining
+}
+
+// Executar tarefa em segundo plano que o desligamento deve aguardar
+// (ex.: geração de exportações), em vez de um "go" solto.
+func Go(task func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		task()
+	}()
+}
+
+// Subir o servidor (HTTPS se cfg.TLS estiver habilitado) e bloquear até um
+// sinal de término (retorna nil) ou uma falha ao escutar a porta.
+func Run(cfg config.ServerConfig, handler http.Handler) error {
+	tlsCfg, err := tlsConfig(cfg.TLS)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		TLSConfig:         tlsCfg,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	srv.RegisterOnShutdown(func() {
+		drainingOnce.Do(func() { close(draining) })
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tlsCfg != nil {
+			// Certificados já carregados em TLSConfig
+			serveErr <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-stop:
+		slog.Info("sinal recebido, encerrando servidor", "signal", sig.String(), "timeout", cfg.ShutdownTimeout.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("requisições não finalizadas dentro do prazo", "error", err)
+		srv.Close()
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("tarefas em segundo plano não finalizadas dentro do prazo")
+	}
+
+	slog.Info("servidor encerrado")
+	return nil
+}
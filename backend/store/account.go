@@ -0,0 +1,13 @@
+// Arquivo: backend/store/account.go
+
+// Tipos trocados entre o middleware e os armazenamentos em models: o
+// middleware define as interfaces que consome e models as implementa sobre o
+// PostgreSQL, sem que um pacote importe o outro.
+package store
+
+// Situação atual da conta, consultada a cada requisição autenticada
+type Account struct {
+	Active       bool // ativa e não excluída
+	TokenVersion int
+	Role         string
+}
@@ -0,0 +1,22 @@
+// Arquivo: backend/store/idempotency.go
+package store
+
+import "time"
+
+// Requisição original sem resposta após esse tempo é considerada abandonada
+const IdempotencyLockTimeout = time.Minute
+
+// Resposta guardada da requisição original
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Registro de uma chave: Response é nil enquanto a requisição original está
+// em andamento
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *IdempotentResponse
+	CreatedAt   time.Time
+}
@@ -304,7 +304,13 @@ const Documentation = () => {
                             <strong>POST /api/chat:</strong> Chat com IA (Groq)
                         </li>
                         <li className="documentation-list-item">
-                            <strong>GET /health:</strong> Health check do servidor
+                            <strong>GET /api/products:</strong> Cardápio com preços e grupos de opções (retirar, adicionais, pão, tamanho)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /healthz:</strong> Liveness (processo respondendo)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /readyz:</strong> Readiness (banco, schema e LLM; 503 se indisponível)
                         </li>
                     </ul>
                 </div>
@@ -316,10 +322,52 @@ const Documentation = () => {
                             <strong>GET /api/auth/me:</strong> Retorna dados do usuário autenticado
                         </li>
                         <li className="documentation-list-item">
-                            <strong>POST /api/orders:</strong> Criar novo pedido
+                            <strong>POST /api/orders:</strong> Criar novo pedido (aplica promoções, o cupom em coupon_code, os pontos em redeem_points, os tributos e, com delivery, a taxa de entrega)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET/PUT/DELETE /api/cart:</strong> Carrinho do usuário, com o valor recalculado a cada leitura
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>POST /api/cart/items | PUT/DELETE /api/cart/items?id=uuid:</strong> Adicionar, alterar quantidade ou remover item do carrinho
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>POST /api/cart/checkout:</strong> Criar pedido com o carrinho (aceita Idempotency-Key)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/loyalty:</strong> Saldo de pontos de fidelidade e extrato
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/promotions:</strong> Promoções automáticas vigentes
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/orders/{'{id}'}/receipt?format=pdf|xml:</strong> Cupom fiscal (NFC-e) do pedido finalizado
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>POST /api/orders/pay?id=uuid:</strong> Pagar pedido (autoriza e captura no gateway)
                         </li>
                         <li className="documentation-list-item">
-                            <strong>POST /api/orders/complete?id=uuid:</strong> Finalizar pedido
+                            <strong>POST /api/orders/pix?id=uuid:</strong> Gerar cobrança PIX (QR Code e copia e cola)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/orders/pix?id=uuid:</strong> Consultar status da cobrança PIX
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>POST /api/orders/complete?id=uuid:</strong> Finalizar pedido já pago (equipe da loja, confirma a entrega e credita os pontos)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/orders/ticket?id=uuid:</strong> Comanda da cozinha com as opções de cada item (equipe da loja, pedido pago)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/payments?order_id=uuid:</strong> Tentativas de pagamento do pedido
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>GET /api/orders/detail?id=uuid:</strong> Detalhe do pedido com pagamentos e estornos
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>POST /api/orders/items/cancel?id=uuid:</strong> Cancelar itens (recalcula o total e estorna se já pago)
+                        </li>
+                        <li className="documentation-list-item">
+                            <strong>POST /api/payments/refund?id=uuid:</strong> Estorno total ou parcial pela equipe da loja (amount_cents, reason)
                         </li>
                         <li className="documentation-list-item">
                             <strong>GET /api/orders/history:</strong> Buscar histórico de pedidos
@@ -411,10 +459,10 @@ const Documentation = () => {
                             <strong>quantity:</strong> INTEGER (quantidade do produto)
                         </li>
                         <li className="documentation-list-item">
-                            <strong>price:</strong> DECIMAL(10,2) (valor unitário)
+                            <strong>price:</strong> DECIMAL(10,2) (valor unitário com os acréscimos das opções)
                         </li>
                         <li className="documentation-list-item">
-                            <strong>ingredients:</strong> TEXT (JSON string com ingredientes)
+                            <strong>ingredients:</strong> TEXT (observação livre do item; as opções ficam em order_item_modifiers)
                         </li>
                     </ul>
                 </div>
@@ -518,7 +566,13 @@ const Documentation = () => {
                             Retorna ID do pedido → Frontend exibe confirmação
                         </li>
                         <li className="documentation-list-item">
-                            Usuário clica "Finalizar" → POST /api/orders/complete?id=uuid
+                            Usuário paga → POST /api/orders/pay?id=uuid (status: paid)
+                        </li>
+                        <li className="documentation-list-item">
+                            Ou via PIX → POST /api/orders/pix?id=uuid; o PSP notifica POST /api/webhooks/pix (status: paid)
+                        </li>
+                        <li className="documentation-list-item">
+                            Equipe da loja confirma a entrega → POST /api/orders/complete?id=uuid
                         </li>
                         <li className="documentation-list-item">
                             Atualiza status para completed → Define completed_at = now()
@@ -32,7 +32,7 @@ export const sendMessageToGPT = async (message, history = []) => {
 
 export const checkServerHealth = async () => {
     try {
-        const response = await fetch(`${API_BASE_URL}/health`);
+        const response = await fetch(`${API_BASE_URL}/readyz`);
         return response.ok;
     } catch (error) {
         return false;
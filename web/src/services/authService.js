@@ -56,6 +56,11 @@ export const login = async (email, password) => {
             throw new Error(data.error || 'Erro no login');
         }
 
+        // Com 2FA ativo o backend devolve apenas um token intermediário
+        if (data.mfa_required) {
+            return data;
+        }
+
         localStorage.setItem('auth_token', data.token);
         localStorage.setItem('user', JSON.stringify(data.user));
 
@@ -66,6 +71,38 @@ export const login = async (email, password) => {
     }
 };
 
+// Segunda etapa do login (código TOTP ou código de recuperação)
+export const loginWithMFA = async (mfaToken, { code, recoveryCode } = {}) => {
+    try {
+        const response = await fetch(`${API_URL}/api/auth/login/2fa`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            credentials: 'include',
+            body: JSON.stringify({
+                mfa_token: mfaToken,
+                code,
+                recovery_code: recoveryCode
+            })
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Código inválido');
+        }
+
+        localStorage.setItem('auth_token', data.token);
+        localStorage.setItem('user', JSON.stringify(data.user));
+
+        return data;
+    } catch (error) {
+        console.error('Erro na verificação 2FA:', error);
+        throw error;
+    }
+};
+
 // Logout
 export const logout = async () => {
     try {
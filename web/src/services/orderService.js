@@ -4,8 +4,17 @@ import { getToken } from './authService';
 
 const API_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080';
 
-// Criar novo pedido
-export const createOrder = async (items, notes = '') => {
+// Chave para deduplicar POSTs no backend: gere uma por tentativa de checkout
+// e reutilize-a nas repetições (duplo clique, retry após falha de rede)
+export const newIdempotencyKey = () => crypto.randomUUID();
+
+// Criar novo pedido. Cada item pode trazer modifiers ([{ group, option }], ver
+// getProducts) e uma observação em ingredients. Promoções vigentes, cupom,
+// pontos resgatados, tributos e taxa de entrega são aplicados no backend; o
+// detalhamento vem em
+// subtotal_cents, discount_cents, tax_cents, delivery_fee_cents, discounts,
+// taxes e loyalty_discount_cents)
+export const createOrder = async (items, notes = '', couponCode = '', redeemPoints = 0, delivery = false, idempotencyKey = newIdempotencyKey()) => {
     try {
         const token = getToken();
         
@@ -13,12 +22,16 @@ export const createOrder = async (items, notes = '') => {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
-                'Authorization': `Bearer ${token}`
+                'Authorization': `Bearer ${token}`,
+                'Idempotency-Key': idempotencyKey
             },
             credentials: 'include',
             body: JSON.stringify({
                 items,
-                notes
+                notes,
+                coupon_code: couponCode,
+                redeem_points: redeemPoints,
+                delivery
             })
         });
 
@@ -35,6 +48,145 @@ export const createOrder = async (items, notes = '') => {
     }
 };
 
+// Pagar pedido (o pedido precisa estar pago para ser finalizado)
+export const payOrder = async (orderId, paymentMethod, idempotencyKey = newIdempotencyKey()) => {
+    try {
+        const token = getToken();
+        
+        const response = await fetch(`${API_URL}/api/orders/pay?id=${orderId}`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${token}`,
+                'Idempotency-Key': idempotencyKey
+            },
+            credentials: 'include',
+            body: JSON.stringify({
+                payment_method: paymentMethod
+            })
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao processar pagamento');
+        }
+
+        return data;
+    } catch (error) {
+        console.error('Erro ao processar pagamento:', error);
+        throw error;
+    }
+};
+
+// Gerar cobrança PIX do pedido (copia e cola + QR Code em base64)
+export const createPixCharge = async (orderId) => {
+    try {
+        const token = getToken();
+        
+        const response = await fetch(`${API_URL}/api/orders/pix?id=${orderId}`, {
+            method: 'POST',
+            headers: {
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include'
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao gerar cobrança PIX');
+        }
+
+        return data;
+    } catch (error) {
+        console.error('Erro ao gerar cobrança PIX:', error);
+        throw error;
+    }
+};
+
+// Consultar cobrança PIX (status pending → captured quando o PIX é recebido)
+export const getPixCharge = async (orderId) => {
+    try {
+        const token = getToken();
+        
+        const response = await fetch(`${API_URL}/api/orders/pix?id=${orderId}`, {
+            headers: {
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include'
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao consultar cobrança PIX');
+        }
+
+        return data;
+    } catch (error) {
+        console.error('Erro ao consultar cobrança PIX:', error);
+        throw error;
+    }
+};
+
+// Detalhe do pedido (itens, pagamentos e histórico de estornos)
+export const getOrderDetail = async (orderId) => {
+    try {
+        const token = getToken();
+        
+        const response = await fetch(`${API_URL}/api/orders/detail?id=${orderId}`, {
+            headers: {
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include'
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao buscar pedido');
+        }
+
+        return data;
+    } catch (error) {
+        console.error('Erro ao buscar pedido:', error);
+        throw error;
+    }
+};
+
+// Cancelar itens do pedido ([{ item_id, quantity }]); pedidos pagos são estornados
+export const cancelOrderItems = async (orderId, items, reason, note) => {
+    try {
+        const token = getToken();
+        
+        const response = await fetch(`${API_URL}/api/orders/items/cancel?id=${orderId}`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include',
+            body: JSON.stringify({
+                items,
+                reason,
+                note
+            })
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao cancelar itens');
+        }
+
+        return data;
+    } catch (error) {
+        console.error('Erro ao cancelar itens:', error);
+        throw error;
+    }
+};
+
 // Finalizar pedido
 export const completeOrder = async (orderId) => {
     try {
@@ -111,4 +263,227 @@ export const cancelOrder = async (orderId) => {
         console.error('Erro ao cancelar pedido:', error);
         throw error;
     }
+};
+// Promoções automáticas vigentes (rota pública)
+export const getPromotions = async () => {
+    try {
+        const response = await fetch(`${API_URL}/api/promotions`, {
+            credentials: 'include'
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao buscar promoções');
+        }
+
+        return data.promotions;
+    } catch (error) {
+        console.error('Erro ao buscar promoções:', error);
+        throw error;
+    }
+};
+
+// Cardápio com preços e grupos de opções (rota pública)
+export const getProducts = async () => {
+    try {
+        const response = await fetch(`${API_URL}/api/products`, {
+            credentials: 'include'
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao buscar cardápio');
+        }
+
+        return data.products;
+    } catch (error) {
+        console.error('Erro ao buscar cardápio:', error);
+        throw error;
+    }
+};
+
+// Saldo de pontos de fidelidade e extrato
+export const getLoyalty = async () => {
+    try {
+        const token = getToken();
+
+        const response = await fetch(`${API_URL}/api/loyalty`, {
+            headers: {
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include'
+        });
+
+        const data = await response.json();
+
+        if (!response.ok) {
+            throw new Error(data.error || 'Erro ao buscar pontos');
+        }
+
+        return data;
+    } catch (error) {
+        console.error('Erro ao buscar pontos:', error);
+        throw error;
+    }
+};
+
+// Cupom fiscal (NFC-e) do pedido finalizado: Blob em PDF (DANFE) ou XML
+export const getOrderReceipt = async (orderId, format = 'pdf') => {
+    try {
+        const token = getToken();
+
+        const response = await fetch(`${API_URL}/api/orders/${orderId}/receipt?format=${format}`, {
+            headers: {
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include'
+        });
+
+        if (!response.ok) {
+            const data = await response.json();
+            throw new Error(data.error || 'Erro ao buscar cupom fiscal');
+        }
+
+        return await response.blob();
+    } catch (error) {
+        console.error('Erro ao buscar cupom fiscal:', error);
+        throw error;
+    }
+};
+
+// Comanda da cozinha do pedido pago (texto na largura da bobina; equipe da loja)
+export const getKitchenTicket = async (orderId) => {
+    try {
+        const token = getToken();
+
+        const response = await fetch(`${API_URL}/api/orders/ticket?id=${orderId}`, {
+            headers: {
+                'Authorization': `Bearer ${token}`
+            },
+            credentials: 'include'
+        });
+
+        if (!response.ok) {
+            const data = await response.json();
+            throw new Error(data.error || 'Erro ao buscar comanda');
+        }
+
+        return await response.text();
+    } catch (error) {
+        console.error('Erro ao buscar comanda:', error);
+        throw error;
+    }
+};
+
+// Requisição autenticada às rotas do carrinho (o backend devolve o carrinho
+// com preços, promoções, tributos e taxa de entrega recalculados)
+const cartRequest = async (path, method = 'GET', body, extraHeaders = {}) => {
+    const token = getToken();
+    const headers = {
+        'Authorization': `Bearer ${token}`,
+        ...extraHeaders
+    };
+    if (body !== undefined) {
+        headers['Content-Type'] = 'application/json';
+    }
+
+    const response = await fetch(`${API_URL}${path}`, {
+        method,
+        headers,
+        credentials: 'include',
+        body: body !== undefined ? JSON.stringify(body) : undefined
+    });
+
+    if (response.status === 204) {
+        return null;
+    }
+
+    const data = await response.json();
+
+    if (!response.ok) {
+        throw new Error(data.error || 'Erro ao processar carrinho');
+    }
+
+    return data;
+};
+
+// Carrinho do usuário (itens fora do cardápio vêm com available = false e
+// problemas com cupom ou pontos em warnings)
+export const getCart = async () => {
+    try {
+        return await cartRequest('/api/cart');
+    } catch (error) {
+        console.error('Erro ao buscar carrinho:', error);
+        throw error;
+    }
+};
+
+// Substituir itens e opções do carrinho
+export const replaceCart = async (items, notes = '', couponCode = '', redeemPoints = 0, delivery = false) => {
+    try {
+        return await cartRequest('/api/cart', 'PUT', {
+            items,
+            notes,
+            coupon_code: couponCode,
+            redeem_points: redeemPoints,
+            delivery
+        });
+    } catch (error) {
+        console.error('Erro ao atualizar carrinho:', error);
+        throw error;
+    }
+};
+
+// Esvaziar carrinho
+export const clearCart = async () => {
+    try {
+        await cartRequest('/api/cart', 'DELETE');
+    } catch (error) {
+        console.error('Erro ao esvaziar carrinho:', error);
+        throw error;
+    }
+};
+
+// Adicionar item (mesmo produto, opções e observação soma a quantidade)
+export const addCartItem = async (item) => {
+    try {
+        return await cartRequest('/api/cart/items', 'POST', item);
+    } catch (error) {
+        console.error('Erro ao adicionar item ao carrinho:', error);
+        throw error;
+    }
+};
+
+// Alterar quantidade de um item (0 remove)
+export const updateCartItem = async (itemId, quantity) => {
+    try {
+        return await cartRequest(`/api/cart/items?id=${itemId}`, 'PUT', { quantity });
+    } catch (error) {
+        console.error('Erro ao alterar item do carrinho:', error);
+        throw error;
+    }
+};
+
+// Remover item do carrinho
+export const removeCartItem = async (itemId) => {
+    try {
+        return await cartRequest(`/api/cart/items?id=${itemId}`, 'DELETE');
+    } catch (error) {
+        console.error('Erro ao remover item do carrinho:', error);
+        throw error;
+    }
+};
+
+// Criar pedido com o carrinho e esvaziá-lo
+export const checkoutCart = async (idempotencyKey = newIdempotencyKey()) => {
+    try {
+        return await cartRequest('/api/cart/checkout', 'POST', undefined, {
+            'Idempotency-Key': idempotencyKey
+        });
+    } catch (error) {
+        console.error('Erro ao finalizar carrinho:', error);
+        throw error;
+    }
 };
\ No newline at end of file